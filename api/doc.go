@@ -0,0 +1,4 @@
+// Package api contains the OpenAPI document of the API, openapi.yaml. Its
+// tests check the document against the routes in template.yaml and the
+// responses of the handlers.
+package api
@@ -0,0 +1,515 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"regexp"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+// methods are the operations of path items in OpenAPI.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var _ = Describe("openapi.yaml", func() {
+	var doc map[string]interface{}
+
+	BeforeEach(func() {
+		doc = readYAML("openapi.yaml")
+	})
+
+	It("should be an OpenAPI 3 document", func() {
+		Expect(doc["openapi"]).To(HavePrefix("3."))
+	})
+
+	It("should only have references which resolve", func() {
+		var refs []string
+		walk(doc, func(m map[string]interface{}) {
+			if ref, ok := m["$ref"].(string); ok {
+				refs = append(refs, ref)
+			}
+		})
+
+		Expect(refs).NotTo(BeEmpty())
+		for _, ref := range refs {
+			Expect(resolve(doc, ref)).NotTo(BeNil(), ref)
+		}
+	})
+
+	It("should document exactly the routes of template.yaml", func() {
+		var documented []string
+		for path, item := range doc["paths"].(map[string]interface{}) {
+			for _, method := range methods {
+				if _, ok := item.(map[string]interface{})[method]; ok {
+					documented = append(documented, method+" "+path)
+				}
+			}
+		}
+
+		sort.Strings(documented)
+		Expect(documented).To(Equal(templateRoutes()))
+	})
+})
+
+var _ = Describe("responses of the handlers", func() {
+	var (
+		doc                 map[string]interface{}
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoCreator     servicefakes.FakeInfoCreator
+		fakeInfoGetter      servicefakes.FakeInfoGetter
+		fakeInfoLister      servicefakes.FakeInfoLister
+		fakeInfoMetaGetter  servicefakes.FakeInfoMetaGetter
+		fakeInfoUpdater     servicefakes.FakeInfoUpdater
+		fakeInfoDeleter     servicefakes.FakeInfoDeleter
+		fakeUsageGetter     servicefakes.FakeUsageGetter
+		limiter             ratelimit.Limiter
+		limits              ratelimit.Limits
+		ctx                 context.Context
+	)
+
+	info := service.Info{
+		ID:          "folder/info-id",
+		Value:       "info value",
+		Owner:       "owner",
+		CreatedAt:   time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC),
+		ContentType: service.DefaultContentType,
+		ContentHash: "hash",
+		Version:     2,
+	}
+
+	BeforeEach(func() {
+		doc = readYAML("openapi.yaml")
+		limiter = ratelimit.NewMemoryLimiter()
+		limits = nil
+		ctx = context.Background()
+
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceStub = func(name string) (service.Namespace, error) {
+			return service.Namespace{Name: name}, nil
+		}
+		fakeInfoCreator = servicefakes.FakeInfoCreator{}
+		fakeInfoCreator.CreateInfoReturns(info, nil)
+		fakeInfoGetter = servicefakes.FakeInfoGetter{}
+		fakeInfoGetter.GetInfoReturns(info, nil)
+		fakeInfoLister = servicefakes.FakeInfoLister{}
+		fakeInfoLister.ListInfosReturns(service.InfoList{Prefix: "folder/"}, nil)
+		fakeInfoMetaGetter = servicefakes.FakeInfoMetaGetter{}
+		fakeInfoMetaGetter.GetInfoMetaReturns(service.InfoMeta{
+			ID:          info.ID,
+			Size:        int64(len(info.Value)),
+			ContentType: info.ContentType,
+			CreatedAt:   info.CreatedAt,
+			UpdatedAt:   info.UpdatedAt,
+			Version:     info.Version,
+			ContentHash: info.ContentHash,
+			Tags:        map[string]string{"k": "v"},
+		}, nil)
+		fakeInfoUpdater = servicefakes.FakeInfoUpdater{}
+		fakeInfoUpdater.UpdateInfoReturns(info, nil)
+		fakeInfoDeleter = servicefakes.FakeInfoDeleter{}
+		fakeInfoDeleter.DeleteInfosReturns(service.DeletedInfos{Deleted: 3}, nil)
+		fakeUsageGetter = servicefakes.FakeUsageGetter{}
+		fakeUsageGetter.GetUsageReturns(service.Usage{Namespace: "team-a", ItemCount: 1, ByteCount: 10}, nil)
+	})
+
+	// serve returns the response of the handlers to the request.
+	serve := func(method, path string, headers map[string]string, body string) events.APIGatewayProxyResponse {
+		router := routes.NewRouter(routes.Services{
+			RateLimiter:     limiter,
+			RateLimits:      limits,
+			NamespaceGetter: &fakeNamespaceGetter,
+			InfoCreator:     &fakeInfoCreator,
+			InfoGetter:      &fakeInfoGetter,
+			InfoLister:      &fakeInfoLister,
+			InfoMetaGetter:  &fakeInfoMetaGetter,
+			InfoUpdater:     &fakeInfoUpdater,
+			InfoDeleter:     &fakeInfoDeleter,
+			UsageGetter:     &fakeUsageGetter,
+		})
+
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod:            method,
+			Path:                  strings.Split(path, "?")[0],
+			Headers:               headers,
+			QueryStringParameters: map[string]string{},
+			Body:                  body,
+		}
+
+		if i := strings.Index(path, "?"); i >= 0 {
+			request.QueryStringParameters[path[i+1:]] = ""
+		}
+
+		response, err := middleware.Chain(router.Handler, middleware.Common(config.Default())...)(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		return response
+	}
+
+	DescribeTable("documented responses",
+		func(method, path string, headers map[string]string, body string, setUp func(), expectedStatusCode int) {
+			if setUp != nil {
+				setUp()
+			}
+
+			response := serve(method, path, headers, body)
+			Expect(response.StatusCode).To(Equal(expectedStatusCode))
+			expectDocumented(doc, method, strings.Split(path, "?")[0], response)
+		},
+		Entry("create", "POST", "/i", nil, "info value", nil, 201),
+		Entry("create with id", "POST", "/n/team-a/i/folder/info-id", map[string]string{"Content-Type": "application/vnd.info+json"},
+			`{"value": "info value"}`, nil, 201),
+		Entry("create with id mismatch", "POST", "/i/folder/info-id", map[string]string{"Content-Type": "application/vnd.info+json"},
+			`{"id": "other", "value": "info value"}`, nil, 400),
+		Entry("create with invalid envelope", "POST", "/i", map[string]string{"Content-Type": "application/vnd.info+json"},
+			`{}`, nil, 400),
+		Entry("create existing", "POST", "/i/folder/info-id", nil, "info value", func() {
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, service.InfoAlreadyExistsError{InfoID: "folder/info-id"})
+		}, 409),
+		Entry("create over quota", "POST", "/i", nil, "info value", func() {
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, service.QuotaExceededError{})
+		}, 403),
+		Entry("get raw value", "GET", "/i/folder/info-id", nil, "", nil, 200),
+		Entry("get envelope", "GET", "/n/team-a/i/folder/info-id", map[string]string{"Accept": "application/vnd.info+json"}, "", nil, 200),
+		Entry("get metadata", "GET", "/i/folder/info-id/meta", nil, "", nil, 200),
+		Entry("get children", "GET", "/i/folder?list", nil, "", nil, 200),
+		Entry("get not modified", "GET", "/i/folder/info-id", map[string]string{"If-None-Match": `"hash-2"`}, "", nil, 304),
+		Entry("get range", "GET", "/i/folder/info-id", map[string]string{"Range": "bytes=0-3"}, "", nil, 206),
+		Entry("get ranges", "GET", "/i/folder/info-id", map[string]string{"Range": "bytes=0-1,4-5"}, "", nil, 206),
+		Entry("get unsatisfiable range", "GET", "/i/folder/info-id", map[string]string{"Range": "bytes=100-"}, "", nil, 416),
+		Entry("get not acceptable", "GET", "/i/folder/info-id", map[string]string{"Accept": "image/png"}, "", nil, 406),
+		Entry("get not found", "GET", "/i/folder/info-id", nil, "", func() {
+			fakeInfoGetter.GetInfoReturns(service.Info{}, service.InfoNotFoundError{InfoID: "folder/info-id"})
+		}, 404),
+		Entry("get invalid id", "GET", "/i/folder/info-id", nil, "", func() {
+			fakeInfoGetter.GetInfoReturns(service.Info{}, service.InvalidIDError{InfoID: "folder/info-id"})
+		}, 400),
+		Entry("get internal error", "GET", "/i/folder/info-id", nil, "", func() {
+			fakeInfoGetter.GetInfoReturns(service.Info{}, fmt.Errorf("test error"))
+		}, 500),
+		Entry("get throttled", "GET", "/i/folder/info-id", nil, "", func() {
+			fakeInfoGetter.GetInfoReturns(service.Info{}, service.UnavailableError{RetryAfter: time.Second, Err: fmt.Errorf("throttled")})
+		}, 503),
+		Entry("head throttled", "HEAD", "/i/folder/info-id", nil, "", func() {
+			fakeInfoMetaGetter.GetInfoMetaReturns(service.InfoMeta{}, service.UnavailableError{RetryAfter: time.Second, Err: fmt.Errorf("throttled")})
+		}, 503),
+		Entry("get in unknown namespace", "GET", "/n/unknown/i/folder/info-id", nil, "", func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, service.NamespaceNotFoundError{Namespace: "unknown"})
+		}, 404),
+		Entry("get with not allowed authentication method", "GET", "/n/team-a/i/folder/info-id", nil, "", func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: "team-a", AuthMethods: []string{"iam"}}, nil)
+		}, 403),
+		Entry("head", "HEAD", "/n/team-a/i/folder/info-id", nil, "", nil, 200),
+		Entry("head not found", "HEAD", "/i/folder/info-id", nil, "", func() {
+			fakeInfoMetaGetter.GetInfoMetaReturns(service.InfoMeta{}, service.InfoNotFoundError{InfoID: "folder/info-id"})
+		}, 404),
+		Entry("update", "PUT", "/i/folder/info-id", nil, "info value", nil, 200),
+		Entry("update too long", "PUT", "/n/team-a/i/folder/info-id", nil, "info value", func() {
+			fakeInfoUpdater.UpdateInfoReturns(service.Info{}, service.ValueTooLongError{})
+		}, 400),
+		Entry("update concurrently", "PUT", "/i/folder/info-id", nil, "info value", func() {
+			fakeInfoUpdater.UpdateInfoReturns(service.Info{}, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conflict", nil))
+		}, 409),
+		Entry("delete", "DELETE", "/i/folder/info-id", nil, "", nil, 204),
+		Entry("delete recursively", "DELETE", "/n/team-a/i/folder?recursive", nil, "", nil, 200),
+		Entry("usage", "GET", "/usage", nil, "", nil, 200),
+		Entry("usage of namespace", "GET", "/n/team-a/usage", nil, "", nil, 200),
+		Entry("options", "OPTIONS", "/n/team-a/i/folder/info-id", nil, "", nil, 204),
+	)
+
+	It("should document the response of exceeded rate limits", func() {
+		limits = ratelimit.Limits{ratelimit.DefaultRoute: {Rate: 1, Burst: 1}}
+
+		serve("GET", "/usage", nil, "")
+		response := serve("GET", "/usage", nil, "")
+		Expect(response.StatusCode).To(Equal(429))
+		expectDocumented(doc, "GET", "/usage", response)
+	})
+
+	It("should document the response of requests at the deadline", func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), middleware.DeadlineMargin/2)
+		defer cancel()
+
+		for _, method := range []string{"GET", "HEAD"} {
+			response := serve(method, "/i/folder/info-id", nil, "")
+			Expect(response.StatusCode).To(Equal(503))
+			expectDocumented(doc, method, "/i/folder/info-id", response)
+		}
+	})
+})
+
+// expectDocumented expects the response to the request to be documented
+// with its status code, headers and content.
+func expectDocumented(doc map[string]interface{}, method, path string, response events.APIGatewayProxyResponse) {
+	operation := operationOf(doc, strings.ToLower(method), path)
+	Expect(operation).NotTo(BeNil(), "%s %s is not documented", method, path)
+
+	responses := operation["responses"].(map[string]interface{})
+	documented, ok := responses[strconv.Itoa(response.StatusCode)].(map[string]interface{})
+	Expect(ok).To(BeTrue(), "%d of %s %s is not documented", response.StatusCode, method, path)
+	documented = deref(doc, documented)
+
+	headers, _ := documented["headers"].(map[string]interface{})
+	for name := range response.Headers {
+		if name == "Content-Type" || strings.HasPrefix(name, "Access-Control-") {
+			continue
+		}
+
+		Expect(headers).To(HaveKey(name), "header %s of %d of %s %s is not documented", name, response.StatusCode, method, path)
+	}
+
+	content, _ := documented["content"].(map[string]interface{})
+	if response.Body == "" || method == "HEAD" {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(response.Headers["Content-Type"])
+	Expect(err).ShouldNot(HaveOccurred(), "response of %s %s has no Content-Type", method, path)
+	Expect(content).To(HaveKey(mediaType), "%s of %d of %s %s is not documented", mediaType, response.StatusCode, method, path)
+
+	if strings.HasSuffix(mediaType, "json") {
+		var body interface{}
+		Expect(json.Unmarshal([]byte(response.Body), &body)).To(Succeed())
+
+		schema := content[mediaType].(map[string]interface{})["schema"].(map[string]interface{})
+		Expect(validate(doc, schema, body)).To(Succeed(), "body of %s %s: %s", method, path, response.Body)
+	}
+}
+
+// operationOf returns the operation of the method on the path.
+func operationOf(doc map[string]interface{}, method, path string) map[string]interface{} {
+	for template, item := range doc["paths"].(map[string]interface{}) {
+		var pattern string
+		for _, segment := range strings.Split(template, "/") {
+			switch {
+			case segment == "{id}":
+				// Ids may contain slashes.
+				pattern += "/.+"
+			case strings.HasPrefix(segment, "{"):
+				pattern += "/[^/]+"
+			case segment != "":
+				pattern += "/" + regexp.QuoteMeta(segment)
+			}
+		}
+
+		if regexp.MustCompile("^" + pattern + "$").MatchString(path) {
+			if operation, ok := item.(map[string]interface{})[method].(map[string]interface{}); ok {
+				return operation
+			}
+		}
+	}
+
+	return nil
+}
+
+// validate returns an error if the value does not conform to the schema. It
+// supports the subset of JSON schema used in openapi.yaml.
+func validate(doc map[string]interface{}, schema map[string]interface{}, value interface{}) error {
+	schema = deref(doc, schema)
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		var errs []string
+		for _, s := range oneOf {
+			err := validate(doc, s.(map[string]interface{}), value)
+			if err == nil {
+				return nil
+			}
+
+			errs = append(errs, err.Error())
+		}
+
+		return fmt.Errorf("none of oneOf matches: %s", strings.Join(errs, "; "))
+	}
+
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+
+		return fmt.Errorf("null is not nullable")
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v is not an object", value)
+		}
+
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s is required", name)
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, v := range object {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				property, ok = schema["additionalProperties"].(map[string]interface{})
+			}
+
+			if !ok {
+				return fmt.Errorf("property %s is not documented", name)
+			}
+
+			if err := validate(doc, property, v); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v is not an array", value)
+		}
+
+		for i, item := range array {
+			if err := validate(doc, schema["items"].(map[string]interface{}), item); err != nil {
+				return fmt.Errorf("[%d]: %v", i, err)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%v is not a string", value)
+		}
+
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			for _, e := range enum {
+				if e == s {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("%s is not in %v", s, enum)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%v is not an integer", value)
+		}
+	}
+
+	return nil
+}
+
+// templateRoutes returns the routes of the per-route functions in
+// template.yaml as "method path" with OpenAPI path parameters, sorted.
+func templateRoutes() []string {
+	template := readYAML("../template.yaml")
+
+	var routes []string
+	for name, resource := range template["Resources"].(map[string]interface{}) {
+		// The hello world function is a sample, not a route of the API.
+		if name == "HelloWorldFunction" {
+			continue
+		}
+
+		properties, _ := resource.(map[string]interface{})["Properties"].(map[string]interface{})
+		events, _ := properties["Events"].(map[string]interface{})
+		for _, event := range events {
+			event := event.(map[string]interface{})
+			eventProperties := event["Properties"].(map[string]interface{})
+
+			// The proxy routes of the api function serve the same routes.
+			if event["Type"] != "Api" || eventProperties["RestApiId"] != nil {
+				continue
+			}
+
+			path := strings.Replace(eventProperties["Path"].(string), "+}", "}", -1)
+			routes = append(routes, strings.ToLower(eventProperties["Method"].(string))+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	return routes
+}
+
+// readYAML returns the YAML document in the file with maps of string keys,
+// like JSON documents.
+func readYAML(filename string) map[string]interface{} {
+	b, err := ioutil.ReadFile(filename)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	var v interface{}
+	Expect(yaml.Unmarshal(b, &v)).To(Succeed())
+	return stringKeys(v).(map[string]interface{})
+}
+
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, value := range v {
+			m[fmt.Sprint(key)] = stringKeys(value)
+		}
+
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = stringKeys(value)
+		}
+	}
+
+	return v
+}
+
+// resolve returns the value of the local reference, e.g.
+// #/components/schemas/Problem, or nil if it does not resolve.
+func resolve(doc map[string]interface{}, ref string) map[string]interface{} {
+	var v interface{} = doc
+	for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		v = m[name]
+	}
+
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// deref returns the value referenced by the object if it is a reference.
+func deref(doc map[string]interface{}, m map[string]interface{}) map[string]interface{} {
+	if ref, ok := m["$ref"].(string); ok {
+		return resolve(doc, ref)
+	}
+
+	return m
+}
+
+// walk calls fn with every object in the document.
+func walk(v interface{}, fn func(map[string]interface{})) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		fn(v)
+		for _, value := range v {
+			walk(value, fn)
+		}
+	case []interface{}:
+		for _, value := range v {
+			walk(value, fn)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// Package client is a typed Go client of the information store API.
+//
+//	c := client.New("https://api.example.com/Prod", client.WithAPIKey(key))
+//	id, err := c.Create(ctx, "", "value")
+//	info, err := c.Get(ctx, id)
+//
+// Errors of the API are returned as the error types of the client, e.g.
+// InfoNotFoundError, or as Problem if there is no such type.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Media types of the API.
+const (
+	typeText     = "text/plain"
+	typeEnvelope = "application/vnd.info+json"
+)
+
+// metaSegment is the last segment of the path of the metadata of an info.
+const metaSegment = "meta"
+
+// Client calls the API at its base URL.
+type Client struct {
+	baseURL    string
+	namespace  string
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures a client.
+type Option func(*Client)
+
+// WithHTTPClient makes the client send requests with the HTTP client, e.g.
+// one with timeouts or a transport which signs requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithNamespace makes the client access infos in the namespace instead of
+// the default namespace.
+func WithNamespace(namespace string) Option {
+	return func(c *Client) {
+		c.namespace = namespace
+	}
+}
+
+// WithAPIKey makes the client authenticate with the API key.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithRetries sets how often idempotent calls are retried and the backoff
+// before the first retry, which doubles with each retry. Retry-After of
+// the API takes precedence over the backoff.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client of the API at the base URL, e.g.
+// https://{apiId}.execute-api.{region}.amazonaws.com/Prod.
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: 2,
+		backoff:    100 * time.Millisecond,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Info is an info with its metadata.
+type Info struct {
+	ID        string
+	Value     string
+	Owner     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ExpiresAt time.Time
+	Version   int64
+	ETag      string
+}
+
+// Meta is the metadata of an info.
+type Meta struct {
+	ID          string            `json:"id"`
+	Size        int64             `json:"size"`
+	ContentType string            `json:"contentType"`
+	CreatedAt   *time.Time        `json:"createdAt"`
+	UpdatedAt   *time.Time        `json:"updatedAt"`
+	ExpiresAt   *time.Time        `json:"expiresAt"`
+	Version     int64             `json:"version"`
+	ETag        string            `json:"etag"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// List is the direct children of a folder.
+type List struct {
+	Prefix  string   `json:"prefix"`
+	IDs     []string `json:"ids"`
+	Folders []string `json:"folders"`
+}
+
+// Create creates an info with the value and returns its id. An id is
+// generated if id is empty. Create is not retried, since it is not
+// idempotent.
+func (c *Client) Create(ctx context.Context, id, value string) (string, error) {
+	path := c.collectionPath()
+	if id != "" {
+		path = c.infoPath(id)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := c.do(ctx, call{method: http.MethodPost, path: path, body: value, id: id, value: value}, &created)
+	return created.ID, err
+}
+
+// Get returns the info with the id.
+func (c *Client) Get(ctx context.Context, id string) (Info, error) {
+	var body envelope
+	err := c.do(ctx, call{method: http.MethodGet, path: c.infoPath(id), accept: typeEnvelope, id: id}, &body)
+	if err != nil {
+		return Info{}, err
+	}
+
+	return Info{
+		ID:        body.ID,
+		Value:     body.Value,
+		Owner:     body.Meta.Owner,
+		CreatedAt: timeOf(body.Meta.CreatedAt),
+		UpdatedAt: timeOf(body.Meta.UpdatedAt),
+		ExpiresAt: timeOf(body.Meta.ExpiresAt),
+		Version:   body.Meta.Version,
+		ETag:      body.Meta.ETag,
+	}, nil
+}
+
+// Meta returns the metadata of the info with the id.
+func (c *Client) Meta(ctx context.Context, id string) (Meta, error) {
+	var meta Meta
+	err := c.do(ctx, call{method: http.MethodGet, path: c.infoPath(id) + "/" + metaSegment, id: id}, &meta)
+	return meta, err
+}
+
+// Update updates the value of the info with the id.
+func (c *Client) Update(ctx context.Context, id, value string) error {
+	return c.do(ctx, call{method: http.MethodPut, path: c.infoPath(id), body: value, id: id, value: value}, nil)
+}
+
+// Delete deletes the info with the id.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: c.infoPath(id), id: id}, nil)
+}
+
+// DeleteAll deletes all infos under the folder prefix page by page and
+// returns how many are deleted.
+func (c *Client) DeleteAll(ctx context.Context, prefix string) (int, error) {
+	deleted, query := 0, "recursive"
+	for {
+		var page struct {
+			Deleted int    `json:"deleted"`
+			Next    string `json:"next"`
+		}
+		err := c.do(ctx, call{method: http.MethodDelete, path: c.infoPath(prefix), query: query, id: prefix}, &page)
+		deleted += page.Deleted
+		if err != nil || page.Next == "" {
+			return deleted, err
+		}
+
+		query = "recursive&after=" + url.QueryEscape(page.Next)
+	}
+}
+
+// List returns the direct children of the folder prefix, e.g. folder/.
+func (c *Client) List(ctx context.Context, prefix string) (List, error) {
+	var list List
+	err := c.do(ctx, call{method: http.MethodGet, path: c.infoPath(prefix), query: "list", id: prefix}, &list)
+	return list, err
+}
+
+// envelope is the JSON representation of an info.
+type envelope struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+	Meta  struct {
+		Owner     string     `json:"owner"`
+		CreatedAt *time.Time `json:"createdAt"`
+		UpdatedAt *time.Time `json:"updatedAt"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+		Version   int64      `json:"version"`
+		ETag      string     `json:"etag"`
+	} `json:"meta"`
+}
+
+// call is a call of the API.
+type call struct {
+	method string
+	path   string
+	query  string
+	accept string
+	body   string
+
+	// id and value are the id and value the call is about, to return errors
+	// of the service.
+	id    string
+	value string
+}
+
+// idempotent returns if the call may be retried.
+func (c call) idempotent() bool {
+	switch c.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// do sends the call, retrying idempotent calls, and decodes the JSON
+// response body into v if v is not nil.
+func (c *Client) do(ctx context.Context, call call, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		response, err := c.send(ctx, call)
+		if err == nil && response.StatusCode < 300 {
+			defer response.Body.Close()
+			if v == nil {
+				io.Copy(ioutil.Discard, response.Body)
+				return nil
+			}
+
+			return json.NewDecoder(response.Body).Decode(v)
+		}
+
+		// Failures of the network are retried like unavailable backends.
+		retry := true
+		var retryAfter time.Duration
+		if err == nil {
+			retry = retryable(response.StatusCode)
+			retryAfter = retryAfterOf(response)
+			err = errorOf(response, call)
+			response.Body.Close()
+		}
+
+		if !retry || !call.idempotent() || attempt >= c.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		wait := c.backoff << uint(attempt)
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// send sends the call once.
+func (c *Client) send(ctx context.Context, call call) (*http.Response, error) {
+	u := c.baseURL + call.path
+	if call.query != "" {
+		u += "?" + call.query
+	}
+
+	var body io.Reader
+	if call.body != "" || call.method == http.MethodPost || call.method == http.MethodPut {
+		body = strings.NewReader(call.body)
+	}
+
+	request, err := http.NewRequest(call.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	request = request.WithContext(ctx)
+	if body != nil {
+		request.Header.Set("Content-Type", typeText)
+	}
+
+	if call.accept != "" {
+		request.Header.Set("Accept", call.accept)
+	}
+
+	if c.apiKey != "" {
+		request.Header.Set("X-Api-Key", c.apiKey)
+	}
+
+	return c.httpClient.Do(request)
+}
+
+// collectionPath returns the path of the infos in the namespace.
+func (c *Client) collectionPath() string {
+	if c.namespace == "" {
+		return "/i"
+	}
+
+	return "/n/" + url.PathEscape(c.namespace) + "/i"
+}
+
+// infoPath returns the path of the info with the id in the namespace.
+func (c *Client) infoPath(id string) string {
+	segments := strings.Split(id, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return c.collectionPath() + "/" + strings.Join(segments, "/")
+}
+
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
@@ -0,0 +1,327 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/httpadapter"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoCreator     servicefakes.FakeInfoCreator
+		fakeInfoGetter      servicefakes.FakeInfoGetter
+		fakeInfoLister      servicefakes.FakeInfoLister
+		fakeInfoUpdater     servicefakes.FakeInfoUpdater
+		fakeInfoDeleter     servicefakes.FakeInfoDeleter
+
+		// unavailable is the number of requests answered with 503 before
+		// requests are passed to the API.
+		unavailable int32
+		requests    int32
+
+		server *httptest.Server
+		c      *client.Client
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, nil)
+		fakeInfoCreator = servicefakes.FakeInfoCreator{}
+		fakeInfoGetter = servicefakes.FakeInfoGetter{}
+		fakeInfoLister = servicefakes.FakeInfoLister{}
+		fakeInfoUpdater = servicefakes.FakeInfoUpdater{}
+		fakeInfoDeleter = servicefakes.FakeInfoDeleter{}
+		unavailable = 0
+		requests = 0
+		ctx = context.Background()
+
+		api := httpadapter.Handler(middleware.Chain(routes.NewRouter(routes.Services{
+			RateLimiter:     ratelimit.NewMemoryLimiter(),
+			NamespaceGetter: &fakeNamespaceGetter,
+			InfoCreator:     &fakeInfoCreator,
+			InfoGetter:      &fakeInfoGetter,
+			InfoLister:      &fakeInfoLister,
+			InfoUpdater:     &fakeInfoUpdater,
+			InfoDeleter:     &fakeInfoDeleter,
+		}).Handler, middleware.Common(config.Default())...), httpadapter.Options{})
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) <= atomic.LoadInt32(&unavailable) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			api.ServeHTTP(w, r)
+		}))
+		c = client.New(server.URL, client.WithRetries(2, time.Millisecond))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Create()", func() {
+		BeforeEach(func() {
+			fakeInfoCreator.CreateInfoReturns(service.Info{ID: "info-id"}, nil)
+		})
+
+		It("should create an info and return its id", func() {
+			id, err := c.Create(ctx, "", "info value")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(id).To(Equal("info-id"))
+
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(1))
+			_, _, _, _, value, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(value).To(Equal("info value"))
+		})
+
+		It("should create an info with the id", func() {
+			_, err := c.Create(ctx, "folder/info id", "info value")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, _, _, id, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(id).To(Equal("folder/info id"))
+		})
+
+		It("should return ValueTooLongError", func() {
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, service.ValueTooLongError{AllowedLen: 1000, ActualLen: 1001})
+
+			_, err := c.Create(ctx, "", "info value")
+			Expect(err).To(Equal(client.ValueTooLongError{AllowedLen: 1000, ActualLen: 1001}))
+		})
+
+		It("should return InfoAlreadyExistsError", func() {
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, service.InfoAlreadyExistsError{InfoID: "info-id"})
+
+			_, err := c.Create(ctx, "info-id", "info value")
+			Expect(err).To(Equal(client.InfoAlreadyExistsError{InfoID: "info-id"}))
+		})
+
+		It("should return QuotaExceededError", func() {
+			quotaExceeded := service.QuotaExceededError{Namespace: "default", Owner: "owner", Quota: "infos", Limit: 10}
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, quotaExceeded)
+
+			_, err := c.Create(ctx, "", "info value")
+			Expect(err).To(Equal(client.QuotaExceededError{Namespace: "default", Owner: "owner", Quota: "infos", Limit: 10}))
+		})
+
+		It("should not be retried", func() {
+			unavailable = 1
+
+			_, err := c.Create(ctx, "", "info value")
+			var p client.Problem
+			Expect(errors.As(err, &p)).To(BeTrue())
+			Expect(p.Status).To(Equal(503))
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Get()", func() {
+		var updatedAt time.Time
+
+		BeforeEach(func() {
+			updatedAt = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+			fakeInfoGetter.GetInfoReturns(service.Info{ID: "folder/info-id", Value: "info value", UpdatedAt: updatedAt, Version: 2}, nil)
+		})
+
+		It("should return the info", func() {
+			info, err := c.Get(ctx, "folder/info-id")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.ID).To(Equal("folder/info-id"))
+			Expect(info.Value).To(Equal("info value"))
+			Expect(info.UpdatedAt.Equal(updatedAt)).To(BeTrue())
+			Expect(info.Version).To(Equal(int64(2)))
+
+			_, _, id := fakeInfoGetter.GetInfoArgsForCall(0)
+			Expect(id).To(Equal("folder/info-id"))
+		})
+
+		It("should return InfoNotFoundError", func() {
+			fakeInfoGetter.GetInfoReturns(service.Info{}, service.InfoNotFoundError{InfoID: "info-id"})
+
+			_, err := c.Get(ctx, "info-id")
+			Expect(err).To(Equal(client.InfoNotFoundError{InfoID: "info-id"}))
+		})
+
+		It("should return InvalidIDError", func() {
+			fakeInfoGetter.GetInfoReturns(service.Info{}, service.InvalidIDError{InfoID: "a#b", Reason: "reserved character"})
+
+			_, err := c.Get(ctx, "a#b")
+			Expect(err).To(Equal(client.InvalidIDError{InfoID: "a#b", Reason: "reserved character"}))
+		})
+
+		It("should be retried if the API is unavailable", func() {
+			unavailable = 2
+
+			info, err := c.Get(ctx, "folder/info-id")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Value).To(Equal("info value"))
+			Expect(requests).To(Equal(int32(3)))
+		})
+
+		It("should give up after the retries", func() {
+			unavailable = 3
+
+			_, err := c.Get(ctx, "folder/info-id")
+			var p client.Problem
+			Expect(errors.As(err, &p)).To(BeTrue())
+			Expect(p.Status).To(Equal(503))
+			Expect(requests).To(Equal(int32(3)))
+		})
+
+		It("should not be retried if the context is done", func() {
+			unavailable = 1
+			cancelledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, err := c.Get(cancelledCtx, "folder/info-id")
+			Expect(err).Should(HaveOccurred())
+			Expect(requests).To(Equal(int32(0)))
+		})
+	})
+
+	Describe("Update()", func() {
+		It("should update the info", func() {
+			err := c.Update(ctx, "info-id", "new value")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, _, _, id, value, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			Expect(id).To(Equal("info-id"))
+			Expect(value).To(Equal("new value"))
+		})
+
+		It("should send the value again when it is retried", func() {
+			unavailable = 1
+
+			err := c.Update(ctx, "info-id", "new value")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, _, _, _, value, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			Expect(value).To(Equal("new value"))
+		})
+
+		It("should return InfoNotFoundError", func() {
+			fakeInfoUpdater.UpdateInfoReturns(service.Info{}, service.InfoNotFoundError{InfoID: "info-id"})
+
+			err := c.Update(ctx, "info-id", "new value")
+			Expect(err).To(Equal(client.InfoNotFoundError{InfoID: "info-id"}))
+		})
+
+		It("should return NotOwnerError", func() {
+			fakeInfoUpdater.UpdateInfoReturns(service.Info{}, service.NotOwnerError{InfoID: "info-id"})
+
+			err := c.Update(ctx, "info-id", "new value")
+			Expect(err).To(Equal(client.NotOwnerError{InfoID: "info-id"}))
+		})
+	})
+
+	Describe("Delete()", func() {
+		It("should delete the info", func() {
+			err := c.Delete(ctx, "info-id")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, _, _, id := fakeInfoDeleter.DeleteInfoArgsForCall(0)
+			Expect(id).To(Equal("info-id"))
+		})
+	})
+
+	Describe("DeleteAll()", func() {
+		It("should delete the infos under the folder page by page", func() {
+			fakeInfoDeleter.DeleteInfosReturnsOnCall(0, service.DeletedInfos{Deleted: 2, Next: "folder/b c"}, nil)
+			fakeInfoDeleter.DeleteInfosReturnsOnCall(1, service.DeletedInfos{Deleted: 1}, nil)
+
+			deleted, err := c.DeleteAll(ctx, "folder/")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).To(Equal(3))
+
+			Expect(fakeInfoDeleter.DeleteInfosCallCount()).To(Equal(2))
+			_, _, _, prefix, after := fakeInfoDeleter.DeleteInfosArgsForCall(0)
+			Expect(prefix).To(Equal("folder/"))
+			Expect(after).To(BeEmpty())
+			_, _, _, _, after = fakeInfoDeleter.DeleteInfosArgsForCall(1)
+			Expect(after).To(Equal("folder/b c"))
+		})
+	})
+
+	Describe("List()", func() {
+		It("should return the children of the folder", func() {
+			fakeInfoLister.ListInfosReturns(service.InfoList{Prefix: "folder/", IDs: []string{"folder/a"}, Folders: []string{"folder/b/"}}, nil)
+
+			list, err := c.List(ctx, "folder/")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list).To(Equal(client.List{Prefix: "folder/", IDs: []string{"folder/a"}, Folders: []string{"folder/b/"}}))
+		})
+	})
+
+	When("the client uses a namespace", func() {
+		BeforeEach(func() {
+			c = client.New(server.URL, client.WithNamespace("other"))
+		})
+
+		It("should access infos in the namespace", func() {
+			_, err := c.Get(ctx, "info-id")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal("other"))
+		})
+
+		It("should return NamespaceNotFoundError", func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, service.NamespaceNotFoundError{Namespace: "other"})
+
+			_, err := c.Get(ctx, "info-id")
+			Expect(err).To(Equal(client.NamespaceNotFoundError{Namespace: "other"}))
+		})
+
+		It("should return PrincipalNotAllowedError", func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: "other", Principals: []string{"key-a"}}, nil)
+
+			_, err := c.Get(ctx, "info-id")
+			Expect(err).To(Equal(client.PrincipalNotAllowedError{Namespace: "other"}))
+		})
+	})
+
+	When("the client has an HTTP client", func() {
+		var transport countingTransport
+
+		BeforeEach(func() {
+			transport = countingTransport{}
+			c = client.New(server.URL, client.WithHTTPClient(&http.Client{Transport: &transport}), client.WithAPIKey("api-key"))
+		})
+
+		It("should send requests with it", func() {
+			_, err := c.Get(ctx, "info-id")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(transport.requests).To(Equal(1))
+			Expect(transport.apiKey).To(Equal("api-key"))
+		})
+	})
+})
+
+// countingTransport counts the requests sent with it.
+type countingTransport struct {
+	requests int
+	apiKey   string
+}
+
+func (t *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.requests++
+	t.apiKey = request.Header.Get("X-Api-Key")
+	return http.DefaultTransport.RoundTrip(request)
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Types of the problems of the API, which the client returns as errors.
+const (
+	typeValueTooLong        = "/problems/value-too-long"
+	typeInvalidID           = "/problems/invalid-id"
+	typeInfoNotFound        = "/problems/info-not-found"
+	typeInfoAlreadyExists   = "/problems/info-already-exists"
+	typeNotOwner            = "/problems/not-owner"
+	typePrincipalNotAllowed = "/problems/principal-not-allowed"
+	typeNamespaceNotFound   = "/problems/namespace-not-found"
+	typeQuotaExceeded       = "/problems/quota-exceeded"
+)
+
+// ValueTooLongError is returned if the value is longer than allowed.
+type ValueTooLongError struct {
+	AllowedLen int
+	ActualLen  int
+}
+
+func (err ValueTooLongError) Error() string {
+	return fmt.Sprintf("The length of the value is %d, however max. %d allowed.", err.ActualLen, err.AllowedLen)
+}
+
+// InvalidIDError is returned if the id is not valid.
+type InvalidIDError struct {
+	InfoID string
+	Reason string
+}
+
+func (err InvalidIDError) Error() string {
+	return fmt.Sprintf("Id %s is invalid: %s.", err.InfoID, err.Reason)
+}
+
+// InfoNotFoundError is returned if there is no info with the id.
+type InfoNotFoundError struct {
+	InfoID string
+}
+
+func (err InfoNotFoundError) Error() string {
+	return fmt.Sprintf("Info with id %s does not exist.", err.InfoID)
+}
+
+// InfoAlreadyExistsError is returned if an info with the id exists already.
+type InfoAlreadyExistsError struct {
+	InfoID string
+}
+
+func (err InfoAlreadyExistsError) Error() string {
+	return fmt.Sprintf("Info with id %s already exists.", err.InfoID)
+}
+
+// NotOwnerError is returned if the info is owned by another principal.
+type NotOwnerError struct {
+	InfoID string
+}
+
+func (err NotOwnerError) Error() string {
+	return fmt.Sprintf("Info with id %s is owned by another principal.", err.InfoID)
+}
+
+// PrincipalNotAllowedError is returned if the namespace does not allow the
+// principal of the client.
+type PrincipalNotAllowedError struct {
+	Namespace string
+}
+
+func (err PrincipalNotAllowedError) Error() string {
+	return fmt.Sprintf("The principal is not allowed in namespace %s.", err.Namespace)
+}
+
+// NamespaceNotFoundError is returned if the namespace does not exist.
+type NamespaceNotFoundError struct {
+	Namespace string
+}
+
+func (err NamespaceNotFoundError) Error() string {
+	return fmt.Sprintf("Namespace %s does not exist.", err.Namespace)
+}
+
+// QuotaExceededError is returned if the namespace, or the owner in it, has
+// reached a quota, e.g. of infos.
+type QuotaExceededError struct {
+	Namespace string
+	Owner     string
+	Quota     string
+	Limit     int64
+}
+
+func (err QuotaExceededError) Error() string {
+	if err.Owner != "" {
+		return fmt.Sprintf("Owner %s has reached the quota of %d %s in namespace %s.", err.Owner, err.Limit, err.Quota, err.Namespace)
+	}
+
+	return fmt.Sprintf("Namespace %s has reached its quota of %d %s.", err.Namespace, err.Limit, err.Quota)
+}
+
+// Problem is the error of a response of the API which is not one of the
+// errors above, e.g. 429 or 500.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+func (p Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+
+	return p.Title
+}
+
+// problemBody is the problem+json body of a response with the extension
+// members of the problems returned as errors.
+type problemBody struct {
+	Problem
+	AllowedLen int    `json:"allowedLen"`
+	ActualLen  int    `json:"actualLen"`
+	Reason     string `json:"reason"`
+	Namespace  string `json:"namespace"`
+	Owner      string `json:"owner"`
+	Quota      string `json:"quota"`
+	Limit      int64  `json:"limit"`
+}
+
+// errorOf returns the error of the unsuccessful response to the call.
+func errorOf(response *http.Response, call call) error {
+	var body problemBody
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil || body.Type == "" {
+		body = problemBody{Problem: Problem{
+			Status: response.StatusCode,
+			Title:  http.StatusText(response.StatusCode),
+		}}
+	}
+
+	if body.Status == 0 {
+		body.Status = response.StatusCode
+	}
+
+	switch body.Type {
+	case typeValueTooLong:
+		return ValueTooLongError{AllowedLen: body.AllowedLen, ActualLen: body.ActualLen}
+	case typeInvalidID:
+		return InvalidIDError{InfoID: call.id, Reason: body.Reason}
+	case typeInfoNotFound:
+		return InfoNotFoundError{InfoID: call.id}
+	case typeInfoAlreadyExists:
+		return InfoAlreadyExistsError{InfoID: call.id}
+	case typeNotOwner:
+		return NotOwnerError{InfoID: call.id}
+	case typePrincipalNotAllowed:
+		return PrincipalNotAllowedError{Namespace: body.Namespace}
+	case typeNamespaceNotFound:
+		return NamespaceNotFoundError{Namespace: body.Namespace}
+	case typeQuotaExceeded:
+		return QuotaExceededError{Namespace: body.Namespace, Owner: body.Owner, Quota: body.Quota, Limit: body.Limit}
+	}
+
+	return body.Problem
+}
+
+// retryable returns if a call may succeed when it is retried after a
+// response with the status, i.e. it is rate limited or the backend is
+// unavailable.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfterOf returns the duration of the Retry-After header of the
+// response, or 0 if there is none.
+func retryAfterOf(response *http.Response) time.Duration {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/snapshot"
+)
+
+// command is a command of infoctl.
+type command struct {
+	usage            string
+	minArgs, maxArgs int
+	run              func(ctx context.Context, inv invocation) error
+}
+
+// invocation is what a command is run with.
+type invocation struct {
+	cliConfig
+	store  store
+	args   []string
+	stdin  io.Reader
+	stderr io.Writer
+	out    printer
+}
+
+// commands are the commands of infoctl by name.
+var commands = map[string]command{
+	"create":  {usage: "create [id] < value", minArgs: 0, maxArgs: 1, run: create},
+	"get":     {usage: "get <id>", minArgs: 1, maxArgs: 1, run: get},
+	"update":  {usage: "update <id> < value", minArgs: 1, maxArgs: 1, run: update},
+	"delete":  {usage: "delete <id>", minArgs: 1, maxArgs: 1, run: remove},
+	"list":    {usage: "list <prefix>", minArgs: 1, maxArgs: 1, run: list},
+	"export":  {usage: "export [prefix]", minArgs: 0, maxArgs: 1, run: export},
+	"import":  {usage: "import < file", minArgs: 0, maxArgs: 0, run: importRecords},
+	"migrate": {usage: "migrate", minArgs: 0, maxArgs: 0, run: migrate},
+
+	"snapshot": {usage: "snapshot > archive", minArgs: 0, maxArgs: 0, run: takeSnapshot},
+	"restore":  {usage: "restore <table> < archive", minArgs: 1, maxArgs: 1, run: restore},
+}
+
+func create(ctx context.Context, inv invocation) error {
+	value, err := ioutil.ReadAll(inv.stdin)
+	if err != nil {
+		return err
+	}
+
+	var id string
+	if len(inv.args) > 0 {
+		id = inv.args[0]
+	}
+
+	id, err = inv.store.Create(ctx, id, string(value))
+	if err != nil {
+		return err
+	}
+
+	return inv.out.id(id)
+}
+
+func get(ctx context.Context, inv invocation) error {
+	info, err := inv.store.Get(ctx, inv.args[0])
+	if err != nil {
+		return err
+	}
+
+	return inv.out.info(info)
+}
+
+func update(ctx context.Context, inv invocation) error {
+	value, err := ioutil.ReadAll(inv.stdin)
+	if err != nil {
+		return err
+	}
+
+	return inv.store.Update(ctx, inv.args[0], string(value))
+}
+
+// remove deletes the info, or all infos under the folder if the id ends with
+// the path separator.
+func remove(ctx context.Context, inv invocation) error {
+	id := inv.args[0]
+	if !strings.HasSuffix(id, service.PathSeparator) {
+		return inv.store.Delete(ctx, id)
+	}
+
+	deleted, err := inv.store.DeleteAll(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return inv.out.deleted(deleted)
+}
+
+func list(ctx context.Context, inv invocation) error {
+	l, err := inv.store.List(ctx, inv.args[0])
+	if err != nil {
+		return err
+	}
+
+	return inv.out.list(l)
+}
+
+// printer prints the results of commands either for humans or as JSON.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func (p printer) printJSON(v interface{}) error {
+	return json.NewEncoder(p.w).Encode(v)
+}
+
+func (p printer) id(id string) error {
+	if p.json {
+		return p.printJSON(map[string]string{"id": id})
+	}
+
+	_, err := fmt.Fprintln(p.w, id)
+	return err
+}
+
+// info prints the value as is, so that it can be redirected into a file, or
+// the info with its metadata as JSON.
+func (p printer) info(info client.Info) error {
+	if p.json {
+		return p.printJSON(struct {
+			ID        string     `json:"id"`
+			Value     string     `json:"value"`
+			Owner     string     `json:"owner,omitempty"`
+			CreatedAt *time.Time `json:"createdAt,omitempty"`
+			UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+			ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+			Version   int64      `json:"version"`
+			ETag      string     `json:"etag,omitempty"`
+		}{
+			ID:        info.ID,
+			Value:     info.Value,
+			Owner:     info.Owner,
+			CreatedAt: optionalTime(info.CreatedAt),
+			UpdatedAt: optionalTime(info.UpdatedAt),
+			ExpiresAt: optionalTime(info.ExpiresAt),
+			Version:   info.Version,
+			ETag:      info.ETag,
+		})
+	}
+
+	_, err := io.WriteString(p.w, info.Value)
+	return err
+}
+
+// list prints the folders and then the ids, one per line.
+func (p printer) list(l client.List) error {
+	if p.json {
+		return p.printJSON(l)
+	}
+
+	for _, child := range append(append([]string{}, l.Folders...), l.IDs...) {
+		if _, err := fmt.Fprintln(p.w, child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p printer) deleted(n int) error {
+	if p.json {
+		return p.printJSON(map[string]int{"deleted": n})
+	}
+
+	_, err := fmt.Fprintf(p.w, "Deleted %d infos.\n", n)
+	return err
+}
+
+func (p printer) imported(imported, skipped int) error {
+	if p.json {
+		return p.printJSON(map[string]int{"imported": imported, "skipped": skipped})
+	}
+
+	_, err := fmt.Fprintf(p.w, "Imported %d infos, skipped %d.\n", imported, skipped)
+	return err
+}
+
+func (p printer) migrated(n int) error {
+	if p.json {
+		return p.printJSON(map[string]int{"migrated": n})
+	}
+
+	_, err := fmt.Fprintf(p.w, "Migrated %d infos.\n", n)
+	return err
+}
+
+// restored prints the manifest of the archive restored into the table.
+func (p printer) restored(table string, manifest snapshot.Manifest) error {
+	if p.json {
+		return p.printJSON(map[string]interface{}{"table": table, "manifest": manifest})
+	}
+
+	_, err := fmt.Fprintf(p.w, "Restored %d infos into %s, schema version %d, checksum %s.\n", manifest.ItemCount, table, manifest.SchemaVersion, manifest.Checksum)
+	return err
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,260 @@
+// Command infoctl reads and writes infos from the command line, either through
+// the HTTP API or directly in the storage backend.
+//
+//	infoctl create < file
+//	infoctl -profile prod -o json get folder/id
+//	infoctl -backend dynamodb export folder/ > infos.ndjson
+//
+// Run infoctl -h for all commands and options.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/service"
+)
+
+// cliConfig is the configuration of infoctl.
+type cliConfig struct {
+	profile          string
+	backend          string
+	apiEndpoint      string
+	apiKey           string
+	dynamoDbEndpoint string
+	valueTableName   string
+	region           string
+	namespace        string
+	output           string
+
+	// checkpoint is the file export and import resume from and save their
+	// progress to, none if empty.
+	checkpoint string
+
+	// segments is the number of segments scanned in parallel by export.
+	segments int
+
+	// conflict is what import does if an info already exists.
+	conflict service.ConflictPolicy
+
+	// at is the point in time snapshot takes the snapshot of, the latest
+	// restorable time if zero.
+	at time.Time
+
+	// live makes snapshot scan the table while it may be written, instead of
+	// a table restored to a point in time, e.g. in DynamoDB local.
+	live bool
+}
+
+// Backends of infoctl.
+const (
+	backendAPI      = "api"
+	backendDynamoDb = "dynamodb"
+)
+
+// Output formats of infoctl.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+const usage = `Usage: infoctl [options] <command> [arguments]
+
+Commands:
+  create [id] < value     create an info, with a generated id if id is omitted
+  get <id>                print the info
+  update <id> < value     update the value of the info
+  delete <id>             delete the info, or all infos under it if id ends with /
+  list <prefix>           list the direct children of the folder prefix
+  export [prefix]         print the infos under the folder prefix as NDJSON, or
+                          the infos of all namespaces with -backend dynamodb
+  import < file           create the infos of NDJSON as printed by export
+  migrate                 migrate the infos of all namespaces to the current
+                          schema version, requires -backend dynamodb
+  snapshot > archive      write the snapshot archive of all namespaces at a
+                          point in time, from a temporary table restored from
+                          point-in-time recovery, requires -backend dynamodb
+  restore <table> < archive
+                          create the table and restore the snapshot archive into
+                          it, requires -backend dynamodb
+
+Options:
+`
+
+// parseConfig returns the configuration and the remaining arguments of the
+// command line arguments. Endpoints not given are taken from the profile.
+func parseConfig(args []string, stderr io.Writer) (cliConfig, []string, error) {
+	var c cliConfig
+	flags := flag.NewFlagSet("infoctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&c.profile, "profile", getenvOr("INFOCTL_PROFILE", "local"), "profile of the endpoints: local, sam-local or prod")
+	flags.StringVar(&c.backend, "backend", getenvOr("INFOCTL_BACKEND", backendAPI), "where infos are accessed: api or dynamodb")
+	flags.StringVar(&c.apiEndpoint, "endpoint", "", "base URL of the API, overrides the profile")
+	flags.StringVar(&c.apiKey, "api-key", os.Getenv("INFOCTL_API_KEY"), "API key sent in the X-Api-Key header")
+	flags.StringVar(&c.dynamoDbEndpoint, "dynamodb-endpoint", "", "endpoint of DynamoDB, overrides the profile")
+	flags.StringVar(&c.valueTableName, "table", "", "name of the value table, overrides the profile")
+	flags.StringVar(&c.region, "region", getenvOr("AWS_REGION", "us-east-1"), "AWS region of DynamoDB")
+	flags.StringVar(&c.namespace, "namespace", "", "namespace of the infos, the default namespace if empty")
+	flags.StringVar(&c.output, "o", outputText, "output format: text or json")
+	flags.StringVar(&c.checkpoint, "checkpoint", "", "file to resume export, import and migrate from and save their progress to")
+	flags.IntVar(&c.segments, "segments", 4, "number of segments export, snapshot and migrate scan in parallel")
+	conflict := flags.String("conflict", string(service.ConflictFail), "what import does if an info already exists: skip, overwrite or fail")
+	at := flags.String("at", "", "time of the snapshot in RFC 3339, the latest restorable time if empty")
+	flags.BoolVar(&c.live, "live", false, "snapshot the table itself, which is not of a point in time while it is written, e.g. in DynamoDB local without point-in-time recovery")
+	if err := flags.Parse(args); err != nil {
+		return cliConfig{}, nil, err
+	}
+
+	if *at != "" {
+		var err error
+		if c.at, err = time.Parse(time.RFC3339, *at); err != nil {
+			return cliConfig{}, nil, fmt.Errorf("invalid time %q, expected RFC 3339", *at)
+		}
+	}
+
+	c.conflict = service.ConflictPolicy(*conflict)
+	p, err := profileOf(c.profile)
+	if err != nil {
+		return cliConfig{}, nil, err
+	}
+
+	if c.apiEndpoint == "" {
+		c.apiEndpoint = p.apiEndpoint
+	}
+
+	explicit := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	if !explicit["dynamodb-endpoint"] {
+		c.dynamoDbEndpoint = p.dynamoDbEndpoint
+	}
+
+	if c.valueTableName == "" {
+		c.valueTableName = p.valueTableName
+	}
+
+	switch {
+	case c.backend != backendAPI && c.backend != backendDynamoDb:
+		return cliConfig{}, nil, fmt.Errorf("unknown backend %q, expected %s or %s", c.backend, backendAPI, backendDynamoDb)
+	case c.output != outputText && c.output != outputJSON:
+		return cliConfig{}, nil, fmt.Errorf("unknown output format %q, expected %s or %s", c.output, outputText, outputJSON)
+	case c.conflict != service.ConflictSkip && c.conflict != service.ConflictOverwrite && c.conflict != service.ConflictFail:
+		return cliConfig{}, nil, fmt.Errorf("unknown conflict policy %q, expected %s, %s or %s", c.conflict, service.ConflictSkip, service.ConflictOverwrite, service.ConflictFail)
+	case c.live && !c.at.IsZero():
+		return cliConfig{}, nil, errors.New("-at cannot be combined with -live")
+	case c.segments < 1:
+		return cliConfig{}, nil, errors.New("the number of segments must be at least 1")
+	case c.backend == backendAPI && c.apiEndpoint == "":
+		return cliConfig{}, nil, errors.New("the API endpoint is unknown, set -endpoint or INFOCTL_API_ENDPOINT")
+	}
+
+	return c, flags.Args(), nil
+}
+
+// appConfig returns the configuration of the app loaded like in Lambda, with
+// the backend of the infoctl configuration.
+func (c cliConfig) appConfig() (config.Config, error) {
+	app, err := config.FromEnv()
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	app.DynamoDbEndpoint = c.dynamoDbEndpoint
+	app.ValueTableName = c.valueTableName
+	app.Region = c.region
+	return app, app.Validate()
+}
+
+// newStore returns the store of the configured backend.
+func (c cliConfig) newStore() (store, error) {
+	if c.backend == backendDynamoDb {
+		app, err := c.appConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		return newDirectStore(newServices(app), c.namespace)
+	}
+
+	options := []client.Option{client.WithNamespace(c.namespace)}
+	if c.apiKey != "" {
+		options = append(options, client.WithAPIKey(c.apiKey))
+	}
+
+	return client.New(c.apiEndpoint, options...), nil
+}
+
+func getenvOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return fallback
+}
+
+// run runs the command line and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, newStore func(cliConfig) (store, error)) int {
+	c, args, err := parseConfig(args, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		return 2
+	}
+
+	if len(args)-1 < cmd.minArgs || len(args)-1 > cmd.maxArgs {
+		fmt.Fprintf(stderr, "usage: infoctl %s\n", cmd.usage)
+		return 2
+	}
+
+	s, err := newStore(c)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	inv := invocation{
+		cliConfig: c,
+		store:     s,
+		args:      args[1:],
+		stdin:     stdin,
+		stderr:    stderr,
+		out:       printer{w: stdout, json: c.output == outputJSON},
+	}
+	if err := cmd.run(context.Background(), inv); err != nil {
+		fmt.Fprintln(stderr, strings.TrimSuffix(err.Error(), "\n"))
+		return 1
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, cliConfig.newStore))
+}
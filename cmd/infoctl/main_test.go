@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseConfig()", func() {
+	BeforeEach(func() {
+		os.Unsetenv("INFOCTL_PROFILE")
+		os.Unsetenv("INFOCTL_BACKEND")
+		os.Unsetenv("INFOCTL_API_ENDPOINT")
+	})
+
+	It("should use the local profile by default", func() {
+		c, args, err := parseConfig([]string{"get", "id"}, ioutil.Discard)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(args).To(Equal([]string{"get", "id"}))
+		Expect(c.backend).To(Equal(backendAPI))
+		Expect(c.apiEndpoint).To(Equal("http://localhost:3000"))
+		Expect(c.dynamoDbEndpoint).To(Equal("http://localhost:8000"))
+		Expect(c.valueTableName).To(Equal(config.LocalValueTableName))
+		Expect(c.output).To(Equal(outputText))
+	})
+
+	It("should reach DynamoDB local like SAM local functions in the sam-local profile", func() {
+		c, _, err := parseConfig([]string{"-profile", "sam-local"}, ioutil.Discard)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.dynamoDbEndpoint).To(Equal("http://dynamodb:8000"))
+	})
+
+	It("should use the endpoints of the region in the prod profile", func() {
+		os.Setenv("INFOCTL_API_ENDPOINT", "https://api.example.com/Prod")
+		defer os.Unsetenv("INFOCTL_API_ENDPOINT")
+
+		c, _, err := parseConfig([]string{"-profile", "prod"}, ioutil.Discard)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.apiEndpoint).To(Equal("https://api.example.com/Prod"))
+		Expect(c.dynamoDbEndpoint).To(BeEmpty())
+	})
+
+	It("should fail in the prod profile without API endpoint", func() {
+		_, _, err := parseConfig([]string{"-profile", "prod"}, ioutil.Discard)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should let flags override the profile", func() {
+		c, _, err := parseConfig([]string{"-endpoint", "http://localhost:9000", "-dynamodb-endpoint", "", "-table", "table"}, ioutil.Discard)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.apiEndpoint).To(Equal("http://localhost:9000"))
+		Expect(c.dynamoDbEndpoint).To(BeEmpty())
+		Expect(c.valueTableName).To(Equal("table"))
+	})
+
+	It("should fail on unknown profiles, backends and output formats", func() {
+		for _, args := range [][]string{{"-profile", "unknown"}, {"-backend", "unknown"}, {"-o", "yaml"}} {
+			_, _, err := parseConfig(args, ioutil.Discard)
+			Expect(err).Should(HaveOccurred())
+		}
+	})
+})
+
+var _ = Describe("run()", func() {
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoCreator     servicefakes.FakeInfoCreator
+		fakeInfoGetter      servicefakes.FakeInfoGetter
+		fakeInfoUpdater     servicefakes.FakeInfoUpdater
+		fakeInfoDeleter     servicefakes.FakeInfoDeleter
+		fakeInfoLister      servicefakes.FakeInfoLister
+
+		args   []string
+		stdin  string
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+		code   int
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, nil)
+		fakeInfoCreator = servicefakes.FakeInfoCreator{}
+		fakeInfoGetter = servicefakes.FakeInfoGetter{}
+		fakeInfoUpdater = servicefakes.FakeInfoUpdater{}
+		fakeInfoDeleter = servicefakes.FakeInfoDeleter{}
+		fakeInfoLister = servicefakes.FakeInfoLister{}
+		stdin = ""
+	})
+
+	JustBeforeEach(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		code = run(args, strings.NewReader(stdin), stdout, stderr, func(c cliConfig) (store, error) {
+			return newDirectStore(services{
+				namespaceGetter: &fakeNamespaceGetter,
+				infoCreator:     &fakeInfoCreator,
+				infoGetter:      &fakeInfoGetter,
+				infoUpdater:     &fakeInfoUpdater,
+				infoDeleter:     &fakeInfoDeleter,
+				infoLister:      &fakeInfoLister,
+			}, c.namespace)
+		})
+	})
+
+	Describe("create", func() {
+		BeforeEach(func() {
+			args = []string{"create"}
+			stdin = "value\n"
+			fakeInfoCreator.CreateInfoReturns(service.Info{ID: "info-id"}, nil)
+		})
+
+		It("should create an info with the value of stdin and print its id", func() {
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(Equal("info-id\n"))
+
+			_, _, owner, id, value, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(owner).To(BeEmpty())
+			Expect(id).To(BeEmpty())
+			Expect(value).To(Equal("value\n"))
+		})
+
+		When("the output is JSON", func() {
+			BeforeEach(func() {
+				args = []string{"-o", "json", "create", "info-id"}
+			})
+
+			It("should print the id as JSON", func() {
+				Expect(stdout.String()).To(MatchJSON(`{"id": "info-id"}`))
+
+				_, _, _, id, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+				Expect(id).To(Equal("info-id"))
+			})
+		})
+
+		When("the value is too long", func() {
+			BeforeEach(func() {
+				fakeInfoCreator.CreateInfoReturns(service.Info{}, service.ValueTooLongError{AllowedLen: 1000, ActualLen: 1001})
+			})
+
+			It("should print the error and fail", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(ContainSubstring("1001"))
+			})
+		})
+	})
+
+	Describe("get", func() {
+		BeforeEach(func() {
+			args = []string{"get", "info-id"}
+			fakeInfoGetter.GetInfoReturns(service.Info{ID: "info-id", Value: "value", Version: 2, ContentHash: "hash"}, nil)
+		})
+
+		It("should print the value as is", func() {
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(Equal("value"))
+		})
+
+		When("the output is JSON", func() {
+			BeforeEach(func() {
+				args = []string{"-o", "json", "get", "info-id"}
+			})
+
+			It("should print the info with its metadata", func() {
+				Expect(stdout.String()).To(MatchJSON(`{"id": "info-id", "value": "value", "version": 2, "etag": "\"hash\""}`))
+			})
+		})
+
+		When("the info does not exist", func() {
+			BeforeEach(func() {
+				fakeInfoGetter.GetInfoReturns(service.Info{}, service.InfoNotFoundError{InfoID: "info-id"})
+			})
+
+			It("should print the error and fail", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal("Info with id info-id does not exist.\n"))
+			})
+		})
+	})
+
+	Describe("update", func() {
+		BeforeEach(func() {
+			args = []string{"-namespace", "other", "update", "info-id"}
+			stdin = "new value"
+		})
+
+		It("should update the info in the namespace", func() {
+			Expect(code).To(Equal(0))
+			Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal("other"))
+
+			_, _, _, id, value, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			Expect(id).To(Equal("info-id"))
+			Expect(value).To(Equal("new value"))
+		})
+	})
+
+	Describe("delete", func() {
+		BeforeEach(func() {
+			args = []string{"delete", "info-id"}
+		})
+
+		It("should delete the info", func() {
+			Expect(code).To(Equal(0))
+			Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(1))
+		})
+
+		When("the id is a folder", func() {
+			BeforeEach(func() {
+				args = []string{"delete", "folder/"}
+				fakeInfoDeleter.DeleteInfosReturnsOnCall(0, service.DeletedInfos{Deleted: 2, Next: "folder/b"}, nil)
+				fakeInfoDeleter.DeleteInfosReturnsOnCall(1, service.DeletedInfos{Deleted: 1}, nil)
+			})
+
+			It("should delete all infos under the folder page by page", func() {
+				Expect(code).To(Equal(0))
+				Expect(stdout.String()).To(Equal("Deleted 3 infos.\n"))
+
+				Expect(fakeInfoDeleter.DeleteInfosCallCount()).To(Equal(2))
+				_, _, _, prefix, after := fakeInfoDeleter.DeleteInfosArgsForCall(0)
+				Expect(prefix).To(Equal("folder/"))
+				Expect(after).To(BeEmpty())
+				_, _, _, _, after = fakeInfoDeleter.DeleteInfosArgsForCall(1)
+				Expect(after).To(Equal("folder/b"))
+			})
+		})
+	})
+
+	Describe("list", func() {
+		BeforeEach(func() {
+			args = []string{"list", "folder/"}
+			fakeInfoLister.ListInfosReturns(service.InfoList{Prefix: "folder/", IDs: []string{"folder/a"}, Folders: []string{"folder/b/"}}, nil)
+		})
+
+		It("should print the folders and ids", func() {
+			Expect(stdout.String()).To(Equal("folder/b/\nfolder/a\n"))
+		})
+
+		When("the output is JSON", func() {
+			BeforeEach(func() {
+				args = []string{"-o", "json", "list", "folder/"}
+			})
+
+			It("should print the list", func() {
+				Expect(stdout.String()).To(MatchJSON(`{"prefix": "folder/", "ids": ["folder/a"], "folders": ["folder/b/"]}`))
+			})
+		})
+	})
+
+	When("the command is unknown", func() {
+		BeforeEach(func() {
+			args = []string{"unknown"}
+		})
+
+		It("should fail with usage error", func() {
+			Expect(code).To(Equal(2))
+		})
+	})
+
+	When("arguments are missing", func() {
+		BeforeEach(func() {
+			args = []string{"get"}
+		})
+
+		It("should print the usage of the command", func() {
+			Expect(code).To(Equal(2))
+			Expect(stderr.String()).To(Equal("usage: infoctl get <id>\n"))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"simple-information-store-app/internal/config"
+)
+
+// profile is the endpoints of an environment of the app.
+type profile struct {
+	// apiEndpoint is the base URL of the API.
+	apiEndpoint string
+
+	// dynamoDbEndpoint is the endpoint of DynamoDB, empty for the endpoint of
+	// the region.
+	dynamoDbEndpoint string
+
+	// valueTableName is the name of the value table.
+	valueTableName string
+}
+
+// profiles returns the profiles by name: DynamoDB local on the host, DynamoDB
+// local in the Docker network of SAM local, and DynamoDB of the region.
+func profiles() map[string]profile {
+	return map[string]profile{
+		// local is the API of cmd/server with DynamoDB local on the host.
+		"local": {
+			apiEndpoint:      "http://localhost:3000",
+			dynamoDbEndpoint: "http://localhost:8000",
+			valueTableName:   config.LocalValueTableName,
+		},
+		// sam-local is the API of sam local start-api. DynamoDB local is
+		// reached like the functions do, so the direct backend must run in
+		// the Docker network of SAM local.
+		"sam-local": {
+			apiEndpoint:      "http://localhost:3000",
+			dynamoDbEndpoint: "http://dynamodb:8000",
+			valueTableName:   config.LocalValueTableName,
+		},
+		// prod is the deployed stack. The API endpoint is an output of the
+		// stack, the table name is the physical name of ValueTable.
+		"prod": {
+			apiEndpoint:      os.Getenv("INFOCTL_API_ENDPOINT"),
+			dynamoDbEndpoint: "",
+			valueTableName:   os.Getenv("VALUE_TABLE_REF"),
+		},
+	}
+}
+
+// profileOf returns the profile with the name.
+func profileOf(name string) (profile, error) {
+	all := profiles()
+	p, ok := all[name]
+	if !ok {
+		names := make([]string, 0, len(all))
+		for name := range all {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		return profile{}, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	return p, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"simple-information-store-app/internal/snapshot"
+)
+
+// snapshotter is a store which can take and restore snapshots of the value
+// table.
+type snapshotter interface {
+	Snapshot(ctx context.Context, w io.Writer, segments int, at time.Time, live bool) (snapshot.Manifest, error)
+	Restore(ctx context.Context, r io.Reader, table string, segments int) (snapshot.Manifest, error)
+}
+
+// takeSnapshot writes the snapshot archive to the output and its manifest to
+// stderr.
+func takeSnapshot(ctx context.Context, inv invocation) error {
+	s, ok := inv.store.(snapshotter)
+	if !ok {
+		return errors.New("snapshots require -backend dynamodb")
+	}
+
+	manifest, err := s.Snapshot(ctx, inv.out.w, inv.segments, inv.at, inv.live)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(inv.stderr, "Snapshot of %d infos, schema version %d, checksum %s.\n", manifest.ItemCount, manifest.SchemaVersion, manifest.Checksum)
+	return nil
+}
+
+// restore restores the snapshot archive of the input into a new table.
+func restore(ctx context.Context, inv invocation) error {
+	s, ok := inv.store.(snapshotter)
+	if !ok {
+		return errors.New("snapshots require -backend dynamodb")
+	}
+
+	table := inv.args[0]
+	manifest, err := s.Restore(ctx, inv.stdin, table, inv.segments)
+	if err != nil {
+		return err
+	}
+
+	return inv.out.restored(table, manifest)
+}
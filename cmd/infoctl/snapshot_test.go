@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"simple-information-store-app/internal/snapshot"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("snapshot and restore", func() {
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoExporter    servicefakes.FakeInfoExporter
+		fakeInfoImporter    servicefakes.FakeInfoImporter
+		fakeUsageReconciler servicefakes.FakeUsageReconciler
+
+		records      []service.Record
+		tables       []string
+		tableErr     error
+		restoredAt   []time.Time
+		restoreErr   error
+		deleted      []string
+		importTables []string
+		viaAPI       bool
+		args         []string
+		stdin        *bytes.Buffer
+		stdout       *bytes.Buffer
+		stderr       *bytes.Buffer
+		code         int
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		records = []service.Record{{ID: "b", Value: "b", Version: 1}, {Namespace: "team-a", ID: "a", Value: "a", Version: 1}}
+		fakeInfoExporter = servicefakes.FakeInfoExporter{}
+		fakeInfoExporter.ExportInfosStub = func(_ context.Context, c service.ExportCheckpoint, fn func([]service.Record, service.ExportCheckpoint) error) error {
+			return fn(records, c)
+		}
+		fakeInfoImporter = servicefakes.FakeInfoImporter{}
+		fakeUsageReconciler = servicefakes.FakeUsageReconciler{}
+		tables = nil
+		tableErr = nil
+		restoredAt = nil
+		restoreErr = nil
+		deleted = nil
+		importTables = nil
+		viaAPI = false
+		stdin = &bytes.Buffer{}
+	})
+
+	JustBeforeEach(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		code = run(args, stdin, stdout, stderr, func(c cliConfig) (store, error) {
+			s, err := newDirectStore(services{
+				namespaceGetter: &fakeNamespaceGetter,
+				infoExporter:    &fakeInfoExporter,
+				createTable: func(name string) (services, error) {
+					tables = append(tables, name)
+					fakeInfoImporter.ImportInfoStub = func(context.Context, service.Record, service.ConflictPolicy) (bool, error) {
+						importTables = append(importTables, name)
+						return true, nil
+					}
+
+					return services{
+						infoImporter:    &fakeInfoImporter,
+						infoExporter:    &fakeInfoExporter,
+						usageReconciler: &fakeUsageReconciler,
+					}, tableErr
+				},
+				restoreTable: func(_ context.Context, name string, at time.Time) (services, error) {
+					tables = append(tables, name)
+					restoredAt = append(restoredAt, at)
+					return services{infoExporter: &fakeInfoExporter}, restoreErr
+				},
+				deleteTable: func(name string) error {
+					deleted = append(deleted, name)
+					return nil
+				},
+			}, c.namespace)
+			if viaAPI {
+				return apiStore{s}, err
+			}
+
+			return s, err
+		})
+	})
+
+	Describe("snapshot", func() {
+		BeforeEach(func() {
+			args = []string{"-segments", "2", "snapshot"}
+		})
+
+		It("should write the archive of all namespaces", func() {
+			Expect(code).To(Equal(0))
+			var read []service.Record
+			manifest, err := snapshot.Read(stdout, func(record service.Record) error {
+				read = append(read, record)
+				return nil
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(manifest.ItemCount).To(Equal(2))
+			Expect(read).To(Equal([]service.Record{records[0], records[1]}))
+
+			_, c, _ := fakeInfoExporter.ExportInfosArgsForCall(0)
+			Expect(c.Segments).To(HaveLen(2))
+		})
+
+		It("should report the manifest", func() {
+			Expect(stderr.String()).To(MatchRegexp(`^Snapshot of 2 infos, schema version 1, checksum [0-9a-f]{64}\.\n$`))
+		})
+
+		It("should scan a temporary table restored to the latest restorable time", func() {
+			Expect(tables).To(HaveLen(1))
+			Expect(tables[0]).To(HavePrefix("snapshot-"))
+			Expect(restoredAt).To(Equal([]time.Time{{}}))
+			Expect(fakeInfoExporter.ExportInfosCallCount()).To(Equal(1))
+			Expect(deleted).To(Equal(tables))
+		})
+
+		When("the time is given", func() {
+			BeforeEach(func() {
+				args = []string{"-at", "2021-03-01T10:00:00Z", "snapshot"}
+			})
+
+			It("should restore the table at the time", func() {
+				Expect(code).To(Equal(0))
+				Expect(restoredAt).To(HaveLen(1))
+				Expect(restoredAt[0]).To(BeTemporally("==", time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)))
+			})
+		})
+
+		When("the time is invalid", func() {
+			BeforeEach(func() {
+				args = []string{"-at", "yesterday", "snapshot"}
+			})
+
+			It("should fail", func() {
+				Expect(code).To(Equal(2))
+				Expect(tables).To(BeEmpty())
+			})
+		})
+
+		When("the table cannot be restored", func() {
+			BeforeEach(func() {
+				restoreErr = errors.New("point-in-time recovery is not enabled")
+			})
+
+			It("should fail and delete the temporary table", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal("point-in-time recovery is not enabled\n"))
+				Expect(fakeInfoExporter.ExportInfosCallCount()).To(Equal(0))
+				Expect(deleted).To(Equal(tables))
+			})
+		})
+
+		When("the live table is snapshotted", func() {
+			BeforeEach(func() {
+				args = []string{"-live", "snapshot"}
+			})
+
+			It("should scan the value table", func() {
+				Expect(code).To(Equal(0))
+				Expect(tables).To(BeEmpty())
+				Expect(fakeInfoExporter.ExportInfosCallCount()).To(Equal(1))
+			})
+		})
+
+		When("the backend is the API", func() {
+			BeforeEach(func() {
+				viaAPI = true
+			})
+
+			It("should fail", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal("snapshots require -backend dynamodb\n"))
+			})
+		})
+	})
+
+	Describe("restore", func() {
+		BeforeEach(func() {
+			args = []string{"restore", "restored"}
+			_, err := snapshot.Write(stdin, records)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should restore the archive into the new table", func() {
+			Expect(code).To(Equal(0))
+			Expect(tables).To(Equal([]string{"restored"}))
+			Expect(importTables).To(Equal([]string{"restored", "restored"}))
+			Expect(stdout.String()).To(MatchRegexp(`^Restored 2 infos into restored, schema version 1, checksum [0-9a-f]{64}\.\n$`))
+		})
+
+		It("should reconcile the usage", func() {
+			Expect(fakeUsageReconciler.ReconcileUsageCallCount()).To(Equal(1))
+		})
+
+		When("the table differs after restoring", func() {
+			BeforeEach(func() {
+				fakeInfoExporter.ExportInfosReturns(nil)
+			})
+
+			It("should fail the verification", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal(snapshot.VerificationError{Missing: 2}.Error() + "\n"))
+			})
+		})
+
+		When("the archive is invalid", func() {
+			BeforeEach(func() {
+				stdin.Reset()
+				stdin.WriteString("not an archive")
+			})
+
+			It("should fail", func() {
+				Expect(code).To(Equal(1))
+				Expect(fakeInfoImporter.ImportInfoCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the table cannot be created", func() {
+			BeforeEach(func() {
+				tableErr = errors.New("table already exists")
+			})
+
+			It("should not import", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal("table already exists\n"))
+				Expect(fakeInfoImporter.ImportInfoCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
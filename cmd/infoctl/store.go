@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/snapshot"
+	"simple-information-store-app/internal/valuetable"
+)
+
+// store is where infoctl reads and writes infos, either the HTTP API or the
+// storage backend directly.
+type store interface {
+	Create(ctx context.Context, id, value string) (string, error)
+	Get(ctx context.Context, id string) (client.Info, error)
+	Update(ctx context.Context, id, value string) error
+	Delete(ctx context.Context, id string) error
+	DeleteAll(ctx context.Context, prefix string) (int, error)
+	List(ctx context.Context, prefix string) (client.List, error)
+}
+
+// The API is accessed with the typed client.
+var _ store = (*client.Client)(nil)
+
+// services are the services the direct backend depends on.
+type services struct {
+	namespaceGetter service.NamespaceGetter
+	infoCreator     service.InfoCreator
+	infoGetter      service.InfoGetter
+	infoUpdater     service.InfoUpdater
+	infoDeleter     service.InfoDeleter
+	infoLister      service.InfoLister
+	infoExporter    service.InfoExporter
+	infoImporter    service.InfoImporter
+	infoMigrator    service.InfoMigrator
+	usageReconciler service.UsageReconciler
+
+	// createTable creates an empty value table with the name and returns the
+	// services of it.
+	createTable func(name string) (services, error)
+
+	// restoreTable creates the table with the name and the infos of the value
+	// table at the time, the latest restorable time if zero, and returns the
+	// services of it.
+	restoreTable func(ctx context.Context, name string, at time.Time) (services, error)
+
+	// deleteTable deletes the table with the name.
+	deleteTable func(name string) error
+}
+
+// newServices returns the services of the configuration backed by DynamoDB.
+func newServices(c config.Config) services {
+	infoService := service.NewInfoService(c)
+	transferService := service.NewInfoTransferService(c)
+	return services{
+		namespaceGetter: service.NewNamespaceGetter(c),
+		infoCreator:     infoService,
+		infoGetter:      infoService,
+		infoUpdater:     infoService,
+		infoDeleter:     infoService,
+		infoLister:      infoService,
+		infoExporter:    transferService,
+		infoImporter:    transferService,
+		infoMigrator:    service.NewMigrationService(c),
+		usageReconciler: service.NewUsageService(c),
+		createTable: func(name string) (services, error) {
+			created := c
+			created.ValueTableName = name
+			return newServices(created), valuetable.Create(created)
+		},
+		restoreTable: func(ctx context.Context, name string, at time.Time) (services, error) {
+			restored := c
+			restored.ValueTableName = name
+			return newServices(restored), valuetable.RestoreToPointInTime(ctx, c, name, at)
+		},
+		deleteTable: func(name string) error {
+			deleted := c
+			deleted.ValueTableName = name
+			return valuetable.Delete(deleted)
+		},
+	}
+}
+
+// directStore accesses infos with the services, bypassing the API and its
+// authentication. Infos created by it have no owner, and it may update and
+// delete the infos of all owners as AdminPrincipal.
+type directStore struct {
+	services
+	ns service.Namespace
+}
+
+// newDirectStore returns the store of the namespace, the default namespace if
+// namespace is empty.
+func newDirectStore(s services, namespace string) (directStore, error) {
+	ns, err := s.namespaceGetter.GetNamespace(namespace)
+	if err != nil {
+		return directStore{}, err
+	}
+
+	return directStore{services: s, ns: ns}, nil
+}
+
+func (s directStore) Create(ctx context.Context, id, value string) (string, error) {
+	info, err := s.infoCreator.CreateInfo(ctx, s.ns, "", id, value, service.InfoAttributes{})
+	return info.ID, clientErrorOf(err)
+}
+
+func (s directStore) Get(ctx context.Context, id string) (client.Info, error) {
+	info, err := s.infoGetter.GetInfo(ctx, s.ns, id)
+	if err != nil {
+		return client.Info{}, clientErrorOf(err)
+	}
+
+	return client.Info{
+		ID:        info.ID,
+		Value:     info.Value,
+		Owner:     info.Owner,
+		CreatedAt: info.CreatedAt,
+		UpdatedAt: info.UpdatedAt,
+		ExpiresAt: info.ExpiresAt,
+		Version:   info.Version,
+		ETag:      `"` + info.ContentHash + `"`,
+	}, nil
+}
+
+func (s directStore) Update(ctx context.Context, id, value string) error {
+	_, err := s.infoUpdater.UpdateInfo(ctx, s.ns, service.AdminPrincipal, id, value, service.InfoAttributes{})
+	return clientErrorOf(err)
+}
+
+func (s directStore) Delete(ctx context.Context, id string) error {
+	return clientErrorOf(s.infoDeleter.DeleteInfo(ctx, s.ns, service.AdminPrincipal, id))
+}
+
+func (s directStore) DeleteAll(ctx context.Context, prefix string) (int, error) {
+	deleted, after := 0, ""
+	for {
+		result, err := s.infoDeleter.DeleteInfos(ctx, s.ns, service.AdminPrincipal, prefix, after)
+		deleted += result.Deleted
+		if err != nil || result.Next == "" {
+			return deleted, clientErrorOf(err)
+		}
+
+		after = result.Next
+	}
+}
+
+func (s directStore) List(ctx context.Context, prefix string) (client.List, error) {
+	list, err := s.infoLister.ListInfos(ctx, s.ns, prefix)
+	return client.List(list), clientErrorOf(err)
+}
+
+// clientErrorOf returns the error of the client of the error of the service,
+// so that the commands handle the errors of both stores alike.
+func clientErrorOf(err error) error {
+	var (
+		valueTooLong      service.ValueTooLongError
+		invalidID         service.InvalidIDError
+		infoNotFound      service.InfoNotFoundError
+		infoAlreadyExists service.InfoAlreadyExistsError
+		notOwner          service.NotOwnerError
+		quotaExceeded     service.QuotaExceededError
+	)
+
+	switch {
+	case errors.As(err, &valueTooLong):
+		return client.ValueTooLongError{AllowedLen: valueTooLong.AllowedLen, ActualLen: valueTooLong.ActualLen}
+	case errors.As(err, &invalidID):
+		return client.InvalidIDError{InfoID: invalidID.InfoID, Reason: invalidID.Reason}
+	case errors.As(err, &infoNotFound):
+		return client.InfoNotFoundError{InfoID: infoNotFound.InfoID}
+	case errors.As(err, &infoAlreadyExists):
+		return client.InfoAlreadyExistsError{InfoID: infoAlreadyExists.InfoID}
+	case errors.As(err, &notOwner):
+		return client.NotOwnerError{InfoID: notOwner.InfoID}
+	case errors.As(err, &quotaExceeded):
+		return client.QuotaExceededError{
+			Namespace: quotaExceeded.Namespace,
+			Owner:     quotaExceeded.Owner,
+			Quota:     quotaExceeded.Quota,
+			Limit:     quotaExceeded.Limit,
+		}
+	default:
+		return err
+	}
+}
+
+func (s directStore) ExportInfos(ctx context.Context, checkpoint service.ExportCheckpoint, fn func([]service.Record, service.ExportCheckpoint) error) error {
+	return s.infoExporter.ExportInfos(ctx, checkpoint, fn)
+}
+
+func (s directStore) MigrateInfos(ctx context.Context, checkpoint service.ExportCheckpoint, fn func(int, service.ExportCheckpoint) error) error {
+	return s.infoMigrator.MigrateInfos(ctx, checkpoint, fn)
+}
+
+// ImportRecord imports the record with its metadata into the namespace of the
+// record, or the namespace of the store if the record has none.
+func (s directStore) ImportRecord(ctx context.Context, record service.Record, policy service.ConflictPolicy) (bool, error) {
+	if record.Namespace == "" {
+		record.Namespace = s.ns.Name
+	}
+
+	return s.infoImporter.ImportInfo(ctx, record, policy)
+}
+
+// ImportDone reconciles the usage, which is not counted by ImportRecord.
+func (s directStore) ImportDone(ctx context.Context) error {
+	_, err := s.usageReconciler.ReconcileUsage(ctx)
+	return err
+}
+
+// Snapshot writes the snapshot archive of the infos of all namespaces at the
+// time, which are restored into a temporary table from the point-in-time
+// recovery of the value table, or of the value table itself if live. The
+// temporary table is deleted afterwards.
+func (s directStore) Snapshot(ctx context.Context, w io.Writer, segments int, at time.Time, live bool) (manifest snapshot.Manifest, err error) {
+	if live {
+		return snapshot.Take(ctx, w, s.infoExporter, segments)
+	}
+
+	name := valuetable.UniqueName("snapshot")
+	defer func() {
+		if deleteErr := s.deleteTable(name); err == nil {
+			err = deleteErr
+		}
+	}()
+
+	restored, err := s.restoreTable(ctx, name, at)
+	if err != nil {
+		return snapshot.Manifest{}, err
+	}
+
+	return snapshot.Take(ctx, w, restored.infoExporter, segments)
+}
+
+// Restore creates the table and restores the snapshot archive into it with
+// the services of the table.
+func (s directStore) Restore(ctx context.Context, r io.Reader, table string, segments int) (snapshot.Manifest, error) {
+	restored, err := s.createTable(table)
+	if err != nil {
+		return snapshot.Manifest{}, err
+	}
+
+	manifest, err := snapshot.Restore(ctx, r, restored.infoImporter, restored.infoExporter, segments)
+	if err != nil {
+		return snapshot.Manifest{}, err
+	}
+
+	_, err = restored.usageReconciler.ReconcileUsage(ctx)
+	return manifest, err
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/service"
+)
+
+// tableExporter is a store which can export the infos of all namespaces.
+type tableExporter interface {
+	ExportInfos(ctx context.Context, checkpoint service.ExportCheckpoint, fn func([]service.Record, service.ExportCheckpoint) error) error
+}
+
+// tableMigrator is a store which can migrate the items of all namespaces.
+type tableMigrator interface {
+	MigrateInfos(ctx context.Context, checkpoint service.ExportCheckpoint, fn func(migrated int, checkpoint service.ExportCheckpoint) error) error
+}
+
+// recordImporter is a store which can import records with their metadata.
+type recordImporter interface {
+	ImportRecord(ctx context.Context, record service.Record, policy service.ConflictPolicy) (bool, error)
+
+	// ImportDone is called after all records are imported.
+	ImportDone(ctx context.Context) error
+}
+
+// importCheckpoint presents how many lines of the input an import has
+// processed.
+type importCheckpoint struct {
+	Lines int `json:"lines"`
+}
+
+// importCheckpointInterval is the number of lines after which an import
+// saves its checkpoint.
+const importCheckpointInterval = 100
+
+// export prints the infos under the folder, or of all namespaces, as NDJSON,
+// one record per line. The output is always JSON, so that it can be imported
+// again.
+func export(ctx context.Context, inv invocation) error {
+	if len(inv.args) > 0 {
+		if inv.checkpoint != "" {
+			return errors.New("checkpoints are only supported when exporting all infos")
+		}
+
+		return exportFolder(ctx, inv, inv.args[0])
+	}
+
+	exporter, ok := inv.store.(tableExporter)
+	if !ok {
+		return errors.New("exporting all infos requires -backend dynamodb, otherwise give a folder prefix")
+	}
+
+	checkpoint := service.NewExportCheckpoint(inv.segments)
+	if err := loadCheckpoint(inv.checkpoint, &checkpoint); err != nil {
+		return err
+	}
+
+	p := newProgress(inv.stderr)
+	if checkpoint.Done() {
+		p.report(true, "Export is already complete.")
+		return nil
+	}
+
+	w := bufio.NewWriter(inv.out.w)
+	encoder := json.NewEncoder(w)
+	exported := 0
+	return exporter.ExportInfos(ctx, checkpoint, func(records []service.Record, checkpoint service.ExportCheckpoint) error {
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+
+		// Records are written before the checkpoint is saved, so that no
+		// record is lost if the export is interrupted. Records of the page
+		// are exported again by the resumed export if it is interrupted in
+		// between.
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if err := saveCheckpoint(inv.checkpoint, checkpoint); err != nil {
+			return err
+		}
+
+		exported += len(records)
+		p.report(checkpoint.Done(), "Exported %d infos, %d of %d segments done.", exported, segmentsDone(checkpoint), len(checkpoint.Segments))
+		return nil
+	})
+}
+
+// exportFolder prints all infos under the folder with the store, walking the
+// sub folders.
+func exportFolder(ctx context.Context, inv invocation, prefix string) error {
+	encoder := json.NewEncoder(inv.out.w)
+	p := newProgress(inv.stderr)
+	exported := 0
+
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		l, err := inv.store.List(ctx, prefix)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range l.IDs {
+			info, err := inv.store.Get(ctx, id)
+			if _, ok := err.(client.InfoNotFoundError); ok {
+				// The info is deleted or expired since it is listed.
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if err := encoder.Encode(recordOf(info)); err != nil {
+				return err
+			}
+
+			exported++
+			p.report(false, "Exported %d infos.", exported)
+		}
+
+		for _, folder := range l.Folders {
+			if err := walk(folder); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(prefix); err != nil {
+		return err
+	}
+
+	p.report(true, "Exported %d infos.", exported)
+	return nil
+}
+
+// migrate migrates the items of all namespaces which are older than the
+// current schema version. Items are also migrated when they are read, so
+// running it is only needed before relying on all items being migrated.
+func migrate(ctx context.Context, inv invocation) error {
+	migrator, ok := inv.store.(tableMigrator)
+	if !ok {
+		return errors.New("migrating requires -backend dynamodb")
+	}
+
+	checkpoint := service.NewExportCheckpoint(inv.segments)
+	if err := loadCheckpoint(inv.checkpoint, &checkpoint); err != nil {
+		return err
+	}
+
+	p := newProgress(inv.stderr)
+	migrated := 0
+	if !checkpoint.Done() {
+		err := migrator.MigrateInfos(ctx, checkpoint, func(n int, checkpoint service.ExportCheckpoint) error {
+			if err := saveCheckpoint(inv.checkpoint, checkpoint); err != nil {
+				return err
+			}
+
+			migrated += n
+			p.report(checkpoint.Done(), "Migrated %d infos, %d of %d segments done.", migrated, segmentsDone(checkpoint), len(checkpoint.Segments))
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return inv.out.migrated(migrated)
+}
+
+// importRecords imports the NDJSON records according to the conflict
+// policy. Records are imported with their metadata if the store supports it.
+// It stops at the first error.
+func importRecords(ctx context.Context, inv invocation) error {
+	var checkpoint importCheckpoint
+	if err := loadCheckpoint(inv.checkpoint, &checkpoint); err != nil {
+		return err
+	}
+
+	importer, withMetadata := inv.store.(recordImporter)
+	scanner := bufio.NewScanner(inv.stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	p := newProgress(inv.stderr)
+	line, imported, skipped := 0, 0, 0
+	for scanner.Scan() {
+		line++
+		if line <= checkpoint.Lines || strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record service.Record
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		stored := false
+		if err == nil && withMetadata {
+			stored, err = importer.ImportRecord(ctx, record, inv.conflict)
+		} else if err == nil {
+			stored, err = importRecord(ctx, inv, record)
+		}
+
+		if err != nil {
+			// The failed line is imported again by the resumed import.
+			if err := saveCheckpoint(inv.checkpoint, importCheckpoint{Lines: line - 1}); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if stored {
+			imported++
+		} else {
+			skipped++
+		}
+
+		if line%importCheckpointInterval == 0 {
+			if err := saveCheckpoint(inv.checkpoint, importCheckpoint{Lines: line}); err != nil {
+				return err
+			}
+		}
+
+		p.report(false, "Imported %d infos, skipped %d, at line %d.", imported, skipped, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := saveCheckpoint(inv.checkpoint, importCheckpoint{Lines: line}); err != nil {
+		return err
+	}
+
+	if withMetadata {
+		if err := importer.ImportDone(ctx); err != nil {
+			return err
+		}
+	}
+
+	return inv.out.imported(imported, skipped)
+}
+
+// importRecord imports the record with the store according to the conflict
+// policy. The metadata of the record is not imported.
+func importRecord(ctx context.Context, inv invocation, record service.Record) (bool, error) {
+	if record.Namespace != "" && record.Namespace != inv.namespace {
+		return false, fmt.Errorf("the record is in namespace %s, import it with -namespace %s or -backend dynamodb", record.Namespace, record.Namespace)
+	}
+
+	_, err := inv.store.Create(ctx, record.ID, record.Value)
+	if _, exists := err.(client.InfoAlreadyExistsError); exists {
+		switch inv.conflict {
+		case service.ConflictSkip:
+			return false, nil
+		case service.ConflictOverwrite:
+			return true, inv.store.Update(ctx, record.ID, record.Value)
+		}
+	}
+
+	return err == nil, err
+}
+
+// recordOf returns the record of the info without namespace, so that it is
+// imported into the namespace of the import.
+func recordOf(info client.Info) service.Record {
+	return service.Record{
+		ID:        info.ID,
+		Value:     info.Value,
+		Owner:     info.Owner,
+		CreatedAt: optionalTime(info.CreatedAt),
+		UpdatedAt: optionalTime(info.UpdatedAt),
+		ExpiresAt: optionalTime(info.ExpiresAt),
+		Version:   info.Version,
+	}
+}
+
+func segmentsDone(checkpoint service.ExportCheckpoint) int {
+	done := 0
+	for _, segment := range checkpoint.Segments {
+		if segment.Done {
+			done++
+		}
+	}
+
+	return done
+}
+
+// loadCheckpoint reads the checkpoint file into v. v is left unchanged if
+// there is no checkpoint file.
+func loadCheckpoint(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("checkpoint %s is invalid: %w", path, err)
+	}
+
+	return nil
+}
+
+// saveCheckpoint replaces the checkpoint file with v, so that the file is
+// never left partially written.
+func saveCheckpoint(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// progressInterval is the min. interval between progress reports.
+var progressInterval = time.Second
+
+// progress reports the progress of long running commands.
+type progress struct {
+	w    io.Writer
+	last time.Time
+}
+
+func newProgress(w io.Writer) *progress {
+	return &progress{w: w, last: time.Now()}
+}
+
+// report prints the progress if it is final or the last report is at least
+// progressInterval ago.
+func (p *progress) report(final bool, format string, args ...interface{}) {
+	if !final && time.Since(p.last) < progressInterval {
+		return
+	}
+
+	p.last = time.Now()
+	fmt.Fprintf(p.w, format+"\n", args...)
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// apiStore hides the capabilities of the direct store which the API does not
+// have.
+type apiStore struct {
+	store
+}
+
+var _ = Describe("export, import and migrate", func() {
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoCreator     servicefakes.FakeInfoCreator
+		fakeInfoGetter      servicefakes.FakeInfoGetter
+		fakeInfoUpdater     servicefakes.FakeInfoUpdater
+		fakeInfoLister      servicefakes.FakeInfoLister
+		fakeInfoExporter    servicefakes.FakeInfoExporter
+		fakeInfoImporter    servicefakes.FakeInfoImporter
+		fakeInfoMigrator    servicefakes.FakeInfoMigrator
+		fakeUsageReconciler servicefakes.FakeUsageReconciler
+
+		viaAPI     bool
+		dir        string
+		checkpoint string
+		args       []string
+		stdin      string
+		stdout     *bytes.Buffer
+		stderr     *bytes.Buffer
+		code       int
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: "team-a"}, nil)
+		fakeInfoCreator = servicefakes.FakeInfoCreator{}
+		fakeInfoGetter = servicefakes.FakeInfoGetter{}
+		fakeInfoUpdater = servicefakes.FakeInfoUpdater{}
+		fakeInfoLister = servicefakes.FakeInfoLister{}
+		fakeInfoExporter = servicefakes.FakeInfoExporter{}
+		fakeInfoImporter = servicefakes.FakeInfoImporter{}
+		fakeInfoImporter.ImportInfoReturns(true, nil)
+		fakeInfoMigrator = servicefakes.FakeInfoMigrator{}
+		fakeUsageReconciler = servicefakes.FakeUsageReconciler{}
+		viaAPI = false
+		stdin = ""
+
+		var err error
+		dir, err = ioutil.TempDir("", "infoctl")
+		Expect(err).ShouldNot(HaveOccurred())
+		checkpoint = filepath.Join(dir, "checkpoint.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		code = run(args, strings.NewReader(stdin), stdout, stderr, func(c cliConfig) (store, error) {
+			s, err := newDirectStore(services{
+				namespaceGetter: &fakeNamespaceGetter,
+				infoCreator:     &fakeInfoCreator,
+				infoGetter:      &fakeInfoGetter,
+				infoUpdater:     &fakeInfoUpdater,
+				infoLister:      &fakeInfoLister,
+				infoExporter:    &fakeInfoExporter,
+				infoImporter:    &fakeInfoImporter,
+				infoMigrator:    &fakeInfoMigrator,
+				usageReconciler: &fakeUsageReconciler,
+			}, c.namespace)
+			if viaAPI {
+				return apiStore{s}, err
+			}
+
+			return s, err
+		})
+	})
+
+	readCheckpoint := func() string {
+		data, err := ioutil.ReadFile(checkpoint)
+		Expect(err).ShouldNot(HaveOccurred())
+		return string(data)
+	}
+
+	Describe("export", func() {
+		BeforeEach(func() {
+			args = []string{"-checkpoint", checkpoint, "-segments", "2", "export"}
+			fakeInfoExporter.ExportInfosStub = func(_ context.Context, c service.ExportCheckpoint, fn func([]service.Record, service.ExportCheckpoint) error) error {
+				c.Segments[0] = service.ExportSegment{LastKey: "a"}
+				if err := fn([]service.Record{{ID: "a", Value: "a"}}, c); err != nil {
+					return err
+				}
+
+				c.Segments[0] = service.ExportSegment{Done: true}
+				c.Segments[1] = service.ExportSegment{Done: true}
+				return fn([]service.Record{{Namespace: "team-b", ID: "b", Value: "b", Version: 2}}, c)
+			}
+		})
+
+		It("should print the records of all namespaces as NDJSON", func() {
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(Equal(`{"id":"a","value":"a"}` + "\n" +
+				`{"namespace":"team-b","id":"b","value":"b","version":2}` + "\n"))
+		})
+
+		It("should scan the segments in parallel", func() {
+			_, c, _ := fakeInfoExporter.ExportInfosArgsForCall(0)
+			Expect(c.Segments).To(HaveLen(2))
+		})
+
+		It("should save the checkpoint and report the progress", func() {
+			Expect(readCheckpoint()).To(MatchJSON(`{"segments": [{"done": true}, {"done": true}]}`))
+			Expect(stderr.String()).To(Equal("Exported 2 infos, 2 of 2 segments done.\n"))
+		})
+
+		When("there is a checkpoint", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(checkpoint, []byte(`{"segments": [{"lastKey": "a"}, {"done": true}, {}]}`), 0644)).To(Succeed())
+				fakeInfoExporter.ExportInfosReturns(nil)
+			})
+
+			It("should resume from the checkpoint", func() {
+				Expect(code).To(Equal(0))
+				_, c, _ := fakeInfoExporter.ExportInfosArgsForCall(0)
+				Expect(c.Segments).To(Equal([]service.ExportSegment{{LastKey: "a"}, {Done: true}, {}}))
+			})
+		})
+
+		When("the export is complete", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(checkpoint, []byte(`{"segments": [{"done": true}]}`), 0644)).To(Succeed())
+			})
+
+			It("should not export again", func() {
+				Expect(code).To(Equal(0))
+				Expect(fakeInfoExporter.ExportInfosCallCount()).To(Equal(0))
+				Expect(stdout.String()).To(BeEmpty())
+			})
+		})
+
+		When("the backend is the API", func() {
+			BeforeEach(func() {
+				viaAPI = true
+			})
+
+			It("should require a folder prefix", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(ContainSubstring("-backend dynamodb"))
+			})
+		})
+
+		When("a folder prefix is given", func() {
+			BeforeEach(func() {
+				args = []string{"export", "folder/"}
+				fakeInfoLister.ListInfosStub = func(_ context.Context, _ service.Namespace, prefix string) (service.InfoList, error) {
+					if prefix == "folder/" {
+						return service.InfoList{IDs: []string{"folder/a", "folder/gone"}, Folders: []string{"folder/b/"}}, nil
+					}
+
+					return service.InfoList{IDs: []string{"folder/b/c"}}, nil
+				}
+				fakeInfoGetter.GetInfoStub = func(_ context.Context, _ service.Namespace, id string) (service.Info, error) {
+					if id == "folder/gone" {
+						return service.Info{}, service.InfoNotFoundError{InfoID: id}
+					}
+
+					return service.Info{ID: id, Value: "value of " + id, Version: 1}, nil
+				}
+			})
+
+			It("should print all infos under the folder as NDJSON", func() {
+				Expect(code).To(Equal(0))
+				Expect(stdout.String()).To(Equal(`{"id":"folder/a","value":"value of folder/a","version":1}` + "\n" +
+					`{"id":"folder/b/c","value":"value of folder/b/c","version":1}` + "\n"))
+				Expect(stderr.String()).To(Equal("Exported 2 infos.\n"))
+			})
+		})
+	})
+
+	Describe("import", func() {
+		BeforeEach(func() {
+			args = []string{"-checkpoint", checkpoint, "-conflict", "skip", "import"}
+			stdin = `{"id":"a","value":"a","version":3}` + "\n\n" + `{"namespace":"team-b","id":"b","value":"b"}` + "\n"
+		})
+
+		It("should import the records with their metadata", func() {
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(Equal("Imported 2 infos, skipped 0.\n"))
+			Expect(fakeInfoImporter.ImportInfoCallCount()).To(Equal(2))
+
+			_, record, policy := fakeInfoImporter.ImportInfoArgsForCall(0)
+			Expect(record).To(Equal(service.Record{Namespace: "team-a", ID: "a", Value: "a", Version: 3}))
+			Expect(policy).To(Equal(service.ConflictSkip))
+
+			_, record, _ = fakeInfoImporter.ImportInfoArgsForCall(1)
+			Expect(record.Namespace).To(Equal("team-b"))
+		})
+
+		It("should reconcile the usage", func() {
+			Expect(fakeUsageReconciler.ReconcileUsageCallCount()).To(Equal(1))
+		})
+
+		It("should save the checkpoint", func() {
+			Expect(readCheckpoint()).To(MatchJSON(`{"lines": 3}`))
+		})
+
+		When("a record is skipped", func() {
+			BeforeEach(func() {
+				fakeInfoImporter.ImportInfoReturnsOnCall(0, false, nil)
+			})
+
+			It("should count it", func() {
+				Expect(code).To(Equal(0))
+				Expect(stdout.String()).To(Equal("Imported 1 infos, skipped 1.\n"))
+			})
+		})
+
+		When("there is a checkpoint", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(checkpoint, []byte(`{"lines": 1}`), 0644)).To(Succeed())
+			})
+
+			It("should skip the imported lines", func() {
+				Expect(code).To(Equal(0))
+				Expect(fakeInfoImporter.ImportInfoCallCount()).To(Equal(1))
+				_, record, _ := fakeInfoImporter.ImportInfoArgsForCall(0)
+				Expect(record.ID).To(Equal("b"))
+			})
+		})
+
+		When("a record fails", func() {
+			BeforeEach(func() {
+				args = []string{"-checkpoint", checkpoint, "import"}
+				fakeInfoImporter.ImportInfoReturnsOnCall(1, false, service.InfoAlreadyExistsError{InfoID: "b"})
+			})
+
+			It("should stop with the line of the error", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal("line 3: Info with id b already exists.\n"))
+				_, _, policy := fakeInfoImporter.ImportInfoArgsForCall(0)
+				Expect(policy).To(Equal(service.ConflictFail))
+			})
+
+			It("should save the checkpoint before the line", func() {
+				Expect(readCheckpoint()).To(MatchJSON(`{"lines": 2}`))
+			})
+
+			It("should not reconcile the usage", func() {
+				Expect(fakeUsageReconciler.ReconcileUsageCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the backend is the API", func() {
+			BeforeEach(func() {
+				viaAPI = true
+				stdin = `{"id":"a","value":"a"}` + "\n" + `{"id":"b","value":"b"}` + "\n"
+				fakeInfoCreator.CreateInfoReturnsOnCall(1, service.Info{}, client.InfoAlreadyExistsError{InfoID: "b"})
+			})
+
+			It("should create the infos and skip existing infos", func() {
+				Expect(code).To(Equal(0))
+				Expect(stdout.String()).To(Equal("Imported 1 infos, skipped 1.\n"))
+				Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(0))
+			})
+
+			When("the conflict policy is overwrite", func() {
+				BeforeEach(func() {
+					args = []string{"-conflict", "overwrite", "import"}
+				})
+
+				It("should update existing infos", func() {
+					Expect(code).To(Equal(0))
+					Expect(stdout.String()).To(Equal("Imported 2 infos, skipped 0.\n"))
+					_, _, _, id, value, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+					Expect(id).To(Equal("b"))
+					Expect(value).To(Equal("b"))
+				})
+			})
+
+			When("a record is in another namespace", func() {
+				BeforeEach(func() {
+					stdin = `{"namespace":"team-b","id":"a","value":"a"}` + "\n"
+				})
+
+				It("should fail", func() {
+					Expect(code).To(Equal(1))
+					Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	Describe("migrate", func() {
+		BeforeEach(func() {
+			args = []string{"-checkpoint", checkpoint, "-segments", "2", "migrate"}
+			fakeInfoMigrator.MigrateInfosStub = func(_ context.Context, c service.ExportCheckpoint, fn func(int, service.ExportCheckpoint) error) error {
+				c.Segments[0] = service.ExportSegment{Done: true}
+				if err := fn(2, c); err != nil {
+					return err
+				}
+
+				c.Segments[1] = service.ExportSegment{Done: true}
+				return fn(1, c)
+			}
+		})
+
+		It("should migrate the infos of all namespaces", func() {
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(Equal("Migrated 3 infos.\n"))
+			_, c, _ := fakeInfoMigrator.MigrateInfosArgsForCall(0)
+			Expect(c.Segments).To(HaveLen(2))
+		})
+
+		It("should save the checkpoint and report the progress", func() {
+			Expect(readCheckpoint()).To(MatchJSON(`{"segments": [{"done": true}, {"done": true}]}`))
+			Expect(stderr.String()).To(Equal("Migrated 3 infos, 2 of 2 segments done.\n"))
+		})
+
+		When("the migration is complete", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(checkpoint, []byte(`{"segments": [{"done": true}]}`), 0644)).To(Succeed())
+			})
+
+			It("should not migrate again", func() {
+				Expect(code).To(Equal(0))
+				Expect(fakeInfoMigrator.MigrateInfosCallCount()).To(Equal(0))
+				Expect(stdout.String()).To(Equal("Migrated 0 infos.\n"))
+			})
+		})
+
+		When("the backend is the API", func() {
+			BeforeEach(func() {
+				viaAPI = true
+			})
+
+			It("should fail", func() {
+				Expect(code).To(Equal(1))
+				Expect(stderr.String()).To(Equal("migrating requires -backend dynamodb\n"))
+			})
+		})
+	})
+})
@@ -0,0 +1,154 @@
+// Command server serves the API over plain net/http, e.g. for local
+// development without SAM CLI and Docker, or for self-hosting.
+//
+//	go run ./cmd/server -addr :3000 -dynamodb-endpoint http://localhost:8000
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/httpadapter"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/service"
+)
+
+// serverConfig is the configuration of the server.
+type serverConfig struct {
+	addr              string
+	dynamoDbEndpoint  string
+	valueTableName    string
+	region            string
+	trustAPIKeyHeader bool
+	shutdownTimeout   time.Duration
+}
+
+// parseConfig returns the configuration of the command line arguments.
+// Defaults are taken from the environment where it makes sense.
+func parseConfig(args []string) (serverConfig, error) {
+	var c serverConfig
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	flags.StringVar(&c.addr, "addr", getenvOr("SERVER_ADDR", ":3000"), "address to listen on")
+	flags.StringVar(&c.dynamoDbEndpoint, "dynamodb-endpoint", getenvOr("DYNAMODB_ENDPOINT", "http://localhost:8000"),
+		"endpoint of DynamoDB, empty for the endpoint of the region")
+	flags.StringVar(&c.valueTableName, "table", getenvOr("VALUE_TABLE_REF", config.LocalValueTableName),
+		"name of the value table")
+	flags.StringVar(&c.region, "region", getenvOr("AWS_REGION", "us-east-1"), "AWS region of DynamoDB")
+	flags.BoolVar(&c.trustAPIKeyHeader, "trust-api-key-header", false,
+		"take the API key in the X-Api-Key header as verified, as API Gateway would")
+	flags.DurationVar(&c.shutdownTimeout, "shutdown-timeout", 10*time.Second,
+		"time to wait for in-flight requests on shutdown")
+	err := flags.Parse(args)
+	return c, err
+}
+
+// appConfig returns the configuration of the app loaded like in Lambda, with
+// the backend of the server configuration.
+func (c serverConfig) appConfig() (config.Config, error) {
+	app, err := config.FromEnv()
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	app.DynamoDbEndpoint = c.dynamoDbEndpoint
+	app.ValueTableName = c.valueTableName
+	app.Region = c.region
+	return app, app.Validate()
+}
+
+// newServices returns the services of the app. Rate limit buckets are kept
+// in memory, since the server is a single process.
+func newServices(app config.Config) routes.Services {
+	services := routes.NewServices(app)
+	services.RateLimiter = ratelimit.NewMemoryLimiter()
+	return services
+}
+
+// newServer returns the server of the API with the configuration of the app.
+func newServer(c serverConfig, app config.Config, services routes.Services) *http.Server {
+	return &http.Server{
+		Addr: c.addr,
+		Handler: httpadapter.Handler(routes.NewHandler(app, services), httpadapter.Options{
+			TrustAPIKeyHeader: c.trustAPIKeyHeader,
+			MaxBodyBytes:      maxBodyBytes(app),
+			Timeout:           requestTimeout,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// maxBodyBytes returns the max. size of request bodies, so that the longest
+// value of any namespace fits even in an envelope, which escapes each byte
+// as \u00XX at worst.
+func maxBodyBytes(app config.Config) int64 {
+	maxLen := service.ValueMaxLen
+	for _, ns := range app.Namespaces {
+		if ns.ValueMaxLen > maxLen {
+			maxLen = ns.ValueMaxLen
+		}
+	}
+
+	return int64(6*maxLen + envelopeOverhead)
+}
+
+// envelopeOverhead is the max. size of an envelope without its value.
+const envelopeOverhead = 4096
+
+// requestTimeout is the deadline of requests, like the timeout of Lambda
+// functions, which bounds the calls of DynamoDB.
+const requestTimeout = 10 * time.Second
+
+func getenvOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return fallback
+}
+
+func main() {
+	c, err := parseConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	} else if err != nil {
+		os.Exit(2)
+	}
+
+	app, err := c.appConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := newServer(c, app, newServices(app))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		log.Printf("shutting down, waiting up to %s for in-flight requests", c.shutdownTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+	}()
+
+	log.Printf("listening on %s, DynamoDB endpoint %q, table %s", c.addr, c.dynamoDbEndpoint, c.valueTableName)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	<-done
+}
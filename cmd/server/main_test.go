@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseConfig()", func() {
+	BeforeEach(func() {
+		os.Unsetenv("SERVER_ADDR")
+		os.Unsetenv("DYNAMODB_ENDPOINT")
+	})
+
+	It("should have defaults for local development", func() {
+		c, err := parseConfig(nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.addr).To(Equal(":3000"))
+		Expect(c.dynamoDbEndpoint).To(Equal("http://localhost:8000"))
+		Expect(c.trustAPIKeyHeader).To(BeFalse())
+		Expect(c.shutdownTimeout).To(Equal(10 * time.Second))
+	})
+
+	It("should take defaults from the environment", func() {
+		os.Setenv("SERVER_ADDR", ":8080")
+		defer os.Unsetenv("SERVER_ADDR")
+
+		c, err := parseConfig(nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.addr).To(Equal(":8080"))
+	})
+
+	It("should parse flags", func() {
+		c, err := parseConfig([]string{"-addr", "127.0.0.1:9000", "-dynamodb-endpoint", "", "-trust-api-key-header", "-shutdown-timeout", "1s"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(c.addr).To(Equal("127.0.0.1:9000"))
+		Expect(c.dynamoDbEndpoint).To(BeEmpty())
+		Expect(c.trustAPIKeyHeader).To(BeTrue())
+		Expect(c.shutdownTimeout).To(Equal(time.Second))
+	})
+
+	It("should fail on unknown flags", func() {
+		_, err := parseConfig([]string{"-unknown"})
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("appConfig()", func() {
+	AfterEach(func() {
+		os.Unsetenv("RATE_LIMITS")
+		os.Unsetenv("VALUE_TABLE_NAME")
+	})
+
+	It("should load the configuration with the backend of the flags", func() {
+		os.Setenv("RATE_LIMITS", `{"*": {"rate": 1, "burst": 1}}`)
+		os.Setenv("VALUE_TABLE_NAME", "other-table")
+
+		c, err := parseConfig([]string{"-table", "table", "-region", "eu-central-1"})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		app, err := c.appConfig()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(app.ValueTableName).To(Equal("table"))
+		Expect(app.Region).To(Equal("eu-central-1"))
+		Expect(app.DynamoDbEndpoint).To(Equal("http://localhost:8000"))
+		Expect(app.RateLimits).To(HaveKey("*"))
+	})
+
+	It("should fail if the configuration is invalid", func() {
+		c, err := parseConfig([]string{"-table", ""})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = c.appConfig()
+		Expect(err).To(BeAssignableToTypeOf(config.InvalidConfigError{}))
+	})
+})
+
+var _ = Describe("newServer()", func() {
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoGetter      servicefakes.FakeInfoGetter
+		path                string
+		recorder            *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, nil)
+		fakeInfoGetter = servicefakes.FakeInfoGetter{}
+		fakeInfoGetter.GetInfoReturns(service.Info{ID: "folder/info-id", Value: "info value"}, nil)
+		path = "/i/folder/info-id"
+	})
+
+	JustBeforeEach(func() {
+		c, err := parseConfig(nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		server := newServer(c, config.Default(), routes.Services{
+			RateLimiter:     ratelimit.NewMemoryLimiter(),
+			NamespaceGetter: &fakeNamespaceGetter,
+			InfoGetter:      &fakeInfoGetter,
+		})
+
+		recorder = httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	})
+
+	It("should serve the routes of the API", func() {
+		Expect(recorder.Code).To(Equal(200))
+		body, _ := ioutil.ReadAll(recorder.Body)
+		Expect(string(body)).To(Equal("info value"))
+
+		Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(1))
+		_, _, id := fakeInfoGetter.GetInfoArgsForCall(0)
+		Expect(id).To(Equal("folder/info-id"))
+	})
+
+	It("should apply the common middlewares", func() {
+		Expect(recorder.Header().Get("X-Request-Id")).NotTo(BeEmpty())
+	})
+
+	When("no route matches the path", func() {
+		BeforeEach(func() {
+			path = "/unknown"
+		})
+
+		It("should return 404 with a problem", func() {
+			Expect(recorder.Code).To(Equal(404))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal(problem.ContentType))
+		})
+	})
+})
+
+var _ = Describe("newServices()", func() {
+	It("should keep the rate limits in memory", func() {
+		Expect(newServices(config.Default()).RateLimiter).To(Equal(ratelimit.NewMemoryLimiter()))
+	})
+})
+
+var _ = Describe("maxBodyBytes()", func() {
+	It("should fit an envelope of the longest value of any namespace", func() {
+		app := config.Default()
+		Expect(maxBodyBytes(app)).To(BeNumerically(">", 6*service.ValueMaxLen))
+
+		app.Namespaces = map[string]config.Namespace{"team-a": {ValueMaxLen: 5000}}
+		Expect(maxBodyBytes(app)).To(BeNumerically(">", 6*5000))
+	})
+})
@@ -0,0 +1,158 @@
+// Command valuetable creates and deletes value tables in DynamoDB, by default
+// in DynamoDB local, and prints the table definition for the AWS CLI and
+// template.yaml.
+//
+//	go run ./cmd/valuetable create
+//	go run ./cmd/valuetable -o local-dynamodb-value-table.json cli-input
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper/awshelper"
+	"simple-information-store-app/internal/valuetable"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const usage = `Usage: valuetable [options] <command>
+
+Commands:
+  create [name]   create the value table and wait until it is active
+  delete [name]   delete the value table and wait until it is gone
+  cli-input       print the input of aws dynamodb create-table --cli-input-json
+  template        print the properties of ValueTable in template.yaml
+
+Options:
+`
+
+// cliConfig is the configuration of valuetable.
+type cliConfig struct {
+	dynamoDbEndpoint string
+	region           string
+	output           string
+
+	// wait is how long create and delete wait for DynamoDB to accept
+	// connections, e.g. for DynamoDB local which was just started.
+	wait time.Duration
+}
+
+// parseConfig returns the configuration and the remaining arguments of the
+// command line arguments.
+func parseConfig(args []string, stderr io.Writer) (cliConfig, []string, error) {
+	var c cliConfig
+	flags := flag.NewFlagSet("valuetable", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&c.dynamoDbEndpoint, "dynamodb-endpoint", getenvOr("DYNAMODB_ENDPOINT", "http://localhost:8000"),
+		"endpoint of DynamoDB, empty for the endpoint of the region")
+	flags.StringVar(&c.region, "region", getenvOr("AWS_REGION", "us-east-1"), "AWS region of DynamoDB")
+	flags.StringVar(&c.output, "o", "", "file to write cli-input and template to instead of stdout")
+	flags.DurationVar(&c.wait, "wait", 30*time.Second, "time to wait for DynamoDB to accept connections")
+	if err := flags.Parse(args); err != nil {
+		return cliConfig{}, nil, err
+	}
+
+	return c, flags.Args(), nil
+}
+
+func getenvOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return fallback
+}
+
+// run runs the command line and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	c, args, err := parseConfig(args, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	table := config.Config{
+		DynamoDbEndpoint: c.dynamoDbEndpoint,
+		Region:           c.region,
+		ValueTableName:   config.LocalValueTableName,
+	}
+	if len(args) > 1 {
+		table.ValueTableName = args[1]
+	}
+
+	switch args[0] {
+	case "create", "delete":
+		err = waitForDynamoDb(table, c.wait)
+		if err == nil && args[0] == "create" {
+			err = valuetable.Create(table)
+		} else if err == nil {
+			err = valuetable.Delete(table)
+		}
+	case "cli-input":
+		err = write(c.output, stdout, valuetable.CLIInput)
+	case "template":
+		err = write(c.output, stdout, valuetable.TemplateProperties)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// write writes the generated content to the file, or to stdout if filename
+// is empty.
+func write(filename string, stdout io.Writer, generate func() ([]byte, error)) error {
+	b, err := generate()
+	if err != nil {
+		return err
+	}
+
+	if filename != "" {
+		return ioutil.WriteFile(filename, b, 0644)
+	}
+
+	_, err = stdout.Write(b)
+	return err
+}
+
+// waitForDynamoDb waits until DynamoDB of the configuration accepts
+// connections, at most for the duration.
+func waitForDynamoDb(c config.Config, d time.Duration) error {
+	dynamoDbClient := awshelper.GetDynamoDbClient(c.DynamoDbEndpoint, c.Region)
+	deadline := time.Now().Add(d)
+	for {
+		_, err := dynamoDbClient.ListTables(&dynamodb.ListTablesInput{})
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"simple-information-store-app/internal/valuetable"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("run()", func() {
+	var (
+		args   []string
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+		code   int
+	)
+
+	JustBeforeEach(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		code = run(args, stdout, stderr)
+	})
+
+	When("cli-input is run", func() {
+		BeforeEach(func() {
+			args = []string{"cli-input"}
+		})
+
+		It("should print the input of the AWS CLI", func() {
+			expected, err := valuetable.CLIInput()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(Equal(string(expected)))
+		})
+	})
+
+	When("template is run with an output file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "valuetable")
+			Expect(err).ShouldNot(HaveOccurred())
+			args = []string{"-o", filepath.Join(dir, "properties.yaml"), "template"}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("should write the properties of the template to the file", func() {
+			expected, err := valuetable.TemplateProperties()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(BeEmpty())
+
+			b, err := ioutil.ReadFile(filepath.Join(dir, "properties.yaml"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(b).To(Equal(expected))
+		})
+	})
+
+	When("the command is unknown", func() {
+		BeforeEach(func() {
+			args = []string{"drop"}
+		})
+
+		It("should fail", func() {
+			Expect(code).To(Equal(2))
+			Expect(stderr.String()).To(Equal("unknown command \"drop\"\n"))
+		})
+	})
+
+	When("no command is given", func() {
+		BeforeEach(func() {
+			args = nil
+		})
+
+		It("should print the usage", func() {
+			Expect(code).To(Equal(2))
+			Expect(stderr.String()).To(HavePrefix("Usage: valuetable"))
+		})
+	})
+})
@@ -9,4 +9,5 @@ require (
 	github.com/maxbrunsfeld/counterfeiter/v6 v6.4.1
 	github.com/onsi/ginkgo v1.15.1
 	github.com/onsi/gomega v1.11.0
+	gopkg.in/yaml.v2 v2.4.0
 )
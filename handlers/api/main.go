@@ -0,0 +1,21 @@
+package main
+
+import (
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/lambdaevent"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/routes"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// handler serves all routes. main builds it once per container, so that warm
+// invocations reuse the router, the middlewares and the services.
+var handler middleware.Handler
+
+func main() {
+	cfg := config.MustLoad()
+	handler = routes.NewHandler(cfg, routes.NewServices(cfg))
+
+	lambda.Start(lambdaevent.Handler(handler))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/lambdaevent"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("api handler", func() {
+	const requestId = "request-id"
+
+	var (
+		cfg                 config.Config
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoCreator     servicefakes.FakeInfoCreator
+		fakeInfoGetter      servicefakes.FakeInfoGetter
+		fakeInfoLister      servicefakes.FakeInfoLister
+		fakeInfoMetaGetter  servicefakes.FakeInfoMetaGetter
+		fakeInfoUpdater     servicefakes.FakeInfoUpdater
+		fakeInfoDeleter     servicefakes.FakeInfoDeleter
+		fakeUsageGetter     servicefakes.FakeUsageGetter
+	)
+
+	BeforeEach(func() {
+		cfg = config.Default()
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceStub = func(name string) (service.Namespace, error) {
+			return service.Namespace{Name: name}, nil
+		}
+		fakeInfoCreator = servicefakes.FakeInfoCreator{}
+		fakeInfoCreator.CreateInfoStub = func(_ context.Context, _ service.Namespace, _, id, value string, _ service.InfoAttributes) (service.Info, error) {
+			return service.Info{ID: id, Value: value}, nil
+		}
+		fakeInfoGetter = servicefakes.FakeInfoGetter{}
+		fakeInfoLister = servicefakes.FakeInfoLister{}
+		fakeInfoMetaGetter = servicefakes.FakeInfoMetaGetter{}
+		fakeInfoUpdater = servicefakes.FakeInfoUpdater{}
+		fakeInfoDeleter = servicefakes.FakeInfoDeleter{}
+		fakeUsageGetter = servicefakes.FakeUsageGetter{}
+	})
+
+	JustBeforeEach(func() {
+		handler = routes.NewHandler(cfg, routes.Services{
+			RateLimiter:     ratelimit.NewMemoryLimiter(),
+			RateLimits:      ratelimit.LimitsOf(cfg),
+			NamespaceGetter: &fakeNamespaceGetter,
+			InfoCreator:     &fakeInfoCreator,
+			InfoGetter:      &fakeInfoGetter,
+			InfoLister:      &fakeInfoLister,
+			InfoMetaGetter:  &fakeInfoMetaGetter,
+			InfoUpdater:     &fakeInfoUpdater,
+			InfoDeleter:     &fakeInfoDeleter,
+			UsageGetter:     &fakeUsageGetter,
+		})
+	})
+
+	// request returns a request as API Gateway sends it to the proxy resource.
+	request := func(method, path string) events.APIGatewayProxyRequest {
+		return events.APIGatewayProxyRequest{
+			HTTPMethod: method,
+			Path:       path,
+			Resource:   "/{proxy+}",
+			PathParameters: map[string]string{
+				"proxy": path[1:],
+			},
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: requestId,
+			},
+		}
+	}
+
+	DescribeTable("routes",
+		func(method, path string, expectedStatusCode int, expectedNamespace string, expectCall func() (service.Namespace, string)) {
+			response, err := handler(context.Background(), request(method, path))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(expectedStatusCode))
+			Expect(response.Headers).To(HaveKeyWithValue("X-Request-Id", requestId))
+
+			Expect(fakeNamespaceGetter.GetNamespaceCallCount()).To(Equal(1))
+			Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal(expectedNamespace))
+
+			ns, id := expectCall()
+			Expect(ns.Name).To(Equal(expectedNamespace))
+			Expect(id).To(Equal("folder/info-id"))
+		},
+		Entry("create", "POST", "/i/folder/info-id", 201, "", func() (service.Namespace, string) {
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(1))
+			_, ns, _, id, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			return ns, id
+		}),
+		Entry("get", "GET", "/n/team-a/i/folder/info-id", 200, "team-a", func() (service.Namespace, string) {
+			Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(1))
+			_, ns, id := fakeInfoGetter.GetInfoArgsForCall(0)
+			return ns, id
+		}),
+		Entry("head", "HEAD", "/i/folder/info-id", 200, "", func() (service.Namespace, string) {
+			Expect(fakeInfoMetaGetter.GetInfoMetaCallCount()).To(Equal(1))
+			_, ns, id := fakeInfoMetaGetter.GetInfoMetaArgsForCall(0)
+			return ns, id
+		}),
+		Entry("update", "PUT", "/n/team-a/i/folder/info-id", 200, "team-a", func() (service.Namespace, string) {
+			Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(1))
+			_, ns, _, id, _, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			return ns, id
+		}),
+		Entry("delete", "DELETE", "/i/folder/info-id", 204, "", func() (service.Namespace, string) {
+			Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(1))
+			_, ns, _, id := fakeInfoDeleter.DeleteInfoArgsForCall(0)
+			return ns, id
+		}),
+	)
+
+	It("should create an info with a generated id without id in the path", func() {
+		response, err := handler(context.Background(), request("POST", "/n/team-a/i"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(201))
+
+		_, ns, _, id, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+		Expect(ns.Name).To(Equal("team-a"))
+		Expect(id).To(HaveLen(36)) // A UUID should have 36 chars.
+	})
+
+	It("should get the usage of the namespace", func() {
+		response, err := handler(context.Background(), request("GET", "/n/team-a/usage"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(200))
+
+		Expect(fakeUsageGetter.GetUsageCallCount()).To(Equal(1))
+		_, ns, _ := fakeUsageGetter.GetUsageArgsForCall(0)
+		Expect(ns.Name).To(Equal("team-a"))
+	})
+
+	It("should answer OPTIONS requests with the allowed methods", func() {
+		response, err := handler(context.Background(), request("OPTIONS", "/i/info-id"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(204))
+		Expect(response.Headers).To(HaveKey("Allow"))
+		Expect(fakeNamespaceGetter.GetNamespaceCallCount()).To(Equal(0))
+	})
+
+	It("should return 404 for unknown routes", func() {
+		response, err := handler(context.Background(), request("GET", "/unknown"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(404))
+		Expect(problemtest.Of(response).Type).To(Equal(problem.TypeRouteNotFound))
+		Expect(response.Headers).To(HaveKeyWithValue("X-Request-Id", requestId))
+	})
+
+	It("should return 405 for methods not allowed on the route", func() {
+		response, err := handler(context.Background(), request("DELETE", "/usage"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(405))
+		Expect(problemtest.Of(response).Type).To(Equal(problem.TypeMethodNotAllowed))
+		Expect(response.Headers).To(HaveKeyWithValue("Allow", "GET, OPTIONS"))
+	})
+
+	It("should route Function URL events, which have no path parameters", func() {
+		event := []byte(`{
+			"version": "2.0",
+			"rawPath": "/n/team-a/i/folder/info-id",
+			"requestContext": {"http": {"method": "GET", "path": "/n/team-a/i/folder/info-id"}, "requestId": "request-id"}
+		}`)
+
+		response, err := lambdaevent.Handler(handler)(context.Background(), event)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.(events.APIGatewayV2HTTPResponse).StatusCode).To(Equal(200))
+
+		_, ns, id := fakeInfoGetter.GetInfoArgsForCall(0)
+		Expect(ns.Name).To(Equal("team-a"))
+		Expect(id).To(Equal("folder/info-id"))
+	})
+
+	When("a route is rate limited", func() {
+		BeforeEach(func() {
+			cfg.RateLimits = map[string]config.RateLimit{"POST /i": {Rate: 1, Burst: 1}}
+		})
+
+		It("should limit the requests of the route by its resource", func() {
+			response, err := handler(context.Background(), request("POST", "/i"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(201))
+
+			response, err = handler(context.Background(), request("POST", "/i"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(429))
+
+			response, err = handler(context.Background(), request("POST", "/i/info-id"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(201))
+		})
+	})
+})
@@ -1,51 +1,27 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
 	"simple-information-store-app/internal/service"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/google/uuid"
 )
 
-var infoCreator service.InfoCreator = service.NewInfoService()
-
-func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	body := request.Body
-
-	// Generate an Id
-	id := uuid.New().String()
-	value := body
-	info, err := infoCreator.CreateInfo(id, value)
+// handler serves the route. main builds it once per container, so that warm
+// invocations reuse the middlewares and the services.
+var handler middleware.Handler
 
-	switch err := err.(type) {
-	case nil:
-		break
-	case service.ValueTooLongError:
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       err.Error(),
-		}, nil
-	default:
-		fmt.Printf("Error when putting item: %s\n", err.Error())
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-		}, nil
-	}
-
-	responseBody := map[string]string{
-		"id": info.ID,
-	}
-	responseBodyBytes, _ := json.Marshal(responseBody)
-	return events.APIGatewayProxyResponse{
-		StatusCode: 201,
-		Body:       string(responseBodyBytes),
-	}, nil
+// newHandler returns the handler of the route behind the middlewares of the API.
+func newHandler(c config.Config, rateLimiter ratelimit.Limiter, namespaceGetter service.NamespaceGetter, infoCreator service.InfoCreator) middleware.Handler {
+	return middleware.API(c, rateLimiter, namespaceGetter, routes.CreateValue(infoCreator))
 }
 
 func main() {
+	cfg := config.MustLoad()
+	handler = newHandler(cfg, ratelimit.NewDynamoDbLimiter(cfg), service.NewNamespaceGetter(cfg), service.NewInfoService(cfg))
+
 	lambda.Start(handler)
 }
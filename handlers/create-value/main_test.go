@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/ratelimit"
 	"simple-information-store-app/internal/service"
 	"simple-information-store-app/internal/servicefakes"
 
@@ -12,45 +18,284 @@ import (
 )
 
 var _ = Describe("create-value handler", func() {
-	const requestBody = "Test value in request body"
+	const (
+		namespace   = "team-a"
+		apiKeyId    = "api-key-id"
+		requestId   = "request-id"
+		requestBody = "Test value in request body"
+	)
 
 	var (
-		fakeInfoCreator servicefakes.FakeInfoCreator
-		handlerResponse events.APIGatewayProxyResponse
+		cfg                 config.Config
+		rateLimiter         ratelimit.Limiter
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoCreator     servicefakes.FakeInfoCreator
+		pathParameters      map[string]string
+		headers             map[string]string
+		body                string
+		request             events.APIGatewayProxyRequest
+		handlerResponse     events.APIGatewayProxyResponse
 	)
 
 	BeforeEach(func() {
+		cfg = config.Default()
+		rateLimiter = ratelimit.NewMemoryLimiter()
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: namespace}, nil)
 		fakeInfoCreator = servicefakes.FakeInfoCreator{}
-		infoCreator = &fakeInfoCreator
+		pathParameters = map[string]string{"namespace": namespace}
+		headers = nil
+		body = requestBody
 	})
 
 	JustBeforeEach(func() {
+		handler = newHandler(cfg, rateLimiter, &fakeNamespaceGetter, &fakeInfoCreator)
+
 		var err error
-		handlerResponse, err = handler(events.APIGatewayProxyRequest{
-			Body: requestBody,
-		})
+		request = events.APIGatewayProxyRequest{
+			PathParameters: pathParameters,
+			Headers:        headers,
+			Body:           body,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: requestId,
+				Identity: events.APIGatewayRequestIdentity{
+					APIKey:   "api-key",
+					APIKeyID: apiKeyId,
+				},
+			},
+		}
+		handlerResponse, err = handler(context.Background(), request)
 
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 
+	It("should get the namespace from the path", func() {
+		Expect(fakeNamespaceGetter.GetNamespaceCallCount()).To(Equal(1))
+		Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal(namespace))
+	})
+
+	It("should call CreateInfo() in the namespace", func() {
+		Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(1))
+
+		_, ns, _, _, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+		Expect(ns.Name).To(Equal(namespace))
+	})
+
+	When("the client exceeds the rate limit", func() {
+		BeforeEach(func() {
+			cfg.RateLimits = map[string]config.RateLimit{"*": {Rate: 1, Burst: 1}}
+		})
+
+		It("should return 429 with Retry-After", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(201))
+
+			response, err := handler(context.Background(), request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(429))
+			Expect(response.Headers).To(HaveKey("Retry-After"))
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(1))
+		})
+	})
+
+	When("GetNamespace() returns NamespaceNotFoundError", func() {
+		var namespaceNotFoundError service.NamespaceNotFoundError
+
+		BeforeEach(func() {
+			namespaceNotFoundError = service.NamespaceNotFoundError{Namespace: namespace}
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, namespaceNotFoundError)
+		})
+
+		It("should return 404 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(404))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(namespaceNotFoundError.Error()))
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the namespace does not allow the authentication method", func() {
+		BeforeEach(func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{
+				Name:        namespace,
+				AuthMethods: []string{auth.MethodIAM},
+			}, nil)
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+		})
+	})
+
 	It("should call CreateInfo() with a generated UUID", func() {
 		Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(1))
 
-		id, value := fakeInfoCreator.CreateInfoArgsForCall(0)
+		_, _, _, id, value, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
 		Expect(id).To(HaveLen(36)) // A UUID should have 36 chars.
 		Expect(value).To(Equal(requestBody))
 	})
 
+	It("should call CreateInfo() with the authenticated owner", func() {
+		_, _, owner, _, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+		Expect(owner).To(Equal(apiKeyId))
+	})
+
 	It("should generate a new UUID each time", func() {
-		handler(events.APIGatewayProxyRequest{Body: requestBody})
+		handler(context.Background(), events.APIGatewayProxyRequest{Body: requestBody})
 
 		Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(2))
-		id1, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
-		id2, _ := fakeInfoCreator.CreateInfoArgsForCall(1)
+		_, _, _, id1, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+		_, _, _, id2, _, _ := fakeInfoCreator.CreateInfoArgsForCall(1)
 
 		Expect(id1).ToNot(Equal(id2))
 	})
 
+	When("a path is given", func() {
+		const infoId = "a/b/c"
+
+		BeforeEach(func() {
+			pathParameters["id"] = infoId
+		})
+
+		It("should call CreateInfo() with the path as id", func() {
+			Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(1))
+
+			_, _, _, id, value, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(id).To(Equal(infoId))
+			Expect(value).To(Equal(requestBody))
+		})
+	})
+
+	When("a content type and tags are given", func() {
+		BeforeEach(func() {
+			headers = map[string]string{
+				"Content-Type": "application/xml",
+				"X-Info-Tags":  "env=prod",
+			}
+		})
+
+		It("should call CreateInfo() with them", func() {
+			_, _, _, _, _, attrs := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(attrs).To(Equal(service.InfoAttributes{
+				ContentType: "application/xml",
+				Tags:        map[string]string{"env": "prod"},
+			}))
+		})
+
+		When("the tags are invalid", func() {
+			BeforeEach(func() {
+				headers["X-Info-Tags"] = "env"
+			})
+
+			It("should return 400", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInvalidRequest))
+				Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	When("the body is raw JSON", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"Content-Type": "application/json"}
+			body = `{"id": "a/b", "value": "json value"}`
+		})
+
+		It("should call CreateInfo() with the body as value", func() {
+			_, _, _, id, value, attrs := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(id).To(HaveLen(36))
+			Expect(value).To(Equal(body))
+			Expect(attrs.ContentType).To(Equal("application/json"))
+		})
+	})
+
+	When("the body is a JSON envelope", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"Content-Type": "application/vnd.info+json"}
+			body = `{"id": "a/b", "value": "envelope value"}`
+		})
+
+		It("should call CreateInfo() with the id and value of the envelope", func() {
+			_, _, _, id, value, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			Expect(id).To(Equal("a/b"))
+			Expect(value).To(Equal("envelope value"))
+		})
+
+		When("the envelope has no id", func() {
+			BeforeEach(func() {
+				body = `{"value": "envelope value"}`
+			})
+
+			It("should call CreateInfo() with a generated UUID", func() {
+				_, _, _, id, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+				Expect(id).To(HaveLen(36))
+			})
+		})
+
+		When("the id does not match the path", func() {
+			BeforeEach(func() {
+				pathParameters["id"] = "a/c"
+			})
+
+			It("should return 400", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the envelope is invalid", func() {
+			BeforeEach(func() {
+				body = `not json`
+			})
+
+			It("should return 400", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(fakeInfoCreator.CreateInfoCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	When("CreateInfo() returns InvalidIDError", func() {
+		var invalidIDError service.InvalidIDError
+
+		BeforeEach(func() {
+			invalidIDError = service.InvalidIDError{InfoID: "a//b", Reason: "reason"}
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, invalidIDError)
+		})
+
+		It("should return 400 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(400))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(invalidIDError.Error()))
+		})
+	})
+
+	When("CreateInfo() returns InfoAlreadyExistsError", func() {
+		var infoAlreadyExistsError service.InfoAlreadyExistsError
+
+		BeforeEach(func() {
+			infoAlreadyExistsError = service.InfoAlreadyExistsError{InfoID: "a/b/c"}
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, infoAlreadyExistsError)
+		})
+
+		It("should return 409 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(409))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(infoAlreadyExistsError.Error()))
+		})
+	})
+
+	When("CreateInfo() returns QuotaExceededError", func() {
+		var quotaExceededError service.QuotaExceededError
+
+		BeforeEach(func() {
+			quotaExceededError = service.QuotaExceededError{Namespace: namespace, Quota: service.QuotaItems, Limit: 10}
+			fakeInfoCreator.CreateInfoReturns(service.Info{}, quotaExceededError)
+		})
+
+		It("should return 403 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(quotaExceededError.Error()))
+		})
+	})
+
 	When("CreateInfo() returns ValueTooLongError", func() {
 		var valueTooLongError service.ValueTooLongError
 
@@ -64,8 +309,8 @@ var _ = Describe("create-value handler", func() {
 
 			errMsg := valueTooLongError.Error()
 			Expect(errMsg).NotTo(BeEmpty())
-			Expect(handlerResponse.Body).To(Equal(errMsg))
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(errMsg))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
 		})
 	})
 
@@ -76,14 +321,14 @@ var _ = Describe("create-value handler", func() {
 
 		It("should return 500", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(500))
-			Expect(handlerResponse.Body).To(BeEmpty())
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
 		})
 	})
 
 	When("CreateInfo() returns no error", func() {
 		BeforeEach(func() {
-			fakeInfoCreator.CreateInfoCalls(func(id, value string) (service.Info, error) {
+			fakeInfoCreator.CreateInfoCalls(func(_ context.Context, _ service.Namespace, _, id, value string, _ service.InfoAttributes) (service.Info, error) {
 				return service.Info{
 					ID:    id,
 					Value: value,
@@ -93,12 +338,12 @@ var _ = Describe("create-value handler", func() {
 
 		It("should return 201 with Id", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(201))
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(handlerResponse.Headers).To(Equal(map[string]string{"Content-Type": "application/json", "X-Request-Id": requestId}))
 
 			responseBody := make(map[string]interface{})
 			json.Unmarshal([]byte(handlerResponse.Body), &responseBody)
 
-			id, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
+			_, _, _, id, _, _ := fakeInfoCreator.CreateInfoArgsForCall(0)
 			Expect(responseBody["id"]).To(Equal(id))
 		})
 	})
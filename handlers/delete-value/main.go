@@ -0,0 +1,27 @@
+package main
+
+import (
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// handler serves the route. main builds it once per container, so that warm
+// invocations reuse the middlewares and the services.
+var handler middleware.Handler
+
+// newHandler returns the handler of the route behind the middlewares of the API.
+func newHandler(c config.Config, rateLimiter ratelimit.Limiter, namespaceGetter service.NamespaceGetter, infoDeleter service.InfoDeleter) middleware.Handler {
+	return middleware.API(c, rateLimiter, namespaceGetter, routes.DeleteValue(infoDeleter))
+}
+
+func main() {
+	cfg := config.MustLoad()
+	handler = newHandler(cfg, ratelimit.NewDynamoDbLimiter(cfg), service.NewNamespaceGetter(cfg), service.NewInfoService(cfg))
+
+	lambda.Start(handler)
+}
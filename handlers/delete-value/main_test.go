@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("delete-value handler", func() {
+	const (
+		namespace = "team-a"
+		infoId    = "a/b"
+		requestId = "request-id"
+	)
+
+	var (
+		fakeNamespaceGetter   servicefakes.FakeNamespaceGetter
+		fakeInfoDeleter       servicefakes.FakeInfoDeleter
+		queryStringParameters map[string]string
+		handlerResponse       events.APIGatewayProxyResponse
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: namespace}, nil)
+		fakeInfoDeleter = servicefakes.FakeInfoDeleter{}
+		queryStringParameters = nil
+	})
+
+	JustBeforeEach(func() {
+		handler = newHandler(config.Default(), ratelimit.NewMemoryLimiter(), &fakeNamespaceGetter, &fakeInfoDeleter)
+
+		var err error
+		handlerResponse, err = handler(context.Background(), events.APIGatewayProxyRequest{
+			PathParameters: map[string]string{
+				"namespace": namespace,
+				"id":        infoId,
+			},
+			QueryStringParameters: queryStringParameters,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: requestId,
+				Identity:  events.APIGatewayRequestIdentity{APIKey: "key", APIKeyID: "key-a"},
+			},
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should get the namespace from the path", func() {
+		Expect(fakeNamespaceGetter.GetNamespaceCallCount()).To(Equal(1))
+		Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal(namespace))
+	})
+
+	It("should call DeleteInfo() in the namespace", func() {
+		Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(1))
+
+		_, ns, principal, _ := fakeInfoDeleter.DeleteInfoArgsForCall(0)
+		Expect(ns.Name).To(Equal(namespace))
+		Expect(principal).To(Equal("key-a"))
+	})
+
+	When("DeleteInfo() returns NotOwnerError", func() {
+		BeforeEach(func() {
+			fakeInfoDeleter.DeleteInfoReturns(service.NotOwnerError{InfoID: infoId})
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeNotOwner))
+		})
+	})
+
+	When("GetNamespace() returns NamespaceNotFoundError", func() {
+		var namespaceNotFoundError service.NamespaceNotFoundError
+
+		BeforeEach(func() {
+			namespaceNotFoundError = service.NamespaceNotFoundError{Namespace: namespace}
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, namespaceNotFoundError)
+		})
+
+		It("should return 404 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(404))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(namespaceNotFoundError.Error()))
+			Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the namespace does not allow the authentication method", func() {
+		BeforeEach(func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{
+				Name:        namespace,
+				AuthMethods: []string{auth.MethodIAM},
+			}, nil)
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	It("should call DeleteInfo() with the id", func() {
+		Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(1))
+		_, _, _, id := fakeInfoDeleter.DeleteInfoArgsForCall(0)
+		Expect(id).To(Equal(infoId))
+		Expect(fakeInfoDeleter.DeleteInfosCallCount()).To(Equal(0))
+	})
+
+	When("DeleteInfo() returns an error", func() {
+		BeforeEach(func() {
+			fakeInfoDeleter.DeleteInfoReturns(errors.New("error"))
+		})
+
+		It("should return 500", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(500))
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
+		})
+	})
+
+	When("DeleteInfo() returns no error", func() {
+		It("should return 204", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(204))
+			Expect(handlerResponse.Body).To(BeEmpty())
+			Expect(handlerResponse.Headers).To(Equal(map[string]string{"X-Request-Id": requestId}))
+		})
+	})
+
+	When("recursive is in query string", func() {
+		BeforeEach(func() {
+			queryStringParameters = map[string]string{"recursive": ""}
+		})
+
+		It("should call DeleteInfos() with the id as prefix", func() {
+			Expect(fakeInfoDeleter.DeleteInfosCallCount()).To(Equal(1))
+			_, _, _, id, after := fakeInfoDeleter.DeleteInfosArgsForCall(0)
+			Expect(id).To(Equal(infoId))
+			Expect(after).To(BeEmpty())
+			Expect(fakeInfoDeleter.DeleteInfoCallCount()).To(Equal(0))
+		})
+
+		When("DeleteInfos() returns InvalidIDError", func() {
+			var invalidIDError service.InvalidIDError
+
+			BeforeEach(func() {
+				invalidIDError = service.InvalidIDError{InfoID: infoId, Reason: "reason"}
+				fakeInfoDeleter.DeleteInfosReturns(service.DeletedInfos{}, invalidIDError)
+			})
+
+			It("should return 400 with error message", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(problemtest.Of(handlerResponse).Detail).To(Equal(invalidIDError.Error()))
+			})
+		})
+
+		When("DeleteInfos() returns an error", func() {
+			BeforeEach(func() {
+				fakeInfoDeleter.DeleteInfosReturns(service.DeletedInfos{}, errors.New("error"))
+			})
+
+			It("should return 500", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(500))
+				Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+			})
+		})
+
+		When("DeleteInfos() returns no error", func() {
+			BeforeEach(func() {
+				fakeInfoDeleter.DeleteInfosReturns(service.DeletedInfos{Deleted: 3}, nil)
+			})
+
+			It("should return 200 with the number of deleted infos", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+
+				responseBody := make(map[string]interface{})
+				json.Unmarshal([]byte(handlerResponse.Body), &responseBody)
+				Expect(responseBody["deleted"]).To(BeEquivalentTo(3))
+				Expect(responseBody).NotTo(HaveKey("next"))
+			})
+		})
+
+		When("DeleteInfos() returns a next page", func() {
+			BeforeEach(func() {
+				queryStringParameters["after"] = infoId + "/a"
+				fakeInfoDeleter.DeleteInfosReturns(service.DeletedInfos{Deleted: 25, Next: infoId + "/z"}, nil)
+			})
+
+			It("should pass after and return next", func() {
+				_, _, _, _, after := fakeInfoDeleter.DeleteInfosArgsForCall(0)
+				Expect(after).To(Equal(infoId + "/a"))
+
+				responseBody := make(map[string]interface{})
+				json.Unmarshal([]byte(handlerResponse.Body), &responseBody)
+				Expect(responseBody["deleted"]).To(BeEquivalentTo(25))
+				Expect(responseBody["next"]).To(Equal(infoId + "/z"))
+			})
+		})
+	})
+})
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("get-usage handler", func() {
+	const namespace = "team-a"
+
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeUsageGetter     servicefakes.FakeUsageGetter
+		identity            events.APIGatewayRequestIdentity
+		handlerResponse     events.APIGatewayProxyResponse
+		responseBody        map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: namespace}, nil)
+		fakeUsageGetter = servicefakes.FakeUsageGetter{}
+		fakeUsageGetter.GetUsageCalls(func(_ context.Context, ns service.Namespace, owner string) (service.Usage, error) {
+			return service.Usage{Namespace: ns.Name, Owner: owner, ItemCount: 2, ByteCount: 20}, nil
+		})
+		identity = events.APIGatewayRequestIdentity{}
+	})
+
+	JustBeforeEach(func() {
+		handler = newHandler(config.Default(), ratelimit.NewMemoryLimiter(), &fakeNamespaceGetter, &fakeUsageGetter)
+
+		var err error
+		handlerResponse, err = handler(context.Background(), events.APIGatewayProxyRequest{
+			PathParameters: map[string]string{
+				"namespace": namespace,
+			},
+			RequestContext: events.APIGatewayProxyRequestContext{
+				Identity: identity,
+			},
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		responseBody = make(map[string]interface{})
+		json.Unmarshal([]byte(handlerResponse.Body), &responseBody)
+	})
+
+	When("the request is anonymous", func() {
+		It("should return 200 with the usage of the namespace only", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(200))
+			Expect(fakeUsageGetter.GetUsageCallCount()).To(Equal(1))
+			Expect(responseBody).To(HaveKey("namespace"))
+			Expect(responseBody).NotTo(HaveKey("owner"))
+
+			namespaceUsage := responseBody["namespace"].(map[string]interface{})
+			Expect(namespaceUsage["namespace"]).To(Equal(namespace))
+			Expect(namespaceUsage["itemCount"]).To(BeEquivalentTo(2))
+			Expect(namespaceUsage["byteCount"]).To(BeEquivalentTo(20))
+		})
+	})
+
+	When("the request is authenticated", func() {
+		BeforeEach(func() {
+			identity.APIKey = "api-key"
+			identity.APIKeyID = "api-key-id"
+		})
+
+		It("should return 200 with the usage of the namespace and the owner", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(200))
+			Expect(fakeUsageGetter.GetUsageCallCount()).To(Equal(2))
+			_, _, owner := fakeUsageGetter.GetUsageArgsForCall(1)
+			Expect(owner).To(Equal("api-key-id"))
+
+			ownerUsage := responseBody["owner"].(map[string]interface{})
+			Expect(ownerUsage["owner"]).To(Equal("api-key-id"))
+		})
+	})
+
+	When("GetNamespace() returns NamespaceNotFoundError", func() {
+		BeforeEach(func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, service.NamespaceNotFoundError{Namespace: namespace})
+		})
+
+		It("should return 404", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(404))
+			Expect(fakeUsageGetter.GetUsageCallCount()).To(Equal(0))
+		})
+	})
+
+	When("GetUsage() returns an error", func() {
+		BeforeEach(func() {
+			fakeUsageGetter.GetUsageCalls(nil)
+			fakeUsageGetter.GetUsageReturns(service.Usage{}, errors.New("error"))
+		})
+
+		It("should return 500", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(500))
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+		})
+	})
+})
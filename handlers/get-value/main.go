@@ -1,40 +1,29 @@
 package main
 
 import (
-	"fmt"
-
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
 	"simple-information-store-app/internal/service"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-var infoGetter service.InfoGetter = service.NewInfoService()
-
-func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	id := request.PathParameters["id"]
+// handler serves the route. main builds it once per container, so that warm
+// invocations reuse the middlewares and the services.
+var handler middleware.Handler
 
-	info, err := infoGetter.GetInfo(id)
-	switch err := err.(type) {
-	case nil:
-		break
-	case service.InfoNotFoundError:
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-		}, nil
-	default:
-		fmt.Printf("Error when retrieving item: %s\n", err.Error())
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-		}, nil
-	}
-
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-		Body:       info.Value,
-	}, nil
+// newHandler returns the handler of the route behind the middlewares of the API.
+func newHandler(c config.Config, rateLimiter ratelimit.Limiter, namespaceGetter service.NamespaceGetter, infoGetter service.InfoGetter, infoLister service.InfoLister, infoMetaGetter service.InfoMetaGetter) middleware.Handler {
+	return middleware.API(c, rateLimiter, namespaceGetter, routes.GetValue(infoGetter, infoLister, infoMetaGetter))
 }
 
 func main() {
+	cfg := config.MustLoad()
+	// One service serves all three interfaces, so that they share its retry budget.
+	infoService := service.NewInfoService(cfg)
+	handler = newHandler(cfg, ratelimit.NewDynamoDbLimiter(cfg), service.NewNamespaceGetter(cfg), infoService, infoService, infoService)
+
 	lambda.Start(handler)
 }
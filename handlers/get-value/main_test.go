@@ -1,9 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/ratelimit"
 	"simple-information-store-app/internal/service"
 	"simple-information-store-app/internal/servicefakes"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	. "github.com/onsi/ginkgo"
@@ -12,31 +28,104 @@ import (
 
 var _ = Describe("get-value handler", func() {
 	const (
+		namespace = "team-a"
 		infoId    = "info-id"
 		infoValue = "info value"
+		requestId = "request-id"
 	)
 
 	var (
-		fakeInfoGetter  servicefakes.FakeInfoGetter
-		handlerResponse events.APIGatewayProxyResponse
+		fakeNamespaceGetter   servicefakes.FakeNamespaceGetter
+		fakeInfoGetter        servicefakes.FakeInfoGetter
+		fakeInfoLister        servicefakes.FakeInfoLister
+		fakeInfoMetaGetter    servicefakes.FakeInfoMetaGetter
+		httpMethod            string
+		id                    string
+		queryStringParameters map[string]string
+		headers               map[string]string
+		handlerResponse       events.APIGatewayProxyResponse
+		ctx                   context.Context
+		cancel                context.CancelFunc
 	)
 
 	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: namespace}, nil)
 		fakeInfoGetter = servicefakes.FakeInfoGetter{}
-		infoGetter = &fakeInfoGetter
+		fakeInfoLister = servicefakes.FakeInfoLister{}
+		fakeInfoMetaGetter = servicefakes.FakeInfoMetaGetter{}
+		httpMethod = "GET"
+		id = infoId
+		queryStringParameters = nil
+		headers = nil
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
 	})
 
 	JustBeforeEach(func() {
+		handler = newHandler(config.Default(), ratelimit.NewMemoryLimiter(), &fakeNamespaceGetter, &fakeInfoGetter, &fakeInfoLister, &fakeInfoMetaGetter)
+
 		var err error
-		handlerResponse, err = handler(events.APIGatewayProxyRequest{
+		handlerResponse, err = handler(ctx, events.APIGatewayProxyRequest{
+			HTTPMethod: httpMethod,
 			PathParameters: map[string]string{
-				"id": infoId,
+				"namespace": namespace,
+				"id":        id,
+			},
+			QueryStringParameters: queryStringParameters,
+			Headers:               headers,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: requestId,
 			},
 		})
 
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 
+	It("should get the namespace from the path", func() {
+		Expect(fakeNamespaceGetter.GetNamespaceCallCount()).To(Equal(1))
+		Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal(namespace))
+	})
+
+	It("should call GetInfo() in the namespace", func() {
+		Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(1))
+
+		_, ns, _ := fakeInfoGetter.GetInfoArgsForCall(0)
+		Expect(ns.Name).To(Equal(namespace))
+	})
+
+	When("GetNamespace() returns NamespaceNotFoundError", func() {
+		var namespaceNotFoundError service.NamespaceNotFoundError
+
+		BeforeEach(func() {
+			namespaceNotFoundError = service.NamespaceNotFoundError{Namespace: namespace}
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, namespaceNotFoundError)
+		})
+
+		It("should return 404 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(404))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(namespaceNotFoundError.Error()))
+			Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the namespace does not allow the authentication method", func() {
+		BeforeEach(func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{
+				Name:        namespace,
+				AuthMethods: []string{auth.MethodIAM},
+			}, nil)
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(0))
+		})
+	})
+
 	When("GetInfo() returns InfoNotFoundError", func() {
 		BeforeEach(func() {
 			fakeInfoGetter.GetInfoReturns(service.Info{}, service.InfoNotFoundError{})
@@ -44,8 +133,8 @@ var _ = Describe("get-value handler", func() {
 
 		It("should return 404", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(404))
-			Expect(handlerResponse.Body).To(BeEmpty())
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInfoNotFound))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
 		})
 	})
 
@@ -56,20 +145,533 @@ var _ = Describe("get-value handler", func() {
 
 		It("should return 500", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(500))
-			Expect(handlerResponse.Body).To(BeEmpty())
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
+		})
+	})
+
+	When("GetInfo() does not return before the deadline", func() {
+		BeforeEach(func() {
+			ctx, cancel = context.WithTimeout(context.Background(), middleware.DeadlineMargin+50*time.Millisecond)
+			fakeInfoGetter.GetInfoStub = func(ctx context.Context, _ service.Namespace, _ string) (service.Info, error) {
+				<-ctx.Done()
+				return service.Info{}, ctx.Err()
+			}
+		})
+
+		It("should return 503 before the deadline", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(503))
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeServiceUnavailable))
+			Expect(ctx.Err()).ShouldNot(HaveOccurred())
 		})
 	})
 
 	When("GetInfo() returns no error", func() {
+		const (
+			etag         = `"hash-3"`
+			lastModified = "Mon, 01 Mar 2021 10:00:00 GMT"
+		)
+
 		BeforeEach(func() {
-			fakeInfoGetter.GetInfoReturns(service.Info{Value: infoValue}, nil)
+			fakeInfoGetter.GetInfoReturns(service.Info{
+				ID:          infoId,
+				Value:       infoValue,
+				UpdatedAt:   time.Date(2021, 3, 1, 10, 0, 0, 500, time.UTC),
+				ContentType: service.DefaultContentType,
+				ContentHash: "hash",
+				Version:     3,
+			}, nil)
 		})
 
-		It("should return 200 with body", func() {
+		It("should return 200 with body and validators", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(200))
 			Expect(handlerResponse.Body).To(Equal(infoValue))
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(handlerResponse.Headers).To(Equal(map[string]string{
+				"ETag":          etag,
+				"Last-Modified": lastModified,
+				"Accept-Ranges": "bytes",
+				"Content-Type":  service.DefaultContentType,
+				"Vary":          "Accept",
+				"X-Request-Id":  requestId,
+			}))
+		})
+
+		When("the info has another content type", func() {
+			BeforeEach(func() {
+				fakeInfoGetter.GetInfoReturns(service.Info{
+					ID:          infoId,
+					Value:       "<a/>",
+					ContentType: "application/xml",
+					ContentHash: "hash",
+				}, nil)
+			})
+
+			It("should return the value with its content type", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+				Expect(handlerResponse.Body).To(Equal("<a/>"))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", "application/xml"))
+			})
+		})
+
+		When("the envelope is accepted", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"Accept": "text/plain;q=0.5, application/vnd.info+json"}
+			})
+
+			It("should return 200 with the envelope", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", content.TypeEnvelope))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("Vary", "Accept"))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("ETag", `"hash-3-json"`))
+
+				var envelope content.Envelope
+				Expect(json.Unmarshal([]byte(handlerResponse.Body), &envelope)).To(Succeed())
+				Expect(envelope.Value).To(Equal(infoValue))
+				Expect(envelope.Meta.ETag).To(Equal(etag))
+				Expect(envelope.Links).To(HaveKeyWithValue("self", "/n/team-a/i/info-id"))
+			})
+
+			It("should not match the ETag of the raw value", func() {
+				headers["If-None-Match"] = etag
+				response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+					PathParameters: map[string]string{"id": infoId},
+					Headers:        headers,
+				})
+				Expect(response.StatusCode).To(Equal(200))
+
+				headers["If-None-Match"] = `"hash-3-json"`
+				response, _ = handler(context.Background(), events.APIGatewayProxyRequest{
+					PathParameters: map[string]string{"id": infoId},
+					Headers:        headers,
+				})
+				Expect(response.StatusCode).To(Equal(304))
+			})
+
+			It("should ignore Range", func() {
+				headers["Range"] = "bytes=0-3"
+				response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+					PathParameters: map[string]string{"id": infoId},
+					Headers:        headers,
+				})
+				Expect(response.StatusCode).To(Equal(200))
+			})
+		})
+
+		When("the value is JSON", func() {
+			BeforeEach(func() {
+				fakeInfoGetter.GetInfoReturns(service.Info{
+					ID:          infoId,
+					Value:       `{"value": 1}`,
+					ContentType: "application/json",
+					ContentHash: "hash",
+				}, nil)
+				headers = map[string]string{"Accept": "application/json"}
+			})
+
+			It("should return the raw JSON value", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+				Expect(handlerResponse.Body).To(Equal(`{"value": 1}`))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", "application/json"))
+			})
+		})
+
+		When("any type is accepted", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"Accept": "*/*"}
+			})
+
+			It("should return the raw value", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+				Expect(handlerResponse.Body).To(Equal(infoValue))
+			})
+		})
+
+		When("no supported type is accepted", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"Accept": "application/xml, text/plain;q=0"}
+			})
+
+			It("should return 406 with the offered types", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(406))
+				Expect(problemtest.Of(handlerResponse).Detail).To(ContainSubstring("text/plain, application/vnd.info+json"))
+			})
+		})
+
+		When("If-None-Match matches the ETag", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"if-none-match": `"other", W/"hash-3"`}
+			})
+
+			It("should return 304 without body", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(304))
+				Expect(handlerResponse.Body).To(BeEmpty())
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("ETag", etag))
+			})
+		})
+
+		When("If-None-Match does not match the ETag", func() {
+			BeforeEach(func() {
+				headers = map[string]string{
+					"If-None-Match":     `"other"`,
+					"If-Modified-Since": lastModified,
+				}
+			})
+
+			It("should return 200 and ignore If-Modified-Since", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+			})
+		})
+
+		When("If-Modified-Since is not before Last-Modified", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"If-Modified-Since": lastModified}
+			})
+
+			It("should return 304", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(304))
+			})
+		})
+
+		When("the info is updated with the same value", func() {
+			get := func(headers map[string]string) events.APIGatewayProxyResponse {
+				response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+					PathParameters: map[string]string{"id": infoId},
+					Headers:        headers,
+				})
+				return response
+			}
+
+			JustBeforeEach(func() {
+				fakeInfoGetter.GetInfoReturns(service.Info{
+					ID:          infoId,
+					Value:       infoValue,
+					Owner:       "new-owner",
+					UpdatedAt:   time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC),
+					ExpiresAt:   time.Date(2021, 4, 1, 11, 0, 0, 0, time.UTC),
+					ContentType: "text/markdown",
+					ContentHash: "hash",
+					Version:     4,
+				}, nil)
+			})
+
+			It("should not match the ETag of the raw value before the update", func() {
+				updated := get(map[string]string{"If-None-Match": handlerResponse.Headers["ETag"]})
+				Expect(updated.StatusCode).To(Equal(200))
+				Expect(updated.Headers).To(HaveKeyWithValue("Content-Type", "text/markdown"))
+				Expect(updated.Headers["ETag"]).NotTo(Equal(handlerResponse.Headers["ETag"]))
+			})
+
+			When("the envelope is accepted", func() {
+				BeforeEach(func() {
+					headers = map[string]string{"Accept": content.TypeEnvelope}
+				})
+
+				It("should not match the ETag of the envelope before the update", func() {
+					updated := get(map[string]string{
+						"Accept":        content.TypeEnvelope,
+						"If-None-Match": handlerResponse.Headers["ETag"],
+					})
+					Expect(updated.StatusCode).To(Equal(200))
+
+					var envelope content.Envelope
+					Expect(json.Unmarshal([]byte(updated.Body), &envelope)).To(Succeed())
+					Expect(envelope.Meta.Owner).To(Equal("new-owner"))
+					Expect(envelope.Meta.Version).To(Equal(int64(4)))
+				})
+			})
+		})
+
+		When("a single range is requested", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"Range": "bytes=0-3"}
+			})
+
+			It("should return 206 with the range", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(206))
+				Expect(handlerResponse.IsBase64Encoded).To(BeTrue())
+				Expect(decodedBodyOf(handlerResponse)).To(Equal([]byte("info")))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Range", "bytes 0-3/10"))
+			})
+
+			When("the range splits a character", func() {
+				BeforeEach(func() {
+					fakeInfoGetter.GetInfoReturns(service.Info{
+						ID:          infoId,
+						Value:       "ünïcode",
+						ContentType: service.DefaultContentType,
+						ContentHash: "hash",
+					}, nil)
+					headers = map[string]string{"Range": "bytes=0-2"}
+				})
+
+				It("should return the bytes of the range", func() {
+					Expect(handlerResponse.StatusCode).To(Equal(206))
+					Expect(decodedBodyOf(handlerResponse)).To(Equal([]byte("ünïcode")[:3]))
+					Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Range", "bytes 0-2/9"))
+				})
+			})
+		})
+
+		When("multiple ranges are requested", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"Range": "bytes=0-3,-5"}
+			})
+
+			It("should return 206 with multipart/byteranges", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(206))
+
+				mediaType, params, err := mime.ParseMediaType(handlerResponse.Headers["Content-Type"])
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(mediaType).To(Equal("multipart/byteranges"))
+
+				Expect(handlerResponse.IsBase64Encoded).To(BeTrue())
+				reader := multipart.NewReader(bytes.NewReader(decodedBodyOf(handlerResponse)), params["boundary"])
+				for _, expected := range []struct{ contentRange, body string }{
+					{"bytes 0-3/10", "info"},
+					{"bytes 5-9/10", "value"},
+				} {
+					part, err := reader.NextPart()
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(part.Header.Get("Content-Range")).To(Equal(expected.contentRange))
+					body, _ := ioutil.ReadAll(part)
+					Expect(string(body)).To(Equal(expected.body))
+				}
+
+				_, err = reader.NextPart()
+				Expect(err).To(Equal(io.EOF))
+			})
+		})
+
+		When("the range is not satisfiable", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"Range": "bytes=10-"}
+			})
+
+			It("should return 416 with the size", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(416))
+				Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Range", "bytes */10"))
+			})
+		})
+
+		When("If-Range does not match the ETag", func() {
+			BeforeEach(func() {
+				headers = map[string]string{
+					"Range":    "bytes=0-3",
+					"If-Range": `"other"`,
+				}
+			})
+
+			It("should return 200 with the whole value", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+				Expect(handlerResponse.Body).To(Equal(infoValue))
+			})
+		})
+
+		When("If-Range matches Last-Modified", func() {
+			BeforeEach(func() {
+				headers = map[string]string{
+					"Range":    "bytes=0-3",
+					"If-Range": lastModified,
+				}
+			})
+
+			It("should return 206", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(206))
+			})
+		})
+
+		When("If-Modified-Since is before Last-Modified", func() {
+			BeforeEach(func() {
+				headers = map[string]string{"If-Modified-Since": "Mon, 01 Mar 2021 09:59:59 GMT"}
+			})
+
+			It("should return 200", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+			})
+		})
+	})
+
+	When("the metadata is requested", func() {
+		meta := service.InfoMeta{
+			ID:          infoId,
+			Size:        10,
+			ContentType: service.DefaultContentType,
+			CreatedAt:   time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC),
+			UpdatedAt:   time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+			Version:     2,
+			ContentHash: "hash",
+			Tags:        map[string]string{"env": "prod", "a b": "c"},
+		}
+
+		BeforeEach(func() {
+			fakeInfoMetaGetter.GetInfoMetaReturns(meta, nil)
+		})
+
+		When("the method is HEAD", func() {
+			BeforeEach(func() {
+				httpMethod = "HEAD"
+			})
+
+			It("should call GetInfoMeta() instead of GetInfo()", func() {
+				Expect(fakeInfoMetaGetter.GetInfoMetaCallCount()).To(Equal(1))
+				_, ns, id := fakeInfoMetaGetter.GetInfoMetaArgsForCall(0)
+				Expect(ns.Name).To(Equal(namespace))
+				Expect(id).To(Equal(infoId))
+				Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(0))
+			})
+
+			It("should return 200 with the metadata in headers and without body", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+				Expect(handlerResponse.Body).To(BeEmpty())
+				Expect(handlerResponse.Headers).To(Equal(map[string]string{
+					"ETag":              `"hash-2"`,
+					"Last-Modified":     "Mon, 01 Mar 2021 10:00:00 GMT",
+					"Content-Length":    "10",
+					"Accept-Ranges":     "bytes",
+					"Content-Type":      service.DefaultContentType,
+					"X-Info-Version":    "2",
+					"X-Info-Created-At": "Mon, 01 Mar 2021 09:00:00 GMT",
+					"X-Info-Tags":       "a+b=c, env=prod",
+					"X-Request-Id":      requestId,
+				}))
+			})
+
+			When("If-None-Match matches the ETag", func() {
+				BeforeEach(func() {
+					headers = map[string]string{"If-None-Match": `"hash-2"`}
+				})
+
+				It("should return 304", func() {
+					Expect(handlerResponse.StatusCode).To(Equal(304))
+				})
+			})
+
+			When("GetInfoMeta() returns InvalidIDError", func() {
+				BeforeEach(func() {
+					fakeInfoMetaGetter.GetInfoMetaReturns(service.InfoMeta{}, service.InvalidIDError{InfoID: infoId})
+				})
+
+				It("should return 400 without body", func() {
+					Expect(handlerResponse.StatusCode).To(Equal(400))
+					Expect(handlerResponse.Body).To(BeEmpty())
+				})
+			})
+
+			When("GetInfoMeta() returns InfoNotFoundError", func() {
+				BeforeEach(func() {
+					fakeInfoMetaGetter.GetInfoMetaReturns(service.InfoMeta{}, service.InfoNotFoundError{})
+				})
+
+				It("should return 404", func() {
+					Expect(handlerResponse.StatusCode).To(Equal(404))
+				})
+			})
+		})
+
+		When("the path ends with the meta segment", func() {
+			BeforeEach(func() {
+				id = infoId + "/" + service.MetaSegment
+			})
+
+			It("should call GetInfoMeta() with the id of the info", func() {
+				Expect(fakeInfoMetaGetter.GetInfoMetaCallCount()).To(Equal(1))
+				_, _, id := fakeInfoMetaGetter.GetInfoMetaArgsForCall(0)
+				Expect(id).To(Equal(infoId))
+				Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(0))
+			})
+
+			It("should return 200 with the metadata", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+
+				responseBody := make(map[string]interface{})
+				json.Unmarshal([]byte(handlerResponse.Body), &responseBody)
+				Expect(responseBody).To(Equal(map[string]interface{}{
+					"id":          infoId,
+					"size":        float64(10),
+					"contentType": service.DefaultContentType,
+					"createdAt":   "2021-03-01T09:00:00Z",
+					"updatedAt":   "2021-03-01T10:00:00Z",
+					"expiresAt":   nil,
+					"version":     float64(2),
+					"etag":        `"hash-2"`,
+					"tags":        map[string]interface{}{"env": "prod", "a b": "c"},
+				}))
+			})
+
+			When("GetInfoMeta() returns an error", func() {
+				BeforeEach(func() {
+					fakeInfoMetaGetter.GetInfoMetaReturns(service.InfoMeta{}, errors.New("error"))
+				})
+
+				It("should return 500", func() {
+					Expect(handlerResponse.StatusCode).To(Equal(500))
+					Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+				})
+			})
+		})
+	})
+
+	When("list is in query string", func() {
+		BeforeEach(func() {
+			queryStringParameters = map[string]string{"list": ""}
+		})
+
+		It("should call ListInfos() with the id as prefix", func() {
+			Expect(fakeInfoLister.ListInfosCallCount()).To(Equal(1))
+			_, _, id := fakeInfoLister.ListInfosArgsForCall(0)
+			Expect(id).To(Equal(infoId))
+			Expect(fakeInfoGetter.GetInfoCallCount()).To(Equal(0))
+		})
+
+		When("ListInfos() returns InvalidIDError", func() {
+			var invalidIDError service.InvalidIDError
+
+			BeforeEach(func() {
+				invalidIDError = service.InvalidIDError{InfoID: infoId, Reason: "reason"}
+				fakeInfoLister.ListInfosReturns(service.InfoList{}, invalidIDError)
+			})
+
+			It("should return 400 with error message", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(problemtest.Of(handlerResponse).Detail).To(Equal(invalidIDError.Error()))
+			})
+		})
+
+		When("ListInfos() returns an error", func() {
+			BeforeEach(func() {
+				fakeInfoLister.ListInfosReturns(service.InfoList{}, errors.New("error"))
+			})
+
+			It("should return 500", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(500))
+				Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+			})
+		})
+
+		When("ListInfos() returns no error", func() {
+			BeforeEach(func() {
+				fakeInfoLister.ListInfosReturns(service.InfoList{
+					Prefix:  "info-id/",
+					IDs:     []string{"info-id/a"},
+					Folders: []string{"info-id/b/"},
+				}, nil)
+			})
+
+			It("should return 200 with the children", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(200))
+
+				responseBody := make(map[string]interface{})
+				json.Unmarshal([]byte(handlerResponse.Body), &responseBody)
+				Expect(responseBody["prefix"]).To(Equal("info-id/"))
+				Expect(responseBody["ids"]).To(ConsistOf("info-id/a"))
+				Expect(responseBody["folders"]).To(ConsistOf("info-id/b/"))
+			})
 		})
 	})
 })
+
+func decodedBodyOf(response events.APIGatewayProxyResponse) []byte {
+	body, err := base64.StdEncoding.DecodeString(response.Body)
+	Expect(err).ShouldNot(HaveOccurred())
+	return body
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
@@ -21,8 +25,13 @@ var (
 	ErrNon200Response = errors.New("Non 200 Response found")
 )
 
-func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	resp, err := http.Get(DefaultHTTPGetAddress)
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, DefaultHTTPGetAddress, nil)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return events.APIGatewayProxyResponse{}, err
 	}
@@ -47,5 +56,5 @@ func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 }
 
 func main() {
-	lambda.Start(handler)
+	lambda.Start(middleware.Chain(handler, middleware.Common(config.MustLoad())...))
 }
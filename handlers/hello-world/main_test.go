@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +14,7 @@ func TestHandler(t *testing.T) {
 	t.Run("Unable to get IP", func(t *testing.T) {
 		DefaultHTTPGetAddress = "http://127.0.0.1:12345"
 
-		_, err := handler(events.APIGatewayProxyRequest{})
+		_, err := handler(context.Background(), events.APIGatewayProxyRequest{})
 		if err == nil {
 			t.Fatal("Error failed to trigger with an invalid request")
 		}
@@ -27,7 +28,7 @@ func TestHandler(t *testing.T) {
 
 		DefaultHTTPGetAddress = ts.URL
 
-		_, err := handler(events.APIGatewayProxyRequest{})
+		_, err := handler(context.Background(), events.APIGatewayProxyRequest{})
 		if err != nil && err.Error() != ErrNon200Response.Error() {
 			t.Fatalf("Error failed to trigger with an invalid HTTP response: %v", err)
 		}
@@ -41,7 +42,7 @@ func TestHandler(t *testing.T) {
 
 		DefaultHTTPGetAddress = ts.URL
 
-		_, err := handler(events.APIGatewayProxyRequest{})
+		_, err := handler(context.Background(), events.APIGatewayProxyRequest{})
 		if err == nil {
 			t.Fatal("Error failed to trigger with an invalid HTTP response")
 		}
@@ -56,7 +57,7 @@ func TestHandler(t *testing.T) {
 
 		DefaultHTTPGetAddress = ts.URL
 
-		_, err := handler(events.APIGatewayProxyRequest{})
+		_, err := handler(context.Background(), events.APIGatewayProxyRequest{})
 		if err != nil {
 			t.Fatal("Everything should be ok")
 		}
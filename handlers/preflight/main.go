@@ -0,0 +1,25 @@
+package main
+
+import (
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/routes"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// handler answers preflight requests. main builds it once per container, so
+// that warm invocations reuse the middlewares.
+var handler middleware.Handler
+
+// newHandler returns the handler of preflight requests behind the common
+// middlewares.
+func newHandler(c config.Config) middleware.Handler {
+	return middleware.Chain(routes.Options, middleware.Common(c)...)
+}
+
+func main() {
+	handler = newHandler(config.MustLoad())
+
+	lambda.Start(handler)
+}
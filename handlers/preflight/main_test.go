@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"simple-information-store-app/internal/config"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("preflight handler", func() {
+	const origin = "https://app.example.com"
+
+	var (
+		cfg             config.Config
+		headers         map[string]string
+		handlerResponse events.APIGatewayProxyResponse
+	)
+
+	BeforeEach(func() {
+		cfg = config.Default()
+		cfg.CORS.AllowedOrigins = []string{origin}
+		cfg.CORS.AllowCredentials = true
+		headers = map[string]string{
+			"Origin":                         origin,
+			"Access-Control-Request-Method":  "PUT",
+			"Access-Control-Request-Headers": "Content-Type",
+		}
+	})
+
+	JustBeforeEach(func() {
+		handler = newHandler(cfg)
+
+		var err error
+		handlerResponse, err = handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "OPTIONS",
+			Path:       "/i/a",
+			Headers:    headers,
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should return 204 with the CORS headers of the configuration", func() {
+		Expect(handlerResponse.StatusCode).To(Equal(204))
+		Expect(handlerResponse.Headers).To(HaveKeyWithValue("Access-Control-Allow-Origin", origin))
+		Expect(handlerResponse.Headers).To(HaveKeyWithValue("Access-Control-Allow-Credentials", "true"))
+		Expect(handlerResponse.Headers).To(HaveKeyWithValue("Access-Control-Allow-Methods", ContainSubstring("PUT")))
+		Expect(handlerResponse.Headers).To(HaveKeyWithValue("Access-Control-Allow-Headers", ContainSubstring("Content-Type")))
+	})
+
+	When("the origin is not allowed", func() {
+		BeforeEach(func() {
+			headers["Origin"] = "https://other.example.com"
+		})
+
+		It("should return 204 without CORS headers", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(204))
+			Expect(handlerResponse.Headers).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+	})
+
+	When("it is not a preflight request", func() {
+		BeforeEach(func() {
+			headers = nil
+		})
+
+		It("should return 204 with the allowed methods", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(204))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Allow", ContainSubstring("DELETE")))
+		})
+	})
+})
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var usageReconciler service.UsageReconciler
+
+// handler repairs the usage counters. It is triggered on a schedule.
+func handler(ctx context.Context, event events.CloudWatchEvent) error {
+	written, err := usageReconciler.ReconcileUsage(ctx)
+	if err != nil {
+		fmt.Printf("Error when reconciling usage after %d counters: %s\n", written, err.Error())
+		return err
+	}
+
+	fmt.Printf("Reconciled %d usage counters\n", written)
+	return nil
+}
+
+func main() {
+	usageReconciler = service.NewUsageService(config.MustLoad())
+
+	lambda.Start(handler)
+}
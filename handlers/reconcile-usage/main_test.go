@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"simple-information-store-app/internal/servicefakes"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("reconcile-usage handler", func() {
+	var (
+		fakeUsageReconciler servicefakes.FakeUsageReconciler
+		handlerErr          error
+	)
+
+	BeforeEach(func() {
+		fakeUsageReconciler = servicefakes.FakeUsageReconciler{}
+		usageReconciler = &fakeUsageReconciler
+	})
+
+	JustBeforeEach(func() {
+		handlerErr = handler(context.Background(), events.CloudWatchEvent{})
+	})
+
+	It("should call ReconcileUsage()", func() {
+		Expect(fakeUsageReconciler.ReconcileUsageCallCount()).To(Equal(1))
+		Expect(handlerErr).ShouldNot(HaveOccurred())
+	})
+
+	When("ReconcileUsage() returns an error", func() {
+		BeforeEach(func() {
+			fakeUsageReconciler.ReconcileUsageReturns(1, errors.New("error"))
+		})
+
+		It("should return the error so that the invocation is retried", func() {
+			Expect(handlerErr).Should(HaveOccurred())
+		})
+	})
+})
@@ -1,45 +1,27 @@
 package main
 
 import (
-	"fmt"
-
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
 	"simple-information-store-app/internal/service"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-var infoUpdater service.InfoUpdater = service.NewInfoService()
-
-func handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	id := request.PathParameters["id"]
-	value := request.Body
+// handler serves the route. main builds it once per container, so that warm
+// invocations reuse the middlewares and the services.
+var handler middleware.Handler
 
-	_, err := infoUpdater.UpdateInfo(id, value)
-	switch err := err.(type) {
-	case nil:
-		break
-	case service.ValueTooLongError:
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       err.Error(),
-		}, nil
-	case service.InfoNotFoundError:
-		return events.APIGatewayProxyResponse{
-			StatusCode: 404,
-		}, nil
-	default:
-		fmt.Printf("Error when updating item: %s\n", err.Error())
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-		}, nil
-	}
-
-	return events.APIGatewayProxyResponse{
-		StatusCode: 200,
-	}, nil
+// newHandler returns the handler of the route behind the middlewares of the API.
+func newHandler(c config.Config, rateLimiter ratelimit.Limiter, namespaceGetter service.NamespaceGetter, infoUpdater service.InfoUpdater) middleware.Handler {
+	return middleware.API(c, rateLimiter, namespaceGetter, routes.UpdateValue(infoUpdater))
 }
 
 func main() {
+	cfg := config.MustLoad()
+	handler = newHandler(cfg, ratelimit.NewDynamoDbLimiter(cfg), service.NewNamespaceGetter(cfg), service.NewInfoService(cfg))
+
 	lambda.Start(handler)
 }
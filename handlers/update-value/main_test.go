@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/ratelimit"
 	"simple-information-store-app/internal/service"
 	"simple-information-store-app/internal/servicefakes"
 
@@ -12,31 +18,192 @@ import (
 
 var _ = Describe("get-value handler", func() {
 	const (
+		namespace = "team-a"
 		infoId    = "info-id"
 		infoValue = "info value"
+		requestId = "request-id"
 	)
 
 	var (
-		fakeInfoUpdater servicefakes.FakeInfoUpdater
-		handlerResponse events.APIGatewayProxyResponse
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		fakeInfoUpdater     servicefakes.FakeInfoUpdater
+		headers             map[string]string
+		identity            events.APIGatewayRequestIdentity
+		body                string
+		handlerResponse     events.APIGatewayProxyResponse
 	)
 
 	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: namespace}, nil)
 		fakeInfoUpdater = servicefakes.FakeInfoUpdater{}
-		infoUpdater = &fakeInfoUpdater
+		headers = nil
+		identity = events.APIGatewayRequestIdentity{}
+		body = infoValue
 	})
 
 	JustBeforeEach(func() {
+		handler = newHandler(config.Default(), ratelimit.NewMemoryLimiter(), &fakeNamespaceGetter, &fakeInfoUpdater)
+
 		var err error
-		handlerResponse, err = handler(events.APIGatewayProxyRequest{
+		handlerResponse, err = handler(context.Background(), events.APIGatewayProxyRequest{
 			PathParameters: map[string]string{
-				"id": infoId,
+				"namespace": namespace,
+				"id":        infoId,
+			},
+			Headers: headers,
+			Body:    body,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: requestId,
+				Identity:  identity,
 			},
 		})
 
 		Expect(err).ShouldNot(HaveOccurred())
 	})
 
+	It("should get the namespace from the path", func() {
+		Expect(fakeNamespaceGetter.GetNamespaceCallCount()).To(Equal(1))
+		Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal(namespace))
+	})
+
+	It("should call UpdateInfo() in the namespace", func() {
+		Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(1))
+
+		_, ns, _, _, _, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+		Expect(ns.Name).To(Equal(namespace))
+	})
+
+	It("should call UpdateInfo() with the body as value", func() {
+		_, _, _, id, value, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+		Expect(id).To(Equal(infoId))
+		Expect(value).To(Equal(infoValue))
+	})
+
+	When("the request is authenticated with an API key", func() {
+		BeforeEach(func() {
+			identity = events.APIGatewayRequestIdentity{APIKey: "key", APIKeyID: "key-a"}
+		})
+
+		It("should call UpdateInfo() with the API key id as principal", func() {
+			_, _, principal, _, _, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			Expect(principal).To(Equal("key-a"))
+		})
+	})
+
+	When("a content type and tags are given", func() {
+		BeforeEach(func() {
+			headers = map[string]string{
+				"Content-Type": "application/xml",
+				"X-Info-Tags":  "env=prod",
+			}
+		})
+
+		It("should call UpdateInfo() with them", func() {
+			_, _, _, _, _, attrs := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			Expect(attrs).To(Equal(service.InfoAttributes{
+				ContentType: "application/xml",
+				Tags:        map[string]string{"env": "prod"},
+			}))
+		})
+	})
+
+	It("should keep the tags if none are given", func() {
+		_, _, _, _, _, attrs := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+		Expect(attrs.Tags).To(BeNil())
+	})
+
+	When("the body is a JSON envelope", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"Content-Type": "application/vnd.info+json; charset=utf-8"}
+			body = `{"id": "info-id", "value": "new value"}`
+		})
+
+		It("should call UpdateInfo() with the value of the envelope", func() {
+			_, _, _, _, value, _ := fakeInfoUpdater.UpdateInfoArgsForCall(0)
+			Expect(value).To(Equal("new value"))
+		})
+
+		When("the id does not match the path", func() {
+			BeforeEach(func() {
+				body = `{"id": "other", "value": "new value"}`
+			})
+
+			It("should return 400", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the value is missing", func() {
+			BeforeEach(func() {
+				body = `{"id": "info-id"}`
+			})
+
+			It("should return 400 with error message", func() {
+				Expect(handlerResponse.StatusCode).To(Equal(400))
+				Expect(handlerResponse.Body).To(ContainSubstring("value is missing"))
+				Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	When("GetNamespace() returns NamespaceNotFoundError", func() {
+		var namespaceNotFoundError service.NamespaceNotFoundError
+
+		BeforeEach(func() {
+			namespaceNotFoundError = service.NamespaceNotFoundError{Namespace: namespace}
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, namespaceNotFoundError)
+		})
+
+		It("should return 404 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(404))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(namespaceNotFoundError.Error()))
+			Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the namespace does not allow the authentication method", func() {
+		BeforeEach(func() {
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{
+				Name:        namespace,
+				AuthMethods: []string{auth.MethodIAM},
+			}, nil)
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the namespace does not allow the principal", func() {
+		BeforeEach(func() {
+			identity = events.APIGatewayRequestIdentity{APIKey: "key", APIKeyID: "key-b"}
+			fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{
+				Name:       namespace,
+				Principals: []string{"key-a"},
+			}, nil)
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypePrincipalNotAllowed))
+			Expect(fakeInfoUpdater.UpdateInfoCallCount()).To(Equal(0))
+		})
+	})
+
+	When("UpdateInfo() returns NotOwnerError", func() {
+		BeforeEach(func() {
+			fakeInfoUpdater.UpdateInfoReturns(service.Info{}, service.NotOwnerError{InfoID: infoId})
+		})
+
+		It("should return 403", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeNotOwner))
+		})
+	})
+
 	When("UpdateInfo() returns ValueTooLongError", func() {
 		var valueTooLongError service.ValueTooLongError
 
@@ -50,8 +217,22 @@ var _ = Describe("get-value handler", func() {
 
 			errMsg := valueTooLongError.Error()
 			Expect(errMsg).NotTo(BeEmpty())
-			Expect(handlerResponse.Body).To(Equal(errMsg))
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(errMsg))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
+		})
+	})
+
+	When("UpdateInfo() returns QuotaExceededError", func() {
+		var quotaExceededError service.QuotaExceededError
+
+		BeforeEach(func() {
+			quotaExceededError = service.QuotaExceededError{Namespace: namespace, Quota: service.QuotaBytes, Limit: 10}
+			fakeInfoUpdater.UpdateInfoReturns(service.Info{}, quotaExceededError)
+		})
+
+		It("should return 403 with error message", func() {
+			Expect(handlerResponse.StatusCode).To(Equal(403))
+			Expect(problemtest.Of(handlerResponse).Detail).To(Equal(quotaExceededError.Error()))
 		})
 	})
 
@@ -62,8 +243,8 @@ var _ = Describe("get-value handler", func() {
 
 		It("should return 404", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(404))
-			Expect(handlerResponse.Body).To(BeEmpty())
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInfoNotFound))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
 		})
 	})
 
@@ -74,8 +255,8 @@ var _ = Describe("get-value handler", func() {
 
 		It("should return 500", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(500))
-			Expect(handlerResponse.Body).To(BeEmpty())
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(problemtest.Of(handlerResponse).Type).To(Equal(problem.TypeInternalError))
+			Expect(handlerResponse.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
 		})
 	})
 
@@ -87,7 +268,7 @@ var _ = Describe("get-value handler", func() {
 		It("should return 200", func() {
 			Expect(handlerResponse.StatusCode).To(Equal(200))
 			Expect(handlerResponse.Body).To(BeEmpty())
-			Expect(handlerResponse.Headers).To(BeEmpty())
+			Expect(handlerResponse.Headers).To(Equal(map[string]string{"X-Request-Id": requestId}))
 		})
 	})
 })
@@ -0,0 +1,37 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CORS", func() {
+	const origin = "http://localhost:8080"
+
+	It("should answer preflight requests of /i/{id}", func() {
+		req, err := http.NewRequest(http.MethodOptions, fmt.Sprintf("%s/i/%s", apiHost, generateNonExistingId()), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+
+		resp, err := (&http.Client{}).Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(204))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).NotTo(BeEmpty())
+		Expect(resp.Header.Get("Access-Control-Allow-Methods")).To(ContainSubstring(http.MethodPut))
+	})
+
+	It("should add CORS headers to responses", func() {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/i/%s", apiHost, generateNonExistingId()), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Origin", origin)
+
+		resp, err := (&http.Client{}).Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(404))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).NotTo(BeEmpty())
+	})
+})
@@ -1,8 +1,10 @@
 package integration_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"simple-information-store-app/client"
 	"simple-information-store-app/internal/service"
 	"strings"
 
@@ -13,78 +15,59 @@ import (
 
 var _ = Describe("POST /i", func() {
 	var (
-		reqBody  string
-		resp     *http.Response
-		respBody string
+		value string
+		id    string
+		err   error
 	)
 
 	BeforeEach(func() {
-		reqBody = "A piece of information created by Integration test suite"
+		value = "A piece of information created by Integration test suite"
 	})
 
 	JustBeforeEach(func() {
-		var err error
-
-		endpointUrl := fmt.Sprintf("%s/i", samHost)
-		resp, err = http.Post(endpointUrl, "", strings.NewReader(reqBody))
-		Expect(err).ShouldNot(HaveOccurred())
-
-		respBody = readReadCloserOrDie(resp.Body)
-		if id, ok := getStringFromJsonString(respBody, "id"); ok && resp.StatusCode == 201 {
+		id, err = apiClient.Create(ctx, "", value)
+		if err == nil {
 			fmt.Printf("Created item with id %s\n", id)
 		}
 	})
 
 	AfterEach(func() {
-		if id, ok := getStringFromJsonString(respBody, "id"); ok && resp.StatusCode == 201 {
-			service.NewInfoService().DeleteInfo(id)
+		if err == nil {
+			service.NewInfoService(cfg).DeleteInfo(context.Background(), service.Namespace{}, service.AdminPrincipal, id)
 		}
 	})
 
-	It("should return 201 with an id", func() {
-		Expect(resp.StatusCode).To(Equal(201))
-
-		bodyJsonMap, err := convertJsonStringToMap(respBody)
+	It("should return an id", func() {
 		Expect(err).ShouldNot(HaveOccurred())
-		Expect(bodyJsonMap).To(HaveKey("id"))
-		Expect(bodyJsonMap["id"]).ShouldNot(BeEmpty())
+		Expect(id).ShouldNot(BeEmpty())
 	})
 
 	When("request body is empty", func() {
 		BeforeEach(func() {
-			reqBody = ""
+			value = ""
 		})
 
-		It("should return 201", func() {
-			Expect(resp.StatusCode).To(Equal(201))
+		It("should create the info", func() {
+			Expect(err).ShouldNot(HaveOccurred())
 		})
 	})
 
 	When("request body has more than 1000 characters", func() {
 		BeforeEach(func() {
-			reqBody = strings.Repeat("x", 1001)
+			value = strings.Repeat("x", 1001)
 		})
 
-		It("should return 400", func() {
-			Expect(resp.StatusCode).To(Equal(400))
-		})
-
-		It("should contain an error message", func() {
-			Expect(respBody).To(SatisfyAll(
-				ContainSubstring("length"),
-				ContainSubstring("1001"),
-				ContainSubstring("max"),
-				ContainSubstring("1000"),
-			))
+		It("should return ValueTooLongError", func() {
+			Expect(err).To(Equal(client.ValueTooLongError{AllowedLen: 1000, ActualLen: 1001}))
 		})
 	})
 })
 
 var _ = Describe("GET /i/{id}", func() {
 	var (
-		id       string
-		resp     *http.Response
-		respBody string
+		id   string
+		info client.Info
+		err  error
 	)
 
 	BeforeEach(func() {
@@ -92,13 +75,8 @@ var _ = Describe("GET /i/{id}", func() {
 	})
 
 	JustBeforeEach(func() {
-		var err error
-
 		Expect(id).ShouldNot(BeEmpty())
-		endpointUrl := fmt.Sprintf("%s/i/%s", samHost, id)
-		resp, err = http.Get(endpointUrl)
-		Expect(err).ShouldNot(HaveOccurred())
-		respBody = readReadCloserOrDie(resp.Body)
+		info, err = apiClient.Get(ctx, id)
 	})
 
 	When("id does not exist", func() {
@@ -106,68 +84,85 @@ var _ = Describe("GET /i/{id}", func() {
 			id = generateNonExistingId()
 		})
 
-		It("should return 404", func() {
-			Expect(resp.StatusCode).To(Equal(404))
-			Expect(respBody).To(BeEmpty())
+		It("should return InfoNotFoundError", func() {
+			Expect(err).To(Equal(client.InfoNotFoundError{InfoID: id}))
 		})
 	})
 
 	When("id exists", func() {
 		const value = "Test value used by Integration test suite"
 
-		BeforeEach(func() { // Create a new item
-			endpointUrl := fmt.Sprintf("%s/i", samHost)
-			resp, err := http.Post(endpointUrl, "", strings.NewReader(value))
-			Expect(err).ShouldNot(HaveOccurred())
-			Expect(resp.StatusCode).To(Equal(201))
-			respBody := readReadCloserOrDie(resp.Body)
-			newId, ok := getStringFromJsonString(respBody, "id")
-			Expect(ok).To(BeTrue())
-			fmt.Printf("Created item with id %s\n", newId)
-
-			id = newId
+		BeforeEach(func() {
+			id = createInfo(value)
 		})
 
 		AfterEach(func() { // Delete the new item created for the test
-			err := service.NewInfoService().DeleteInfo(id)
+			err := service.NewInfoService(cfg).DeleteInfo(context.Background(), service.Namespace{}, service.AdminPrincipal, id)
 			if err != nil {
 				panic(err)
 			}
+		})
 
+		It("should return the info", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.ID).To(Equal(id))
+			Expect(info.Value).To(Equal(value))
+			Expect(info.Version).To(Equal(int64(1)))
+			Expect(info.ETag).NotTo(BeEmpty())
 		})
 
-		It("should return 200 with the value", func() {
+		It("should return 200 with the value as text", func() {
+			resp, err := http.Get(fmt.Sprintf("%s/i/%s", apiHost, id))
+			Expect(err).ShouldNot(HaveOccurred())
 			Expect(resp.StatusCode).To(Equal(200))
-			Expect(respBody).To(Equal(value))
+			Expect(readReadCloserOrDie(resp.Body)).To(Equal(value))
+		})
+
+		It("should return 206 with the requested range", func() {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/i/%s", apiHost, id), nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Range", "bytes=0-3")
+
+			rangeResp, err := (&http.Client{}).Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(rangeResp.StatusCode).To(Equal(206))
+			Expect(rangeResp.Header.Get("Content-Range")).To(Equal(fmt.Sprintf("bytes 0-3/%d", len(value))))
+			Expect(readReadCloserOrDie(rangeResp.Body)).To(Equal(value[:4]))
+		})
+
+		It("should return 304 if the ETag matches", func() {
+			resp, err := http.Get(fmt.Sprintf("%s/i/%s", apiHost, id))
+			Expect(err).ShouldNot(HaveOccurred())
+			etag := resp.Header.Get("ETag")
+			Expect(etag).NotTo(BeEmpty())
+			Expect(resp.Header.Get("Last-Modified")).NotTo(BeEmpty())
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/i/%s", apiHost, id), nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("If-None-Match", etag)
+
+			conditionalResp, err := (&http.Client{}).Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conditionalResp.StatusCode).To(Equal(304))
 		})
 	})
 })
 
 var _ = Describe("PUT /i/{id}", func() {
 	var (
-		id       string
-		reqBody  string
-		resp     *http.Response
-		respBody string
+		id    string
+		value string
+		err   error
 	)
 
 	BeforeEach(func() {
 		id = ""
-		reqBody = "An updated version of information updated by Integration test suite"
+		value = "An updated version of information updated by Integration test suite"
 	})
 
 	JustBeforeEach(func() {
-		var err error
-
 		Expect(id).ShouldNot(BeEmpty())
-		endpointUrl := fmt.Sprintf("%s/i/%s", samHost, id)
-		req, err := http.NewRequest(http.MethodPut, endpointUrl, strings.NewReader(reqBody))
-		Expect(err).ShouldNot(HaveOccurred())
-
-		httpClient := &http.Client{}
-		resp, err = httpClient.Do(req)
-		Expect(err).ShouldNot(HaveOccurred())
-		respBody = readReadCloserOrDie(resp.Body)
+		err = apiClient.Update(ctx, id, value)
 	})
 
 	When("id does not exist", func() {
@@ -175,93 +170,82 @@ var _ = Describe("PUT /i/{id}", func() {
 			id = generateNonExistingId()
 		})
 
-		It("should return 404", func() {
-			Expect(resp.StatusCode).To(Equal(404))
-			Expect(respBody).To(BeEmpty())
+		It("should return InfoNotFoundError", func() {
+			Expect(err).To(Equal(client.InfoNotFoundError{InfoID: id}))
 		})
 
 		When("request body has more than 1000 characters", func() {
 			BeforeEach(func() {
-				reqBody = strings.Repeat("x", 1001)
+				value = strings.Repeat("x", 1001)
 			})
 
-			It("should return 400", func() {
-				Expect(resp.StatusCode).To(Equal(400))
-			})
-
-			It("should contain an error message", func() {
-				Expect(respBody).To(SatisfyAll(
-					ContainSubstring("length"),
-					ContainSubstring("1001"),
-					ContainSubstring("max"),
-					ContainSubstring("1000"),
-				))
+			It("should return ValueTooLongError", func() {
+				Expect(err).To(Equal(client.ValueTooLongError{AllowedLen: 1000, ActualLen: 1001}))
 			})
 		})
 	})
 
 	When("id exists", func() {
-		const value = "First version created by Integration test suite"
-
-		BeforeEach(func() { // Create a new item
-			endpointUrl := fmt.Sprintf("%s/i", samHost)
-			resp, err := http.Post(endpointUrl, "", strings.NewReader(value))
-			Expect(err).ShouldNot(HaveOccurred())
-			Expect(resp.StatusCode).To(Equal(201))
-			respBody := readReadCloserOrDie(resp.Body)
-			newId, ok := getStringFromJsonString(respBody, "id")
-			Expect(ok).To(BeTrue())
-			fmt.Printf("Created item with id %s\n", newId)
-
-			id = newId
+		BeforeEach(func() {
+			id = createInfo("First version created by Integration test suite")
 		})
 
 		AfterEach(func() { // Delete the new item created for the test
-			err := service.NewInfoService().DeleteInfo(id)
+			err := service.NewInfoService(cfg).DeleteInfo(context.Background(), service.Namespace{}, service.AdminPrincipal, id)
 			if err != nil {
 				panic(err)
 			}
 		})
 
-		It("should return 200", func() {
-			Expect(resp.StatusCode).To(Equal(200))
-			Expect(respBody).To(BeEmpty())
+		It("should update the value", func() {
+			Expect(err).ShouldNot(HaveOccurred())
 
 			By("checking if the value is updated", func() {
-				info, err := service.NewInfoService().GetInfo(id)
+				info, err := service.NewInfoService(cfg).GetInfo(context.Background(), service.Namespace{}, id)
 				if err != nil {
 					panic(err)
 				}
 
-				Expect(info.Value).To(Equal(reqBody))
+				Expect(info.Value).To(Equal(value))
 			})
 		})
 
 		When("request body has more than 1000 characters", func() {
 			BeforeEach(func() {
-				reqBody = strings.Repeat("x", 1001)
-			})
-
-			It("should return 400", func() {
-				Expect(resp.StatusCode).To(Equal(400))
+				value = strings.Repeat("x", 1001)
 			})
 
-			It("should contain an error message", func() {
-				Expect(respBody).To(SatisfyAll(
-					ContainSubstring("length"),
-					ContainSubstring("1001"),
-					ContainSubstring("max"),
-					ContainSubstring("1000"),
-				))
+			It("should return ValueTooLongError", func() {
+				Expect(err).To(Equal(client.ValueTooLongError{AllowedLen: 1000, ActualLen: 1001}))
 			})
 		})
 	})
 })
 
+var _ = Describe("DELETE /i/{id}", func() {
+	It("should delete the info", func() {
+		id := createInfo("Deleted by Integration test suite")
+
+		err := apiClient.Delete(ctx, id)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = service.NewInfoService(cfg).GetInfo(context.Background(), service.Namespace{}, id)
+		Expect(err).To(Equal(service.InfoNotFoundError{InfoID: id}))
+	})
+})
+
+// createInfo creates an info with the value and returns its id.
+func createInfo(value string) string {
+	id, err := apiClient.Create(ctx, "", value)
+	Expect(err).ShouldNot(HaveOccurred())
+	fmt.Printf("Created item with id %s\n", id)
+	return id
+}
+
 func generateNonExistingId() string {
 	for {
 		id := uuid.NewString()
-		_, err := service.NewInfoService().GetInfo(id)
+		_, err := service.NewInfoService(cfg).GetInfo(context.Background(), service.Namespace{}, id)
 		switch err := err.(type) {
 		case service.InfoNotFoundError:
 			return id
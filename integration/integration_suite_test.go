@@ -1,12 +1,17 @@
 package integration_test
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"simple-information-store-app/internal/env"
+	"net/http/httptest"
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/httpadapter"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/routes"
+	"simple-information-store-app/internal/valuetable"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -18,62 +23,57 @@ func TestIntegration(t *testing.T) {
 	RunSpecs(t, "IntegrationSuite")
 }
 
-const (
-	samHost = "http://localhost:3000"
-)
+var (
+	ctx = context.Background()
 
-var _ = BeforeSuite(func() {
-	var err error
+	// cfg is the configuration of the API served by the suite, with a table
+	// of its own in DynamoDB local, so that runs do not share data.
+	cfg = localConfig()
 
-	By("setting environment variable")
-	os.Setenv("GINKGO_TEST", "true")
-	os.Setenv("AWS_REGION", "eu-central-1")
+	// apiHost is the URL of the API served by the suite.
+	apiHost   string
+	apiClient *client.Client
+	server    *httptest.Server
+)
 
-	By("checking local server is running")
-	_, err = http.Get(samHost)
-	if err != nil {
-		Fail("SAM local is not running.")
-	}
+func localConfig() config.Config {
+	c := config.Local("http://localhost:8000")
+	c.Region = "eu-central-1"
+	c.ValueTableName = valuetable.UniqueName("integration-test")
+	return c
+}
 
+var _ = BeforeSuite(func() {
 	By("checking local DynamoDB is running")
-	dynamoDbEndpoint := env.GetDynamoDbEndpoint()
-	Expect(dynamoDbEndpoint).To(ContainSubstring("localhost"))
-	_, err = http.Get(dynamoDbEndpoint)
+	_, err := http.Get(cfg.DynamoDbEndpoint)
 	if err != nil {
 		Fail("Local DynamoDB is not running.")
 	}
-})
 
-func readReadCloserOrDie(rc io.ReadCloser) string {
-	bytes, err := ioutil.ReadAll(rc)
-	if err != nil {
-		panic(err)
-	}
+	By("creating the value table of the run")
+	Expect(valuetable.Create(cfg)).To(Succeed())
 
-	return string(bytes)
-}
+	By("serving the API with the table")
+	services := routes.NewServices(cfg)
+	services.RateLimiter = ratelimit.NewMemoryLimiter()
+	server = httptest.NewServer(httpadapter.Handler(routes.NewHandler(cfg, services), httpadapter.Options{}))
+	apiHost = server.URL
+	apiClient = client.New(apiHost)
+})
 
-func convertJsonStringToMap(jsonString string) (map[string]interface{}, error) {
-	jsonMap := make(map[string]interface{})
-	err := json.Unmarshal([]byte(jsonString), &jsonMap)
-	if err != nil {
-		return nil, err
+var _ = AfterSuite(func() {
+	if server != nil {
+		server.Close()
 	}
 
-	return jsonMap, nil
-}
+	Expect(valuetable.Delete(cfg)).To(Succeed())
+})
 
-func getStringFromJsonString(jsonString string, field string) (value string, ok bool) {
-	jsonMap, err := convertJsonStringToMap(jsonString)
+func readReadCloserOrDie(rc io.ReadCloser) string {
+	bytes, err := ioutil.ReadAll(rc)
 	if err != nil {
-		return "", false
-	}
-
-	rawValue, ok := jsonMap[field]
-	if !ok {
-		return "", false
+		panic(err)
 	}
 
-	value, ok = rawValue.(string)
-	return
+	return string(bytes)
 }
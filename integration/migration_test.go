@@ -0,0 +1,85 @@
+package integration_test
+
+import (
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/helper/awshelper"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migration", func() {
+	var id string
+
+	// putLegacyItem stores an info like before the schema was versioned.
+	putLegacyItem := func(id string) {
+		dynamoDbClient := awshelper.GetDynamoDbClient(cfg.DynamoDbEndpoint, cfg.Region)
+		_, err := dynamoDbClient.PutItem(&dynamodb.PutItemInput{
+			TableName: helper.StringPtr(cfg.ValueTableName),
+			Item: map[string]*dynamodb.AttributeValue{
+				"Id":    {S: helper.StringPtr(id)},
+				"Value": {S: helper.StringPtr("legacy")},
+			},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	getItem := func(id string) map[string]*dynamodb.AttributeValue {
+		dynamoDbClient := awshelper.GetDynamoDbClient(cfg.DynamoDbEndpoint, cfg.Region)
+		result, err := dynamoDbClient.GetItem(&dynamodb.GetItemInput{
+			TableName: helper.StringPtr(cfg.ValueTableName),
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: helper.StringPtr(id)},
+			},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		return result.Item
+	}
+
+	migrate := func() int {
+		migrated := 0
+		err := service.NewMigrationService(cfg).MigrateInfos(ctx, service.NewExportCheckpoint(4), func(n int, _ service.ExportCheckpoint) error {
+			migrated += n
+			return nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		return migrated
+	}
+
+	BeforeEach(func() {
+		id = "migration/" + generateNonExistingId()
+		putLegacyItem(id)
+	})
+
+	AfterEach(func() {
+		Expect(apiClient.Delete(ctx, id)).To(Succeed())
+	})
+
+	It("should migrate infos on read", func() {
+		info, err := apiClient.Get(ctx, id)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Value).To(Equal("legacy"))
+		Expect(info.Version).To(Equal(int64(1)))
+
+		item := getItem(id)
+		Expect(*item["SchemaVersion"].N).To(Equal("1"))
+		Expect(*item["Root"].S).To(Equal("migration"))
+
+		list, err := apiClient.List(ctx, "migration/")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.IDs).To(ContainElement(id))
+	})
+
+	It("should migrate infos by the backfill", func() {
+		Expect(migrate()).To(BeNumerically(">=", 1))
+
+		item := getItem(id)
+		Expect(*item["SchemaVersion"].N).To(Equal("1"))
+		Expect(*item["Hash"].S).NotTo(BeEmpty())
+		Expect(*item["Size"].N).To(Equal("6"))
+
+		Expect(migrate()).To(Equal(0))
+	})
+})
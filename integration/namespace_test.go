@@ -0,0 +1,31 @@
+package integration_test
+
+import (
+	"context"
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/service"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("/n/{namespace}/i", func() {
+	When("the namespace is not configured", func() {
+		It("should return NamespaceNotFoundError", func() {
+			_, err := client.New(apiHost, client.WithNamespace("not-configured")).Create(ctx, "", "value")
+			Expect(err).To(Equal(client.NamespaceNotFoundError{Namespace: "not-configured"}))
+		})
+	})
+
+	When("the namespace is the default namespace", func() {
+		It("should access the same infos as /i", func() {
+			id, err := client.New(apiHost, client.WithNamespace(service.DefaultNamespace)).Create(ctx, "", "value")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer service.NewInfoService(cfg).DeleteInfo(context.Background(), service.Namespace{}, service.AdminPrincipal, id)
+
+			info, err := apiClient.Get(ctx, id)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Value).To(Equal("value"))
+		})
+	})
+})
@@ -0,0 +1,94 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"simple-information-store-app/client"
+	"simple-information-store-app/internal/service"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hierarchical ids", func() {
+	var folder string
+
+	BeforeEach(func() {
+		folder = fmt.Sprintf("integration-%s", uuid.NewString())
+
+		By("creating infos under the folder")
+		for _, path := range []string{"a", "b/c", "b/d/e"} {
+			_, err := apiClient.Create(ctx, folder+"/"+path, path)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		apiClient.DeleteAll(ctx, folder)
+	})
+
+	Describe("POST /i/{id}", func() {
+		It("should return InfoAlreadyExistsError if the id already exists", func() {
+			_, err := apiClient.Create(ctx, folder+"/a", "again")
+			Expect(err).To(Equal(client.InfoAlreadyExistsError{InfoID: folder + "/a"}))
+		})
+
+		It("should create the info at the path", func() {
+			info, err := service.NewInfoService(cfg).GetInfo(context.Background(), service.Namespace{}, folder+"/b/c")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(info.Value).To(Equal("b/c"))
+		})
+	})
+
+	Describe("HEAD /i/{id}", func() {
+		It("should return the metadata without the value", func() {
+			endpointUrl := fmt.Sprintf("%s/i/%s/b/c", apiHost, folder)
+			resp, err := http.Head(endpointUrl)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Header.Get("X-Info-Version")).To(Equal("1"))
+			Expect(readReadCloserOrDie(resp.Body)).To(BeEmpty())
+		})
+	})
+
+	Describe("GET /i/{id}/meta", func() {
+		It("should return the metadata", func() {
+			meta, err := apiClient.Meta(ctx, folder+"/b/c")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(meta.ID).To(Equal(folder + "/b/c"))
+			Expect(meta.Size).To(Equal(int64(len("b/c"))))
+			Expect(meta.Version).To(Equal(int64(1)))
+		})
+
+		It("should reject creating an info at the path", func() {
+			_, err := apiClient.Create(ctx, folder+"/meta", "value")
+			var invalidID client.InvalidIDError
+			Expect(errors.As(err, &invalidID)).To(BeTrue())
+		})
+	})
+
+	Describe("GET /i/{prefix}/?list", func() {
+		It("should return the direct children", func() {
+			list, err := apiClient.List(ctx, folder+"/b/")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list.IDs).To(ConsistOf(folder + "/b/c"))
+			Expect(list.Folders).To(ConsistOf(folder + "/b/d/"))
+		})
+	})
+
+	Describe("DELETE /i/{prefix}/?recursive", func() {
+		It("should delete all infos under the prefix", func() {
+			deleted, err := apiClient.DeleteAll(ctx, folder+"/b/")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).To(Equal(2))
+
+			list, err := service.NewInfoService(cfg).ListInfos(context.Background(), service.Namespace{}, folder)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list.IDs).To(ConsistOf(folder + "/a"))
+			Expect(list.Folders).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package integration_test
+
+import (
+	"bytes"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/snapshot"
+	"simple-information-store-app/internal/valuetable"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot", func() {
+	var (
+		transferService service.InfoTransferService
+		table           config.Config
+	)
+
+	BeforeEach(func() {
+		transferService = service.NewInfoTransferService(cfg)
+		table = cfg
+		table.ValueTableName = valuetable.UniqueName("snapshot-test")
+
+		_, err := apiClient.Create(ctx, generateNonExistingId(), "snapshot")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(valuetable.Delete(table)).To(Succeed())
+	})
+
+	take := func(exporter service.InfoExporter) []byte {
+		archive := &bytes.Buffer{}
+		_, err := snapshot.Take(ctx, archive, exporter, 4)
+		Expect(err).ShouldNot(HaveOccurred())
+		return archive.Bytes()
+	}
+
+	It("should be byte-identical for identical data", func() {
+		Expect(take(transferService)).To(Equal(take(transferService)))
+	})
+
+	It("should restore into a new table", func() {
+		archive := take(transferService)
+		Expect(valuetable.Create(table)).To(Succeed())
+
+		restored := service.NewInfoTransferService(table)
+		manifest, err := snapshot.Restore(ctx, bytes.NewReader(archive), restored, restored, 4)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(manifest.ItemCount).To(BeNumerically(">", 0))
+		Expect(take(restored)).To(Equal(archive))
+	})
+
+	It("should not restore into an existing table", func() {
+		Expect(valuetable.Create(table)).To(Succeed())
+		Expect(valuetable.Create(table)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Authentication methods of a request, as verified by API Gateway.
+const (
+	MethodNone    = "none"
+	MethodAPIKey  = "api_key"
+	MethodIAM     = "iam"
+	MethodCognito = "cognito"
+)
+
+// MethodOf returns the method the request is authenticated with. Only the
+// identity verified by API Gateway is considered, not raw request headers.
+func MethodOf(request events.APIGatewayProxyRequest) string {
+	identity := request.RequestContext.Identity
+	switch {
+	case identity.CognitoIdentityID != "" || request.RequestContext.Authorizer["claims"] != nil:
+		return MethodCognito
+	case identity.UserArn != "" || identity.AccessKey != "":
+		return MethodIAM
+	case identity.APIKey != "":
+		return MethodAPIKey
+	default:
+		return MethodNone
+	}
+}
+
+// OwnerOf returns the identity the request is authenticated as, e.g. the API
+// key id or the IAM user ARN, or an empty string for anonymous requests.
+func OwnerOf(request events.APIGatewayProxyRequest) string {
+	identity := request.RequestContext.Identity
+	switch MethodOf(request) {
+	case MethodCognito:
+		if claims, ok := request.RequestContext.Authorizer["claims"].(map[string]interface{}); ok {
+			if sub, ok := claims["sub"].(string); ok {
+				return sub
+			}
+		}
+		return identity.CognitoIdentityID
+	case MethodIAM:
+		if identity.UserArn != "" {
+			return identity.UserArn
+		}
+		return identity.AccessKey
+	case MethodAPIKey:
+		if identity.APIKeyID != "" {
+			return identity.APIKeyID
+		}
+		return identity.APIKey
+	default:
+		return ""
+	}
+}
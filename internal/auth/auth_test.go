@@ -0,0 +1,73 @@
+package auth_test
+
+import (
+	"simple-information-store-app/internal/auth"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MethodOf()", func() {
+	var request events.APIGatewayProxyRequest
+
+	BeforeEach(func() {
+		request = events.APIGatewayProxyRequest{}
+	})
+
+	It("should return none for an anonymous request", func() {
+		Expect(auth.MethodOf(request)).To(Equal(auth.MethodNone))
+	})
+
+	It("should ignore an unverified x-api-key header", func() {
+		request.Headers = map[string]string{"x-api-key": "key"}
+		Expect(auth.MethodOf(request)).To(Equal(auth.MethodNone))
+	})
+
+	It("should return api_key for a request with verified API key", func() {
+		request.RequestContext.Identity.APIKey = "key"
+		Expect(auth.MethodOf(request)).To(Equal(auth.MethodAPIKey))
+	})
+
+	It("should return iam for a signed request", func() {
+		request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/test"
+		Expect(auth.MethodOf(request)).To(Equal(auth.MethodIAM))
+	})
+
+	It("should return cognito for a request with authorizer claims", func() {
+		request.RequestContext.Authorizer = map[string]interface{}{
+			"claims": map[string]interface{}{"sub": "user"},
+		}
+		Expect(auth.MethodOf(request)).To(Equal(auth.MethodCognito))
+	})
+})
+
+var _ = Describe("OwnerOf()", func() {
+	var request events.APIGatewayProxyRequest
+
+	BeforeEach(func() {
+		request = events.APIGatewayProxyRequest{}
+	})
+
+	It("should return an empty string for an anonymous request", func() {
+		Expect(auth.OwnerOf(request)).To(BeEmpty())
+	})
+
+	It("should return the API key id", func() {
+		request.RequestContext.Identity.APIKey = "key"
+		request.RequestContext.Identity.APIKeyID = "key-id"
+		Expect(auth.OwnerOf(request)).To(Equal("key-id"))
+	})
+
+	It("should return the IAM user ARN", func() {
+		request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/test"
+		Expect(auth.OwnerOf(request)).To(Equal("arn:aws:iam::123456789012:user/test"))
+	})
+
+	It("should return the subject of the Cognito claims", func() {
+		request.RequestContext.Authorizer = map[string]interface{}{
+			"claims": map[string]interface{}{"sub": "user"},
+		}
+		Expect(auth.OwnerOf(request)).To(Equal("user"))
+	})
+})
@@ -0,0 +1,315 @@
+// Package config loads the configuration of the app. It is loaded once on
+// startup from an optional JSON file and environment variables, validated,
+// and passed to the services and handlers.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalValueTableName is the name of ValueTable in DynamoDB local.
+const LocalValueTableName = "simple-information-store-app-local-ValueTable"
+
+// Config is the configuration of the app.
+type Config struct {
+	// DynamoDbEndpoint is the endpoint of DynamoDB, empty for the endpoint of
+	// the region.
+	DynamoDbEndpoint string `json:"dynamoDbEndpoint"`
+
+	// Region is the AWS region, empty for the region of the AWS SDK
+	// configuration.
+	Region string `json:"region"`
+
+	ValueTableName string `json:"valueTableName"`
+
+	// Namespaces are the namespaces other than the default namespace by name,
+	// with their limits. The default namespace may be configured, too.
+	Namespaces map[string]Namespace `json:"namespaces"`
+
+	// RateLimits are the token bucket limits by route, e.g. "POST /i", or "*"
+	// for all routes without their own limit.
+	RateLimits map[string]RateLimit `json:"rateLimits"`
+
+	CORS     CORS     `json:"cors"`
+	Features Features `json:"features"`
+}
+
+// Namespace is the configuration of a namespace. Limits are unlimited if 0.
+type Namespace struct {
+	// ValueMaxLen is the max. length of values, the default if 0.
+	ValueMaxLen int `json:"valueMaxLen"`
+
+	// DefaultTTL is the time to live of new infos as a Go duration, e.g.
+	// "24h", infinite if empty.
+	DefaultTTL string `json:"defaultTtl"`
+
+	// AuthMethods are the allowed authentication methods, all if empty.
+	AuthMethods []string `json:"authMethods"`
+
+	// Principals are the identities allowed to access the namespace, e.g. API
+	// key ids, IAM user ARNs or Cognito subjects, all if empty.
+	Principals []string `json:"principals"`
+
+	ItemQuota      int   `json:"itemQuota"`
+	ByteQuota      int64 `json:"byteQuota"`
+	OwnerItemQuota int   `json:"ownerItemQuota"`
+	OwnerByteQuota int64 `json:"ownerByteQuota"`
+}
+
+// RateLimit is the configuration of a token bucket.
+type RateLimit struct {
+	// Rate is the number of tokens added to the bucket per second.
+	Rate float64 `json:"rate"`
+
+	// Burst is the capacity of the bucket.
+	Burst int `json:"burst"`
+}
+
+// CORS configures cross-origin requests. Methods and headers of the API are
+// allowed if empty.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to access the API, "*" for any.
+	AllowedOrigins   []string `json:"allowedOrigins"`
+	AllowedMethods   []string `json:"allowedMethods"`
+	AllowedHeaders   []string `json:"allowedHeaders"`
+	AllowCredentials bool     `json:"allowCredentials"`
+}
+
+// Features switches features on and off.
+type Features struct {
+	// MigrateOnRead migrates items of older schema versions when they are
+	// read.
+	MigrateOnRead bool `json:"migrateOnRead"`
+}
+
+// Default returns the configuration used for what is not configured: DynamoDB
+// of the region, any origin and all features. The value table has no default
+// and has to be configured.
+func Default() Config {
+	return Config{
+		CORS:     CORS{AllowedOrigins: []string{"*"}},
+		Features: Features{MigrateOnRead: true},
+	}
+}
+
+// Local returns the default configuration with the value table in DynamoDB
+// local on the endpoint.
+func Local(endpoint string) Config {
+	c := Default()
+	c.DynamoDbEndpoint = endpoint
+	c.ValueTableName = LocalValueTableName
+	return c
+}
+
+// InvalidConfigError indicates that the configuration is invalid.
+type InvalidConfigError struct {
+	Reason string
+}
+
+func (err InvalidConfigError) Error() string {
+	return fmt.Sprintf("The configuration is invalid: %s.", err.Reason)
+}
+
+// Load returns the validated configuration. The default configuration is
+// overridden by the JSON file of CONFIG_FILE, if set, and then by the
+// environment variables:
+//
+//	DYNAMODB_ENDPOINT       endpoint of DynamoDB, empty for the region
+//	AWS_REGION              AWS region
+//	VALUE_TABLE_NAME        name of the value table
+//	VALUE_TABLE_REF         name of the value table, set by template.yaml
+//	NAMESPACES              JSON of namespaces
+//	RATE_LIMITS             JSON of rate limits
+//	CORS_ALLOWED_ORIGINS    comma separated origins, "*" for any, empty for none
+//	CORS_ALLOWED_METHODS    comma separated methods
+//	CORS_ALLOWED_HEADERS    comma separated request headers
+//	CORS_ALLOW_CREDENTIALS  true or false
+//	MIGRATE_ON_READ         true or false
+//
+// InvalidConfigError is returned if the configuration is invalid.
+func Load() (Config, error) {
+	c, err := FromEnv()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return c, c.Validate()
+}
+
+// FromEnv returns the configuration of Load without validating it, e.g. for
+// commands which override it with their flags and validate it then.
+func FromEnv() (Config, error) {
+	c := Default()
+	if filename := os.Getenv("CONFIG_FILE"); filename != "" {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return Config{}, err
+		}
+
+		if err := json.Unmarshal(b, &c); err != nil {
+			return Config{}, InvalidConfigError{Reason: fmt.Sprintf("%s is not valid JSON: %s", filename, err)}
+		}
+	}
+
+	if err := c.loadEnv(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// MustLoad returns the configuration of Load, and exits the process if it is
+// invalid, so that misconfigured functions fail on startup.
+func MustLoad() Config {
+	c, err := Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	return c
+}
+
+// loadEnv overrides the configuration with the environment variables.
+func (c *Config) loadEnv() error {
+	if endpoint, ok := os.LookupEnv("DYNAMODB_ENDPOINT"); ok {
+		c.DynamoDbEndpoint = endpoint
+	}
+
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		c.Region = region
+	}
+
+	if name := os.Getenv("VALUE_TABLE_NAME"); name != "" {
+		c.ValueTableName = name
+	} else if name := os.Getenv("VALUE_TABLE_REF"); name != "" {
+		c.ValueTableName = name
+	}
+
+	if raw := os.Getenv("NAMESPACES"); raw != "" {
+		c.Namespaces = nil
+		if err := json.Unmarshal([]byte(raw), &c.Namespaces); err != nil {
+			return InvalidConfigError{Reason: fmt.Sprintf("NAMESPACES is not valid JSON: %s", err)}
+		}
+	}
+
+	if raw := os.Getenv("RATE_LIMITS"); raw != "" {
+		c.RateLimits = nil
+		if err := json.Unmarshal([]byte(raw), &c.RateLimits); err != nil {
+			return InvalidConfigError{Reason: fmt.Sprintf("RATE_LIMITS is not valid JSON: %s", err)}
+		}
+	}
+
+	if origins, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		c.CORS.AllowedOrigins = splitList(origins)
+	}
+
+	if methods := splitList(os.Getenv("CORS_ALLOWED_METHODS")); len(methods) > 0 {
+		c.CORS.AllowedMethods = methods
+	}
+
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+		c.CORS.AllowedHeaders = headers
+	}
+
+	for name, value := range map[string]*bool{
+		"CORS_ALLOW_CREDENTIALS": &c.CORS.AllowCredentials,
+		"MIGRATE_ON_READ":        &c.Features.MigrateOnRead,
+	} {
+		if raw := os.Getenv(name); raw != "" {
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return InvalidConfigError{Reason: fmt.Sprintf("%s is not true or false", name)}
+			}
+
+			*value = b
+		}
+	}
+
+	return nil
+}
+
+var namespaceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidNamespaceName returns if the name is a valid name of a namespace, i.e.
+// lower case letters, digits and dashes, which never contain the separator of
+// storage keys.
+func ValidNamespaceName(name string) bool {
+	return namespaceNamePattern.MatchString(name)
+}
+
+// Validate returns InvalidConfigError if the configuration is invalid.
+func (c Config) Validate() error {
+	if c.ValueTableName == "" {
+		return InvalidConfigError{Reason: "the name of the value table is missing"}
+	}
+
+	for name, ns := range c.Namespaces {
+		if !ValidNamespaceName(name) {
+			return InvalidConfigError{Reason: fmt.Sprintf("invalid namespace name %q", name)}
+		}
+
+		if _, err := ns.ParseDefaultTTL(); err != nil {
+			return InvalidConfigError{Reason: fmt.Sprintf("invalid default TTL of namespace %s", name)}
+		}
+
+		for _, principal := range ns.Principals {
+			if principal == "" {
+				return InvalidConfigError{Reason: fmt.Sprintf("empty principal of namespace %s", name)}
+			}
+		}
+
+		if ns.ValueMaxLen < 0 || ns.ItemQuota < 0 || ns.ByteQuota < 0 || ns.OwnerItemQuota < 0 || ns.OwnerByteQuota < 0 {
+			return InvalidConfigError{Reason: fmt.Sprintf("negative limit of namespace %s", name)}
+		}
+	}
+
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				return InvalidConfigError{Reason: "credentials cannot be allowed to any origin, only to explicit origins"}
+			}
+		}
+	}
+
+	for route, limit := range c.RateLimits {
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			return InvalidConfigError{Reason: fmt.Sprintf("invalid rate limit of route %s", route)}
+		}
+	}
+
+	return nil
+}
+
+// ParseDefaultTTL returns the default TTL, 0 if it is infinite.
+func (ns Namespace) ParseDefaultTTL() (time.Duration, error) {
+	if ns.DefaultTTL == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(ns.DefaultTTL)
+	if err == nil && ttl < 0 {
+		err = fmt.Errorf("negative duration %s", ns.DefaultTTL)
+	}
+
+	return ttl, err
+}
+
+// splitList returns the non-empty items of the comma separated list.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,277 @@
+package config_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"simple-information-store-app/internal/config"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+// envVars are the environment variables Load reads.
+var envVars = []string{
+	"CONFIG_FILE",
+	"DYNAMODB_ENDPOINT",
+	"AWS_REGION",
+	"VALUE_TABLE_NAME",
+	"VALUE_TABLE_REF",
+	"NAMESPACES",
+	"RATE_LIMITS",
+	"CORS_ALLOWED_ORIGINS",
+	"CORS_ALLOWED_METHODS",
+	"CORS_ALLOWED_HEADERS",
+	"CORS_ALLOW_CREDENTIALS",
+	"MIGRATE_ON_READ",
+}
+
+var _ = Describe("Load()", func() {
+	var (
+		c   config.Config
+		err error
+	)
+
+	BeforeEach(func() {
+		for _, name := range envVars {
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+
+		Expect(os.Setenv("VALUE_TABLE_REF", "test-ValueTable")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		for _, name := range envVars {
+			os.Unsetenv(name)
+		}
+	})
+
+	JustBeforeEach(func() {
+		c, err = config.Load()
+	})
+
+	When("only the value table is configured", func() {
+		It("should return the defaults", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(c.DynamoDbEndpoint).To(BeEmpty())
+			Expect(c.ValueTableName).To(Equal("test-ValueTable"))
+			Expect(c.CORS.AllowedOrigins).To(Equal([]string{"*"}))
+			Expect(c.CORS.AllowCredentials).To(BeFalse())
+			Expect(c.Features.MigrateOnRead).To(BeTrue())
+		})
+	})
+
+	When("the value table is not configured", func() {
+		BeforeEach(func() {
+			os.Unsetenv("VALUE_TABLE_REF")
+		})
+
+		It("should return InvalidConfigError", func() {
+			Expect(err).To(BeAssignableToTypeOf(config.InvalidConfigError{}))
+		})
+	})
+
+	When("environment variables are set", func() {
+		BeforeEach(func() {
+			os.Setenv("DYNAMODB_ENDPOINT", "http://localhost:8001")
+			os.Setenv("AWS_REGION", "eu-central-1")
+			os.Setenv("VALUE_TABLE_NAME", "restored-ValueTable")
+			os.Setenv("NAMESPACES", `{"team-a": {"valueMaxLen": 5000, "defaultTtl": "24h"}}`)
+			os.Setenv("RATE_LIMITS", `{"POST /i": {"rate": 1, "burst": 10}}`)
+			os.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com,")
+			os.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+			os.Setenv("MIGRATE_ON_READ", "false")
+		})
+
+		It("should return their configuration", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(c.DynamoDbEndpoint).To(Equal("http://localhost:8001"))
+			Expect(c.Region).To(Equal("eu-central-1"))
+			Expect(c.ValueTableName).To(Equal("restored-ValueTable"))
+			Expect(c.Namespaces).To(Equal(map[string]config.Namespace{
+				"team-a": {ValueMaxLen: 5000, DefaultTTL: "24h"},
+			}))
+			Expect(c.RateLimits).To(Equal(map[string]config.RateLimit{"POST /i": {Rate: 1, Burst: 10}}))
+			Expect(c.CORS.AllowedOrigins).To(Equal([]string{"https://a.example.com", "https://b.example.com"}))
+			Expect(c.CORS.AllowCredentials).To(BeTrue())
+			Expect(c.Features.MigrateOnRead).To(BeFalse())
+		})
+	})
+
+	When("CORS_ALLOWED_ORIGINS is empty", func() {
+		BeforeEach(func() {
+			os.Setenv("CORS_ALLOWED_ORIGINS", "")
+		})
+
+		It("should allow no origin", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(c.CORS.AllowedOrigins).To(BeEmpty())
+		})
+	})
+
+	When("a config file is set", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "config")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			filename := filepath.Join(dir, "config.json")
+			Expect(ioutil.WriteFile(filename, []byte(`{
+				"dynamoDbEndpoint": "http://localhost:8000",
+				"valueTableName": "file-ValueTable",
+				"rateLimits": {"*": {"rate": 2, "burst": 4}},
+				"cors": {"allowedOrigins": ["https://app.example.com"]}
+			}`), 0644)).To(Succeed())
+			os.Setenv("CONFIG_FILE", filename)
+			os.Setenv("RATE_LIMITS", `{"*": {"rate": 1, "burst": 1}}`)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("should return its configuration overridden by the environment variables", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(c.DynamoDbEndpoint).To(Equal("http://localhost:8000"))
+			Expect(c.ValueTableName).To(Equal("test-ValueTable"))
+			Expect(c.RateLimits).To(Equal(map[string]config.RateLimit{"*": {Rate: 1, Burst: 1}}))
+			Expect(c.CORS.AllowedOrigins).To(Equal([]string{"https://app.example.com"}))
+			Expect(c.Features.MigrateOnRead).To(BeTrue())
+		})
+	})
+
+	When("the config file does not exist", func() {
+		BeforeEach(func() {
+			os.Setenv("CONFIG_FILE", "does-not-exist.json")
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	When("an environment variable is invalid", func() {
+		BeforeEach(func() {
+			os.Setenv("MIGRATE_ON_READ", "sometimes")
+		})
+
+		It("should return InvalidConfigError", func() {
+			Expect(err).To(Equal(config.InvalidConfigError{Reason: "MIGRATE_ON_READ is not true or false"}))
+		})
+	})
+
+	When("NAMESPACES is not valid JSON", func() {
+		BeforeEach(func() {
+			os.Setenv("NAMESPACES", `{"team-a":`)
+		})
+
+		It("should return InvalidConfigError", func() {
+			Expect(err).To(BeAssignableToTypeOf(config.InvalidConfigError{}))
+		})
+	})
+})
+
+var _ = Describe("Validate()", func() {
+	var c config.Config
+
+	BeforeEach(func() {
+		c = config.Local("http://localhost:8000")
+	})
+
+	It("should accept the local configuration", func() {
+		Expect(c.Validate()).To(Succeed())
+	})
+
+	It("should reject invalid namespace names", func() {
+		c.Namespaces = map[string]config.Namespace{"Team A": {}}
+		Expect(c.Validate()).To(Equal(config.InvalidConfigError{Reason: `invalid namespace name "Team A"`}))
+	})
+
+	It("should reject invalid default TTLs", func() {
+		c.Namespaces = map[string]config.Namespace{"team-a": {DefaultTTL: "a day"}}
+		Expect(c.Validate()).To(Equal(config.InvalidConfigError{Reason: "invalid default TTL of namespace team-a"}))
+	})
+
+	It("should reject negative limits", func() {
+		c.Namespaces = map[string]config.Namespace{"team-a": {ItemQuota: -1}}
+		Expect(c.Validate()).To(Equal(config.InvalidConfigError{Reason: "negative limit of namespace team-a"}))
+	})
+
+	It("should reject empty principals", func() {
+		c.Namespaces = map[string]config.Namespace{"team-a": {Principals: []string{"key-id", ""}}}
+		Expect(c.Validate()).To(Equal(config.InvalidConfigError{Reason: "empty principal of namespace team-a"}))
+	})
+
+	It("should reject credentials allowed to any origin", func() {
+		c.CORS = config.CORS{AllowedOrigins: []string{"https://example.com", "*"}, AllowCredentials: true}
+		Expect(c.Validate()).To(Equal(config.InvalidConfigError{Reason: "credentials cannot be allowed to any origin, only to explicit origins"}))
+	})
+
+	It("should accept credentials allowed to explicit origins", func() {
+		c.CORS = config.CORS{AllowedOrigins: []string{"https://example.com"}, AllowCredentials: true}
+		Expect(c.Validate()).To(Succeed())
+	})
+
+	It("should reject rate limits without rate or burst", func() {
+		c.RateLimits = map[string]config.RateLimit{"POST /i": {Rate: 1}}
+		Expect(c.Validate()).To(Equal(config.InvalidConfigError{Reason: "invalid rate limit of route POST /i"}))
+	})
+})
+
+var _ = Describe("ParseDefaultTTL()", func() {
+	It("should return 0 for infinite TTLs", func() {
+		Expect(config.Namespace{}.ParseDefaultTTL()).To(Equal(time.Duration(0)))
+	})
+
+	It("should parse Go durations", func() {
+		Expect(config.Namespace{DefaultTTL: "90m"}.ParseDefaultTTL()).To(Equal(90 * time.Minute))
+	})
+
+	It("should reject negative durations", func() {
+		_, err := config.Namespace{DefaultTTL: "-1h"}.ParseDefaultTTL()
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("local-env.json", func() {
+	var parameters map[string]string
+
+	BeforeEach(func() {
+		b, err := ioutil.ReadFile("../../local-env.json")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var env struct {
+			Parameters map[string]string
+		}
+		Expect(json.Unmarshal(b, &env)).To(Succeed())
+		parameters = env.Parameters
+	})
+
+	It("should point sam local at the local value table", func() {
+		Expect(parameters).To(HaveKeyWithValue("VALUE_TABLE_REF", config.LocalValueTableName))
+	})
+
+	It("should only override variables declared in template.yaml", func() {
+		b, err := ioutil.ReadFile("../../template.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var template struct {
+			Globals struct {
+				Function struct {
+					Environment struct {
+						Variables map[string]interface{} `yaml:"Variables"`
+					} `yaml:"Environment"`
+				} `yaml:"Function"`
+			} `yaml:"Globals"`
+		}
+		Expect(yaml.Unmarshal(b, &template)).To(Succeed())
+		for name := range parameters {
+			Expect(template.Globals.Function.Environment.Variables).To(HaveKey(name))
+		}
+	})
+})
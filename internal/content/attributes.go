@@ -0,0 +1,89 @@
+package content
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+
+	"simple-information-store-app/internal/service"
+)
+
+// TagsHeader is the header of the tags of an info, formatted as comma
+// separated, URL encoded key=value pairs.
+const TagsHeader = "X-Info-Tags"
+
+// InvalidAttributesError indicates that a header of the attributes of an
+// info is invalid.
+type InvalidAttributesError struct {
+	Header string
+	Reason string
+}
+
+func (err InvalidAttributesError) Error() string {
+	return fmt.Sprintf("The %s header is invalid: %s.", err.Header, err.Reason)
+}
+
+// ParseAttributes returns the attributes of an info sent with its value in a
+// request body of the content type. The content type of an envelope is not the
+// one of the value, which is stored with the default then. Tags are nil if the
+// tags header is empty.
+// InvalidAttributesError is returned if the content type or tags are invalid.
+func ParseAttributes(contentType, tags string) (service.InfoAttributes, error) {
+	var attrs service.InfoAttributes
+	if contentType != "" && !IsEnvelope(contentType) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return service.InfoAttributes{}, InvalidAttributesError{Header: "Content-Type", Reason: err.Error()}
+		}
+
+		attrs.ContentType = mime.FormatMediaType(mediaType, params)
+	}
+
+	if strings.TrimSpace(tags) != "" {
+		attrs.Tags = map[string]string{}
+		for _, pair := range strings.Split(tags, ",") {
+			k, v, err := parseTag(strings.TrimSpace(pair))
+			if err != nil {
+				return service.InfoAttributes{}, InvalidAttributesError{Header: TagsHeader, Reason: err.Error()}
+			}
+
+			attrs.Tags[k] = v
+		}
+	}
+
+	return attrs, nil
+}
+
+// FormatTags formats the tags as the tags header, sorted by key.
+func FormatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, url.QueryEscape(k)+"="+url.QueryEscape(v))
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+func parseTag(pair string) (key, value string, err error) {
+	i := strings.Index(pair, "=")
+	if i < 0 {
+		return "", "", fmt.Errorf("tag %q is not a key=value pair", pair)
+	}
+
+	if key, err = url.QueryUnescape(pair[:i]); err != nil {
+		return "", "", err
+	}
+
+	if key == "" {
+		return "", "", fmt.Errorf("tag %q has no key", pair)
+	}
+
+	if value, err = url.QueryUnescape(pair[i+1:]); err != nil {
+		return "", "", err
+	}
+
+	return key, value, nil
+}
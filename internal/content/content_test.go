@@ -0,0 +1,148 @@
+package content_test
+
+import (
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/service"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Negotiate()", func() {
+	DescribeTable("acceptable types",
+		func(accept, expected string) {
+			mediaType, ok := content.Negotiate(accept, content.TypeText, content.TypeJSON)
+			Expect(ok).To(BeTrue())
+			Expect(mediaType).To(Equal(expected))
+		},
+		Entry("no Accept header", "", content.TypeText),
+		Entry("any type", "*/*", content.TypeText),
+		Entry("JSON", "application/json", content.TypeJSON),
+		Entry("text", "text/plain; charset=utf-8", content.TypeText),
+		Entry("JSON preferred by quality", "text/plain;q=0.5, application/json", content.TypeJSON),
+		Entry("sub type wildcard", "application/*", content.TypeJSON),
+		Entry("more specific range wins", "*/*;q=0.1, text/plain;q=0", content.TypeJSON),
+		Entry("invalid header", ";;", content.TypeText),
+	)
+
+	DescribeTable("unacceptable types",
+		func(accept string) {
+			_, ok := content.Negotiate(accept, content.TypeText, content.TypeJSON)
+			Expect(ok).To(BeFalse())
+		},
+		Entry("other type", "application/xml"),
+		Entry("zero quality", "text/plain;q=0, application/json;q=0"),
+	)
+})
+
+var _ = Describe("NewEnvelope()", func() {
+	It("should return the info with metadata and links", func() {
+		updatedAt := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+		envelope := content.NewEnvelope(service.Namespace{Name: "team-a"}, service.Info{
+			ID:          "a/b c",
+			Value:       "value",
+			UpdatedAt:   updatedAt,
+			Version:     2,
+			ContentHash: "hash",
+		})
+
+		Expect(envelope.ID).To(Equal("a/b c"))
+		Expect(envelope.Value).To(Equal("value"))
+		Expect(envelope.Meta.Size).To(Equal(5))
+		Expect(*envelope.Meta.UpdatedAt).To(Equal(updatedAt))
+		Expect(envelope.Meta.CreatedAt).To(BeNil())
+		Expect(envelope.Meta.Version).To(Equal(int64(2)))
+		Expect(envelope.Meta.ETag).To(Equal(`"hash-2"`))
+		Expect(envelope.Links).To(Equal(map[string]string{
+			"self": "/n/team-a/i/a/b%20c",
+			"meta": "/n/team-a/i/a/b%20c/meta",
+		}))
+	})
+
+	It("should link infos in the default namespace without namespace", func() {
+		envelope := content.NewEnvelope(service.Namespace{}, service.Info{ID: "a"})
+		Expect(envelope.Links["self"]).To(Equal("/i/a"))
+	})
+})
+
+var _ = Describe("ParseBody()", func() {
+	It("should return the raw body if it is not JSON", func() {
+		id, value, err := content.ParseBody("text/plain", `{"value": "v"}`)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(id).To(BeEmpty())
+		Expect(value).To(Equal(`{"value": "v"}`))
+	})
+
+	It("should return raw JSON as it is", func() {
+		id, value, err := content.ParseBody("application/json", `{"id": "a", "value": "v"}`)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(id).To(BeEmpty())
+		Expect(value).To(Equal(`{"id": "a", "value": "v"}`))
+	})
+
+	It("should return the id and value of an envelope", func() {
+		id, value, err := content.ParseBody(content.TypeEnvelope, `{"id": "a", "value": "v"}`)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(id).To(Equal("a"))
+		Expect(value).To(Equal("v"))
+	})
+
+	It("should accept an empty value", func() {
+		_, value, err := content.ParseBody(content.TypeEnvelope, `{"value": ""}`)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(value).To(BeEmpty())
+	})
+
+	DescribeTable("invalid envelopes",
+		func(body string) {
+			_, _, err := content.ParseBody(content.TypeEnvelope, body)
+			Expect(err).To(BeAssignableToTypeOf(content.InvalidEnvelopeError{}))
+		},
+		Entry("not JSON", "value"),
+		Entry("missing value", `{"id": "a"}`),
+		Entry("value not a string", `{"value": 1}`),
+	)
+})
+
+var _ = Describe("ParseAttributes()", func() {
+	It("should return the content type and tags of a raw value", func() {
+		attrs, err := content.ParseAttributes("Application/XML; charset=UTF-8", "env=prod, team=a%2Cb")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(attrs).To(Equal(service.InfoAttributes{
+			ContentType: "application/xml; charset=UTF-8",
+			Tags:        map[string]string{"env": "prod", "team": "a,b"},
+		}))
+	})
+
+	It("should return the content type of a raw JSON value", func() {
+		attrs, err := content.ParseAttributes("application/json", "")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(attrs.ContentType).To(Equal("application/json"))
+	})
+
+	It("should return no content type of an envelope", func() {
+		attrs, err := content.ParseAttributes(content.TypeEnvelope, "")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(attrs).To(Equal(service.InfoAttributes{}))
+	})
+
+	It("should be reversed by FormatTags()", func() {
+		tags := map[string]string{"env": "prod", "a b": "c=d"}
+		attrs, err := content.ParseAttributes("", content.FormatTags(tags))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(attrs.Tags).To(Equal(tags))
+	})
+
+	DescribeTable("invalid attributes",
+		func(contentType, tags string) {
+			_, err := content.ParseAttributes(contentType, tags)
+			Expect(err).To(BeAssignableToTypeOf(content.InvalidAttributesError{}))
+		},
+		Entry("invalid content type", "text/", ""),
+		Entry("tag without value", "", "env"),
+		Entry("tag without key", "", "=prod"),
+		Entry("invalid escape", "", "env=%zz"),
+	)
+})
@@ -0,0 +1,120 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"simple-information-store-app/internal/service"
+)
+
+// Envelope is the JSON representation of an info, of media type TypeEnvelope.
+type Envelope struct {
+	ID    string            `json:"id"`
+	Value string            `json:"value"`
+	Meta  EnvelopeMeta      `json:"meta"`
+	Links map[string]string `json:"links"`
+}
+
+// EnvelopeMeta is the metadata of an info in its JSON representation.
+type EnvelopeMeta struct {
+	Size      int        `json:"size"`
+	Owner     string     `json:"owner,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Version   int64      `json:"version"`
+	ETag      string     `json:"etag"`
+}
+
+// NewEnvelope returns the JSON representation of the info in the namespace.
+func NewEnvelope(ns service.Namespace, info service.Info) Envelope {
+	self := PathOf(ns, info.ID)
+	return Envelope{
+		ID:    info.ID,
+		Value: info.Value,
+		Meta: EnvelopeMeta{
+			Size:      len(info.Value),
+			Owner:     info.Owner,
+			CreatedAt: optionalTime(info.CreatedAt),
+			UpdatedAt: optionalTime(info.UpdatedAt),
+			ExpiresAt: optionalTime(info.ExpiresAt),
+			Version:   info.Version,
+			ETag:      ETag(info.ContentHash, info.Version),
+		},
+		Links: map[string]string{
+			"self": self,
+			"meta": self + service.PathSeparator + service.MetaSegment,
+		},
+	}
+}
+
+// ETag returns the strong entity tag of the raw value of an info with the
+// content hash and version. The version is incremented by each update, also
+// of the metadata only, e.g. of the content type or TTL, so that the tag of
+// an info updated with the same value changes as well.
+func ETag(contentHash string, version int64) string {
+	return `"` + contentHash + "-" + strconv.FormatInt(version, 10) + `"`
+}
+
+// PathOf returns the API path of the info in the namespace.
+func PathOf(ns service.Namespace, id string) string {
+	segments := strings.Split(id, service.PathSeparator)
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	path := "/i/" + strings.Join(segments, "/")
+	if ns.Name != "" && ns.Name != service.DefaultNamespace {
+		path = "/n/" + ns.Name + path
+	}
+
+	return path
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	t = t.UTC()
+	return &t
+}
+
+// InvalidEnvelopeError indicates that a request body is not a valid envelope.
+type InvalidEnvelopeError struct {
+	Reason string
+}
+
+func (err InvalidEnvelopeError) Error() string {
+	return fmt.Sprintf("The request body is not a valid info: %s.", err.Reason)
+}
+
+// ParseBody returns the id and value of an info sent in a request body. The
+// body is an envelope if the content type is TypeEnvelope, in which only value
+// is required and id is empty if absent. Otherwise the body is the raw value,
+// including JSON values.
+// InvalidEnvelopeError is returned if the body is not a valid envelope.
+func ParseBody(contentType, body string) (id, value string, err error) {
+	if !IsEnvelope(contentType) {
+		return "", body, nil
+	}
+
+	var input struct {
+		ID    string  `json:"id"`
+		Value *string `json:"value"`
+	}
+
+	if err := json.Unmarshal([]byte(body), &input); err != nil {
+		return "", "", InvalidEnvelopeError{Reason: err.Error()}
+	}
+
+	if input.Value == nil {
+		return "", "", InvalidEnvelopeError{Reason: "value is missing"}
+	}
+
+	return input.ID, *input.Value, nil
+}
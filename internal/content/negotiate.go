@@ -0,0 +1,111 @@
+package content
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Media types of info representations.
+const (
+	TypeText = "text/plain"
+	TypeJSON = "application/json"
+
+	// TypeEnvelope is the media type of the JSON envelope of an info, so that
+	// it is told apart from raw JSON values.
+	TypeEnvelope = "application/vnd.info+json"
+)
+
+// mediaRange is a media range of the Accept header with its quality.
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// matches returns if the media range matches the media type and how
+// specific the match is, 0 if it does not match.
+func (r mediaRange) matches(mediaType string) int {
+	switch {
+	case r.mediaType == mediaType:
+		return 3
+	case r.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(r.mediaType, "/*") &&
+		strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*")):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Negotiate returns the offered media type which is preferred by the Accept
+// header (RFC 7231). The first offer is preferred if the header is empty or
+// if the client accepts several offers equally. ok is false if no offer is
+// acceptable.
+func Negotiate(accept string, offers ...string) (mediaType string, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		// Ignore an Accept header without any valid media range.
+		return offers[0], true
+	}
+
+	bestQuality := 0.0
+	for _, offer := range offers {
+		// The quality of an offer is that of the most specific matching range.
+		quality, specificity := 0.0, 0
+		for _, r := range ranges {
+			if s := r.matches(offer); s > specificity {
+				quality, specificity = r.quality, s
+			}
+		}
+
+		if quality > bestQuality {
+			mediaType, bestQuality = offer, quality
+		}
+	}
+
+	return mediaType, bestQuality > 0
+}
+
+// parseAccept parses the media ranges of the Accept header. Invalid ranges
+// are skipped.
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	return ranges
+}
+
+// IsEnvelope returns if the Content-Type header denotes the envelope.
+func IsEnvelope(contentType string) bool {
+	return MediaTypeOf(contentType) == TypeEnvelope
+}
+
+// MediaTypeOf returns the media type of the Content-Type header without
+// parameters, empty if it is invalid.
+func MediaTypeOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	return mediaType
+}
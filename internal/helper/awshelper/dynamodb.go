@@ -1,15 +1,105 @@
 package awshelper
 
 import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
-// GetDynamoDbClient returns a DynamoDB client.
-func GetDynamoDbClient(endpoint string) *dynamodb.DynamoDB {
-	sess := session.Must(session.NewSession())
-	config := aws.NewConfig().WithEndpoint(endpoint)
-	dynamoDbClient := dynamodb.New(sess, config)
+// Connection settings of DynamoDB clients. Requests of the API run in
+// functions with a timeout of a few seconds, so they fail fast on dead
+// connections instead of waiting for the function to time out. Calls have no
+// overall timeout: calls of the API are bounded by the deadline of their
+// context, and calls of CLIs and batch jobs, e.g. scans, may take longer.
+const (
+	dialTimeout           = 2 * time.Second
+	keepAlive             = 30 * time.Second
+	tlsHandshakeTimeout   = 2 * time.Second
+	responseHeaderTimeout = 5 * time.Second
+	idleConnTimeout       = 90 * time.Second
+
+	// maxIdleConnsPerHost keeps the connections of parallel requests, e.g.
+	// of scan segments, open. The default of net/http is 2.
+	maxIdleConnsPerHost = 64
+)
+
+// maxRetries is 0, so that the SDK does not retry requests. The services
+// retry transient errors themselves, within their retry budget and the
+// deadline of the call, which retries of the SDK would multiply.
+const maxRetries = 0
+
+// clientKey identifies the clients GetDynamoDbClient reuses.
+type clientKey struct {
+	endpoint string
+	region   string
+}
+
+var (
+	sharedSessionOnce sync.Once
+	sharedSession     *session.Session
+
+	clientsMutex sync.Mutex
+	clients      = map[clientKey]*dynamodb.DynamoDB{}
+)
+
+// GetDynamoDbClient returns a DynamoDB client of the endpoint and region. The
+// endpoint and region of the AWS SDK configuration are used if they are empty.
+// Clients are created once per endpoint and region and reused, so that warm
+// Lambda containers keep their session, credentials and connections.
+func GetDynamoDbClient(endpoint, region string) *dynamodb.DynamoDB {
+	key := clientKey{endpoint: endpoint, region: region}
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	if dynamoDbClient, ok := clients[key]; ok {
+		return dynamoDbClient
+	}
+
+	sharedSessionOnce.Do(func() {
+		sharedSession = newSession()
+	})
+
+	dynamoDbClient := newDynamoDbClient(sharedSession, endpoint, region)
+	clients[key] = dynamoDbClient
 	return dynamoDbClient
 }
+
+// newSession returns a session with the tuned HTTP client, which does not
+// retry requests.
+func newSession() *session.Session {
+	config := aws.NewConfig().WithHTTPClient(newHTTPClient()).WithMaxRetries(maxRetries)
+	return session.Must(session.NewSession(config))
+}
+
+func newDynamoDbClient(sess *session.Session, endpoint, region string) *dynamodb.DynamoDB {
+	config := aws.NewConfig().WithEndpoint(endpoint)
+	if region != "" {
+		config = config.WithRegion(region)
+	}
+
+	return dynamodb.New(sess, config)
+}
+
+// newHTTPClient returns the HTTP client of DynamoDB clients, which keeps
+// connections alive between invocations.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   dialTimeout,
+				KeepAlive: keepAlive,
+			}).DialContext,
+			TLSHandshakeTimeout:   tlsHandshakeTimeout,
+			ResponseHeaderTimeout: responseHeaderTimeout,
+			IdleConnTimeout:       idleConnTimeout,
+			MaxIdleConns:          maxIdleConnsPerHost,
+			MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package awshelper
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// newDynamoDbStandIn returns a server which answers every DynamoDB request
+// with an item, like DynamoDB local would answer GetItem, and the counter of
+// the connections opened to it.
+func newDynamoDbStandIn() (*httptest.Server, *int64) {
+	var conns int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		io.WriteString(w, `{"Item":{"Id":{"S":"a"},"Value":{"S":"info value"}}}`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+
+	server.Start()
+	return server, &conns
+}
+
+// setBenchmarkCredentials sets static credentials, so that sessions do not
+// look them up elsewhere, and returns the function restoring the environment.
+func setBenchmarkCredentials() func() {
+	restore := map[string]string{}
+	for name, value := range map[string]string{
+		"AWS_ACCESS_KEY_ID":     "benchmark",
+		"AWS_SECRET_ACCESS_KEY": "benchmark",
+		"AWS_REGION":            "us-east-1",
+	} {
+		restore[name] = os.Getenv(name)
+		os.Setenv(name, value)
+	}
+
+	return func() {
+		for name, value := range restore {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+var getItemInput = &dynamodb.GetItemInput{
+	TableName: aws.String("table"),
+	Key: map[string]*dynamodb.AttributeValue{
+		"Id": {S: aws.String("a")},
+	},
+}
+
+// BenchmarkGetItem compares getting an item with a session and client per
+// request, like before clients were reused, with the warm path of a Lambda
+// container which reuses its client.
+func BenchmarkGetItem(b *testing.B) {
+	defer setBenchmarkCredentials()()
+	server, conns := newDynamoDbStandIn()
+	defer server.Close()
+
+	benchmarks := []struct {
+		name   string
+		client func() *dynamodb.DynamoDB
+	}{
+		{"client per call", func() *dynamodb.DynamoDB {
+			sess := session.Must(session.NewSession())
+			return dynamodb.New(sess, aws.NewConfig().WithEndpoint(server.URL))
+		}},
+		{"reused client", func() *dynamodb.DynamoDB {
+			return GetDynamoDbClient(server.URL, "")
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			server.CloseClientConnections()
+			atomic.StoreInt64(conns, 0)
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.client().GetItem(getItemInput); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(atomic.LoadInt64(conns))/float64(b.N), "conns/op")
+		})
+	}
+}
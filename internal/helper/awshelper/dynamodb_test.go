@@ -1,6 +1,7 @@
 package awshelper_test
 
 import (
+	"net/http"
 	"simple-information-store-app/internal/helper/awshelper"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -10,13 +11,38 @@ import (
 
 var _ = Describe("GetDynamoDbClient()", func() {
 	const testEndpoint = "http://test-endpoint.com/dynamodb"
+	const testRegion = "eu-central-1"
 	var dynamoDbClient *dynamodb.DynamoDB
 
 	BeforeEach(func() {
-		dynamoDbClient = awshelper.GetDynamoDbClient(testEndpoint)
+		dynamoDbClient = awshelper.GetDynamoDbClient(testEndpoint, testRegion)
 	})
 
 	It("should return a DynamoDB client with correct endpoint", func() {
 		Expect(dynamoDbClient.Endpoint).To(Equal(testEndpoint))
 	})
+
+	It("should return a DynamoDB client with correct region", func() {
+		Expect(*dynamoDbClient.Config.Region).To(Equal(testRegion))
+	})
+
+	It("should reuse the client of the endpoint and region", func() {
+		Expect(awshelper.GetDynamoDbClient(testEndpoint, testRegion)).To(BeIdenticalTo(dynamoDbClient))
+		Expect(awshelper.GetDynamoDbClient(testEndpoint, "us-east-1")).NotTo(BeIdenticalTo(dynamoDbClient))
+	})
+
+	It("should keep connections alive", func() {
+		transport, ok := dynamoDbClient.Config.HTTPClient.Transport.(*http.Transport)
+		Expect(ok).To(BeTrue())
+		Expect(transport.MaxIdleConnsPerHost).To(BeNumerically(">", http.DefaultMaxIdleConnsPerHost))
+		Expect(transport.IdleConnTimeout).To(BeNumerically(">", 0))
+	})
+
+	It("should leave the timeout of calls to their context", func() {
+		Expect(dynamoDbClient.Config.HTTPClient.Timeout).To(BeZero())
+	})
+
+	It("should leave retries to the services", func() {
+		Expect(dynamoDbClient.MaxRetries()).To(BeZero())
+	})
 })
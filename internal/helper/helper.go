@@ -1,6 +1,30 @@
 package helper
 
+import (
+	"strings"
+)
+
 // StringPtr returns a pointer to the string.
 func StringPtr(s string) *string {
 	return &s
 }
+
+// BoolPtr returns a pointer to the bool.
+func BoolPtr(b bool) *bool {
+	return &b
+}
+
+// GetHeader returns the value of the header with the name, case-insensitively.
+func GetHeader(headers map[string]string, name string) string {
+	if value, ok := headers[name]; ok {
+		return value
+	}
+
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,212 @@
+// Package httpadapter serves API Gateway proxy handlers over net/http by
+// adapting http.Request to events.APIGatewayProxyRequest and the response
+// back, so that the API can run without API Gateway and Lambda.
+package httpadapter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"simple-information-store-app/internal/middleware"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// APIKeyHeader is the header API Gateway reads API keys from.
+const APIKeyHeader = "X-Api-Key"
+
+// Options are the options of the adapter.
+type Options struct {
+	// TrustAPIKeyHeader makes the adapter take the API key in the request
+	// header as verified, as API Gateway would after validating it. The key
+	// is also used as its id. Only use it for local development.
+	TrustAPIKeyHeader bool
+
+	// MaxBodyBytes is the max. size of request bodies, 0 for no limit. Larger
+	// requests are answered with 413, like API Gateway does beyond its limit.
+	MaxBodyBytes int64
+
+	// Timeout is the deadline of the context of requests, like the timeout of
+	// a Lambda function, 0 for none.
+	Timeout time.Duration
+}
+
+// Handler returns an http.Handler which serves the proxy handler.
+func Handler(handler middleware.Handler, options Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if options.MaxBodyBytes > 0 {
+			r.Body = &limitedBody{ReadCloser: r.Body, remaining: options.MaxBodyBytes}
+		}
+
+		request, err := RequestOf(r, options)
+		if errors.Is(err, errBodyTooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"message": "Request Too Long"})
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		if options.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+			defer cancel()
+		}
+
+		response, err := handler(ctx, request)
+		if err != nil {
+			// API Gateway answers errors of Lambda functions with 502.
+			log.Printf("handler of %s %s failed: %v", r.Method, r.URL.Path, err)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"message": "Internal server error"})
+			return
+		}
+
+		writeResponse(w, response)
+	})
+}
+
+// errBodyTooLarge is returned by reading a request body larger than
+// MaxBodyBytes.
+var errBodyTooLarge = errors.New("request body too large")
+
+// limitedBody is a request body which fails with errBodyTooLarge once more
+// than remaining bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+
+	// Read one byte more than remaining to tell if the body is larger.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = -1
+		return n, errBodyTooLarge
+	}
+
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// RequestOf returns the API Gateway proxy request of the HTTP request. Path
+// parameters and resource are left to the router.
+func RequestOf(r *http.Request, options Options) (events.APIGatewayProxyRequest, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:        r.Method,
+		Path:              r.URL.Path,
+		Headers:           map[string]string{},
+		MultiValueHeaders: map[string][]string{},
+		Body:              string(body),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod:       r.Method,
+			RequestTimeEpoch: time.Now().UnixNano() / int64(time.Millisecond),
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  sourceIPOf(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	for name, values := range r.Header {
+		// Like API Gateway, the last value is taken for single value headers.
+		request.Headers[name] = values[len(values)-1]
+		request.MultiValueHeaders[name] = values
+	}
+
+	if r.Host != "" {
+		request.Headers["Host"] = r.Host
+		request.MultiValueHeaders["Host"] = []string{r.Host}
+	}
+
+	if query := r.URL.Query(); len(query) > 0 {
+		request.QueryStringParameters = map[string]string{}
+		request.MultiValueQueryStringParameters = map[string][]string{}
+		for name, values := range query {
+			request.QueryStringParameters[name] = values[len(values)-1]
+			request.MultiValueQueryStringParameters[name] = values
+		}
+	}
+
+	if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" && options.TrustAPIKeyHeader {
+		request.RequestContext.Identity.APIKey = apiKey
+		request.RequestContext.Identity.APIKeyID = apiKey
+	}
+
+	return request, nil
+}
+
+// writeResponse writes the API Gateway proxy response to w.
+func writeResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			log.Printf("invalid base64 body of response: %v", err)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"message": "Internal server error"})
+			return
+		}
+
+		body = decoded
+	}
+
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+
+	for name, values := range response.MultiValueHeaders {
+		w.Header().Del(name)
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	bodyBytes, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(bodyBytes)
+}
+
+// sourceIPOf returns the IP address of the client of the request.
+func sourceIPOf(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,215 @@
+package httpadapter_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"simple-information-store-app/internal/httpadapter"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestOf()", func() {
+	var (
+		r       *http.Request
+		options httpadapter.Options
+		request events.APIGatewayProxyRequest
+	)
+
+	BeforeEach(func() {
+		r = httptest.NewRequest("POST", "http://localhost:3000/n/team-a/i/folder/info-id?list&tag=a&tag=b", strings.NewReader("info value"))
+		r.RemoteAddr = "192.0.2.1:54321"
+		r.Header.Add("Accept", "text/plain")
+		r.Header.Add("X-Multi", "first")
+		r.Header.Add("X-Multi", "last")
+		r.Header.Set(httpadapter.APIKeyHeader, "api-key")
+		options = httpadapter.Options{}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		request, err = httpadapter.RequestOf(r, options)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should adapt method, path and body", func() {
+		Expect(request.HTTPMethod).To(Equal("POST"))
+		Expect(request.Path).To(Equal("/n/team-a/i/folder/info-id"))
+		Expect(request.Body).To(Equal("info value"))
+		Expect(request.PathParameters).To(BeNil())
+	})
+
+	It("should adapt headers", func() {
+		Expect(request.Headers).To(HaveKeyWithValue("Accept", "text/plain"))
+		Expect(request.Headers).To(HaveKeyWithValue("X-Multi", "last"))
+		Expect(request.Headers).To(HaveKeyWithValue("Host", "localhost:3000"))
+		Expect(request.MultiValueHeaders).To(HaveKeyWithValue("X-Multi", []string{"first", "last"}))
+	})
+
+	It("should adapt query string parameters", func() {
+		Expect(request.QueryStringParameters).To(HaveKeyWithValue("list", ""))
+		Expect(request.QueryStringParameters).To(HaveKeyWithValue("tag", "b"))
+		Expect(request.MultiValueQueryStringParameters).To(HaveKeyWithValue("tag", []string{"a", "b"}))
+	})
+
+	It("should set the source IP", func() {
+		Expect(request.RequestContext.Identity.SourceIP).To(Equal("192.0.2.1"))
+	})
+
+	It("should not take the API key in the header as verified", func() {
+		Expect(request.RequestContext.Identity.APIKey).To(BeEmpty())
+	})
+
+	When("the API key header is trusted", func() {
+		BeforeEach(func() {
+			options.TrustAPIKeyHeader = true
+		})
+
+		It("should take the API key as verified", func() {
+			Expect(request.RequestContext.Identity.APIKey).To(Equal("api-key"))
+			Expect(request.RequestContext.Identity.APIKeyID).To(Equal("api-key"))
+		})
+	})
+})
+
+var _ = Describe("Handler()", func() {
+	var (
+		response events.APIGatewayProxyResponse
+		err      error
+		options  httpadapter.Options
+		body     string
+		called   bool
+		deadline bool
+		recorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		response = events.APIGatewayProxyResponse{}
+		err = nil
+		options = httpadapter.Options{}
+		body = ""
+		called = false
+		deadline = false
+		recorder = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		handler := httpadapter.Handler(func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			called = true
+			_, deadline = ctx.Deadline()
+			return response, err
+		}, options)
+		handler.ServeHTTP(recorder, httptest.NewRequest("PUT", "/i/info-id", strings.NewReader(body)))
+	})
+
+	When("the body exceeds the max. size", func() {
+		BeforeEach(func() {
+			options.MaxBodyBytes = 4
+			body = "info value"
+		})
+
+		It("should return 413 without calling the handler", func() {
+			Expect(recorder.Code).To(Equal(413))
+			Expect(called).To(BeFalse())
+		})
+	})
+
+	When("the body exceeds the max. size by one byte", func() {
+		BeforeEach(func() {
+			options.MaxBodyBytes = 9
+			body = "info value"
+		})
+
+		It("should return 413 without calling the handler", func() {
+			Expect(recorder.Code).To(Equal(413))
+			Expect(recorder.Body.String()).To(ContainSubstring("Request Too Long"))
+			Expect(called).To(BeFalse())
+		})
+	})
+
+	When("the body does not exceed the max. size", func() {
+		BeforeEach(func() {
+			options.MaxBodyBytes = 10
+			body = "info value"
+		})
+
+		It("should call the handler", func() {
+			Expect(recorder.Code).To(Equal(200))
+			Expect(called).To(BeTrue())
+		})
+	})
+
+	It("should call the handler without deadline", func() {
+		Expect(deadline).To(BeFalse())
+	})
+
+	When("a timeout is set", func() {
+		BeforeEach(func() {
+			options.Timeout = time.Second
+		})
+
+		It("should call the handler with a deadline", func() {
+			Expect(deadline).To(BeTrue())
+		})
+	})
+
+	When("the handler returns a response", func() {
+		BeforeEach(func() {
+			response = events.APIGatewayProxyResponse{
+				StatusCode: 201,
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				MultiValueHeaders: map[string][]string{
+					"Vary": {"Accept", "Origin"},
+				},
+				Body: "info value",
+			}
+		})
+
+		It("should write the response", func() {
+			Expect(recorder.Code).To(Equal(201))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain"))
+			Expect(recorder.Header()["Vary"]).To(Equal([]string{"Accept", "Origin"}))
+			Expect(recorder.Body.String()).To(Equal("info value"))
+		})
+	})
+
+	When("the handler returns a base64 encoded body", func() {
+		BeforeEach(func() {
+			response = events.APIGatewayProxyResponse{
+				StatusCode:      200,
+				Body:            base64.StdEncoding.EncodeToString([]byte{0, 1, 2}),
+				IsBase64Encoded: true,
+			}
+		})
+
+		It("should write the decoded body", func() {
+			body, _ := ioutil.ReadAll(recorder.Body)
+			Expect(body).To(Equal([]byte{0, 1, 2}))
+		})
+	})
+
+	When("the handler returns no status code", func() {
+		It("should return 200", func() {
+			Expect(recorder.Code).To(Equal(200))
+		})
+	})
+
+	When("the handler returns an error", func() {
+		BeforeEach(func() {
+			err = errors.New("test error")
+		})
+
+		It("should return 502 like API Gateway", func() {
+			Expect(recorder.Code).To(Equal(502))
+			Expect(recorder.Body.String()).To(ContainSubstring("Internal server error"))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("test error"))
+		})
+	})
+})
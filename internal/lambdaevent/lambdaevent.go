@@ -0,0 +1,361 @@
+// Package lambdaevent serves API Gateway proxy handlers to the HTTP events
+// Lambda functions may be invoked with: REST API (payload version 1.0),
+// HTTP API and Function URL (payload version 2.0) and ALB target group
+// events. The shape of an event is detected at runtime, the event is adapted
+// to events.APIGatewayProxyRequest and the response back to the shape of the
+// event, so that the handlers are written once for all of them.
+//
+// Only handlers which route by the path may be served to HTTP API, Function
+// URL and ALB events, i.e. the api function: these events have no path
+// parameters of REST API resources like /i/{id+}, which the per-route
+// handlers read, so that the per-route functions take REST API events only.
+package lambdaevent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"simple-information-store-app/internal/middleware"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Kind is the shape of an HTTP event.
+type Kind string
+
+// Kinds of HTTP events.
+const (
+	KindRESTAPI Kind = "rest-api"
+	KindHTTPAPI Kind = "http-api"
+	KindALB     Kind = "alb"
+)
+
+// probe contains the fields which tell the kinds of events apart.
+type probe struct {
+	Version        string `json:"version"`
+	HTTPMethod     string `json:"httpMethod"`
+	RequestContext struct {
+		ELB *json.RawMessage `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// KindOf returns the kind of the event. Function URL events have the same
+// shape as HTTP API events.
+func KindOf(event []byte) (Kind, error) {
+	var p probe
+	if err := json.Unmarshal(event, &p); err != nil {
+		return "", err
+	}
+
+	switch {
+	case p.Version == "2.0":
+		return KindHTTPAPI, nil
+	case p.RequestContext.ELB != nil:
+		return KindALB, nil
+	case p.HTTPMethod != "":
+		return KindRESTAPI, nil
+	default:
+		return "", fmt.Errorf("unknown event shape")
+	}
+}
+
+// Handler returns a Lambda handler which serves the proxy handler to HTTP
+// events of any kind and returns the response in the shape of the event.
+func Handler(handler middleware.Handler) func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	return func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+		kind, err := KindOf(event)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case KindHTTPAPI:
+			var v2 events.APIGatewayV2HTTPRequest
+			if err := json.Unmarshal(event, &v2); err != nil {
+				return nil, err
+			}
+
+			response, err := handler(ctx, RequestOfV2(v2))
+			if err != nil {
+				return nil, err
+			}
+
+			return ResponseV2Of(response), nil
+		case KindALB:
+			var alb events.ALBTargetGroupRequest
+			if err := json.Unmarshal(event, &alb); err != nil {
+				return nil, err
+			}
+
+			response, err := handler(ctx, RequestOfALB(alb))
+			if err != nil {
+				return nil, err
+			}
+
+			return ResponseALBOf(response, alb.MultiValueHeaders != nil), nil
+		default:
+			var request events.APIGatewayProxyRequest
+			if err := json.Unmarshal(event, &request); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, decodeBody(request))
+		}
+	}
+}
+
+// RequestOfV2 returns the REST API request of the HTTP API or Function URL
+// request.
+func RequestOfV2(v2 events.APIGatewayV2HTTPRequest) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		Resource:        resourceOfRouteKey(v2.RouteKey),
+		Path:            v2.RequestContext.HTTP.Path,
+		HTTPMethod:      v2.RequestContext.HTTP.Method,
+		Headers:         map[string]string{},
+		PathParameters:  v2.PathParameters,
+		StageVariables:  v2.StageVariables,
+		Body:            v2.Body,
+		IsBase64Encoded: v2.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:        v2.RequestContext.AccountID,
+			Stage:            v2.RequestContext.Stage,
+			RequestID:        v2.RequestContext.RequestID,
+			APIID:            v2.RequestContext.APIID,
+			DomainName:       v2.RequestContext.DomainName,
+			HTTPMethod:       v2.RequestContext.HTTP.Method,
+			RequestTimeEpoch: v2.RequestContext.TimeEpoch,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  v2.RequestContext.HTTP.SourceIP,
+				UserAgent: v2.RequestContext.HTTP.UserAgent,
+			},
+		},
+	}
+
+	if request.Path == "" {
+		request.Path = v2.RawPath
+	}
+
+	// Paths of named stages start with the stage, unlike in REST APIs.
+	if stage := v2.RequestContext.Stage; stage != "" && stage != "$default" {
+		if path := strings.TrimPrefix(request.Path, "/"+stage); strings.HasPrefix(path, "/") {
+			request.Path = path
+		}
+	}
+
+	// HTTP APIs join the values of headers and query string parameters with
+	// commas, so that the multi values are only known from the raw query.
+	request.MultiValueHeaders = map[string][]string{}
+	for name, value := range v2.Headers {
+		request.Headers[name] = value
+		request.MultiValueHeaders[name] = []string{value}
+	}
+
+	if len(v2.Cookies) > 0 {
+		cookie := strings.Join(v2.Cookies, "; ")
+		request.Headers["cookie"] = cookie
+		request.MultiValueHeaders["cookie"] = []string{cookie}
+	}
+
+	if query, err := url.ParseQuery(v2.RawQueryString); err == nil && len(query) > 0 {
+		request.QueryStringParameters = map[string]string{}
+		request.MultiValueQueryStringParameters = map[string][]string{}
+		for name, values := range query {
+			request.QueryStringParameters[name] = values[len(values)-1]
+			request.MultiValueQueryStringParameters[name] = values
+		}
+	} else if len(v2.QueryStringParameters) > 0 {
+		request.QueryStringParameters = v2.QueryStringParameters
+	}
+
+	if authorizer := v2.RequestContext.Authorizer; authorizer != nil {
+		if authorizer.JWT != nil {
+			claims := map[string]interface{}{}
+			for name, value := range authorizer.JWT.Claims {
+				claims[name] = value
+			}
+
+			request.RequestContext.Authorizer = map[string]interface{}{"claims": claims}
+		}
+
+		if iam := authorizer.IAM; iam != nil {
+			request.RequestContext.Identity.AccessKey = iam.AccessKey
+			request.RequestContext.Identity.AccountID = iam.AccountID
+			request.RequestContext.Identity.Caller = iam.CallerID
+			request.RequestContext.Identity.User = iam.UserID
+			request.RequestContext.Identity.UserArn = iam.UserARN
+			request.RequestContext.Identity.CognitoIdentityID = iam.CognitoIdentity.IdentityID
+			request.RequestContext.Identity.CognitoIdentityPoolID = iam.CognitoIdentity.IdentityPoolID
+		}
+	}
+
+	return decodeBody(request)
+}
+
+// ResponseV2Of returns the HTTP API response of the REST API response.
+func ResponseV2Of(response events.APIGatewayProxyResponse) events.APIGatewayV2HTTPResponse {
+	v2 := events.APIGatewayV2HTTPResponse{
+		StatusCode:      response.StatusCode,
+		Headers:         map[string]string{},
+		Body:            response.Body,
+		IsBase64Encoded: response.IsBase64Encoded,
+	}
+
+	for name, value := range response.Headers {
+		v2.Headers[name] = value
+	}
+
+	// HTTP APIs take multi value headers joined with commas, and cookies
+	// separately.
+	for name, values := range response.MultiValueHeaders {
+		if strings.EqualFold(name, "Set-Cookie") {
+			v2.Cookies = append(v2.Cookies, values...)
+			continue
+		}
+
+		v2.Headers[name] = strings.Join(values, ", ")
+	}
+
+	for name, value := range v2.Headers {
+		if strings.EqualFold(name, "Set-Cookie") {
+			v2.Cookies = append(v2.Cookies, value)
+			delete(v2.Headers, name)
+		}
+	}
+
+	return v2
+}
+
+// RequestOfALB returns the REST API request of the ALB request.
+func RequestOfALB(alb events.ALBTargetGroupRequest) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		Path:              alb.Path,
+		HTTPMethod:        alb.HTTPMethod,
+		Headers:           map[string]string{},
+		MultiValueHeaders: map[string][]string{},
+		Body:              alb.Body,
+		IsBase64Encoded:   alb.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: alb.HTTPMethod,
+		},
+	}
+
+	// ALB sends either single or multi value headers and query string
+	// parameters, depending on the configuration of the target group.
+	for name, value := range alb.Headers {
+		request.Headers[name] = value
+		request.MultiValueHeaders[name] = []string{value}
+	}
+
+	for name, values := range alb.MultiValueHeaders {
+		request.Headers[name] = values[len(values)-1]
+		request.MultiValueHeaders[name] = values
+	}
+
+	// Unlike API Gateway, ALB does not decode query string parameters.
+	query := map[string][]string{}
+	for name, value := range alb.QueryStringParameters {
+		query[unescape(name)] = []string{unescape(value)}
+	}
+
+	for name, values := range alb.MultiValueQueryStringParameters {
+		for _, value := range values {
+			query[unescape(name)] = append(query[unescape(name)], unescape(value))
+		}
+	}
+
+	if len(query) > 0 {
+		request.QueryStringParameters = map[string]string{}
+		request.MultiValueQueryStringParameters = query
+		for name, values := range query {
+			request.QueryStringParameters[name] = values[len(values)-1]
+		}
+	}
+
+	// ALB appends the address of the client to X-Forwarded-For. Addresses
+	// before it are sent by the client, which may spoof them to get around
+	// rate limits, so the client is the last address.
+	if forwardedFor := request.Headers["x-forwarded-for"]; forwardedFor != "" {
+		addresses := strings.Split(forwardedFor, ",")
+		request.RequestContext.Identity.SourceIP = strings.TrimSpace(addresses[len(addresses)-1])
+	}
+
+	request.RequestContext.Identity.UserAgent = request.Headers["user-agent"]
+
+	return decodeBody(request)
+}
+
+// ResponseALBOf returns the ALB response of the REST API response. The
+// response has multi value headers if the target group has them enabled,
+// i.e. the request has multi value headers.
+func ResponseALBOf(response events.APIGatewayProxyResponse, multiValueHeaders bool) events.ALBTargetGroupResponse {
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	alb := events.ALBTargetGroupResponse{
+		StatusCode:        statusCode,
+		StatusDescription: fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Body:              response.Body,
+		IsBase64Encoded:   response.IsBase64Encoded,
+	}
+
+	headers := map[string][]string{}
+	for name, value := range response.Headers {
+		headers[name] = []string{value}
+	}
+
+	for name, values := range response.MultiValueHeaders {
+		headers[name] = values
+	}
+
+	if multiValueHeaders {
+		alb.MultiValueHeaders = headers
+		return alb
+	}
+
+	alb.Headers = map[string]string{}
+	for name, values := range headers {
+		alb.Headers[name] = strings.Join(values, ", ")
+	}
+
+	return alb
+}
+
+// resourceOfRouteKey returns the resource of the route key of HTTP APIs,
+// e.g. /i/{id+} of "GET /i/{id+}". The default route has no resource.
+func resourceOfRouteKey(routeKey string) string {
+	if i := strings.Index(routeKey, " "); i >= 0 {
+		return routeKey[i+1:]
+	}
+
+	return ""
+}
+
+// decodeBody returns the request with its body decoded if it is base64
+// encoded, since the handlers take bodies as text.
+func decodeBody(request events.APIGatewayProxyRequest) events.APIGatewayProxyRequest {
+	if !request.IsBase64Encoded {
+		return request
+	}
+
+	if body, err := base64.StdEncoding.DecodeString(request.Body); err == nil {
+		request.Body = string(body)
+		request.IsBase64Encoded = false
+	}
+
+	return request
+}
+
+func unescape(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+
+	return s
+}
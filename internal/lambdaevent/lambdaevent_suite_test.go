@@ -0,0 +1,22 @@
+package lambdaevent_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLambdaevent(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lambdaevent Suite")
+}
+
+// readEvent returns the recorded sample event in testdata.
+func readEvent(name string) []byte {
+	event, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	Expect(err).ShouldNot(HaveOccurred())
+	return event
+}
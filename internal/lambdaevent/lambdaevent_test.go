@@ -0,0 +1,245 @@
+package lambdaevent_test
+
+import (
+	"context"
+	"encoding/json"
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/lambdaevent"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("KindOf()",
+	func(name string, expected lambdaevent.Kind) {
+		kind, err := lambdaevent.KindOf(readEvent(name))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(kind).To(Equal(expected))
+	},
+	Entry("REST API", "rest-api-get-value.json", lambdaevent.KindRESTAPI),
+	Entry("HTTP API", "http-api-create-value.json", lambdaevent.KindHTTPAPI),
+	Entry("Function URL", "function-url-get-value.json", lambdaevent.KindHTTPAPI),
+	Entry("ALB", "alb-get-value.json", lambdaevent.KindALB),
+	Entry("ALB with multi value headers", "alb-multi-value-headers.json", lambdaevent.KindALB),
+)
+
+var _ = Describe("Handler()", func() {
+	var (
+		eventName string
+		response  events.APIGatewayProxyResponse
+		received  events.APIGatewayProxyRequest
+		output    interface{}
+		err       error
+	)
+
+	BeforeEach(func() {
+		received = events.APIGatewayProxyRequest{}
+		response = events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers: map[string]string{
+				"Content-Type": "text/plain",
+			},
+			MultiValueHeaders: map[string][]string{
+				"Vary":       {"Accept", "Origin"},
+				"Set-Cookie": {"a=1", "b=2"},
+			},
+			Body: "info value",
+		}
+	})
+
+	JustBeforeEach(func() {
+		handler := lambdaevent.Handler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			received = request
+			return response, nil
+		})
+		output, err = handler(context.Background(), readEvent(eventName))
+	})
+
+	When("invoked by a REST API", func() {
+		BeforeEach(func() {
+			eventName = "rest-api-get-value.json"
+		})
+
+		It("should pass the request as is", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(received.HTTPMethod).To(Equal("GET"))
+			Expect(received.Path).To(Equal("/n/team-a/i/folder/info-id"))
+			Expect(received.Resource).To(Equal("/n/{namespace}/i/{id+}"))
+			Expect(received.PathParameters).To(HaveKeyWithValue("id", "folder/info-id"))
+			Expect(auth.MethodOf(received)).To(Equal(auth.MethodAPIKey))
+			Expect(auth.OwnerOf(received)).To(Equal("a1b2c3d4e5"))
+		})
+
+		It("should return the response as is", func() {
+			Expect(output).To(Equal(response))
+		})
+	})
+
+	When("invoked by an HTTP API", func() {
+		BeforeEach(func() {
+			eventName = "http-api-create-value.json"
+		})
+
+		It("should adapt the request", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(received.HTTPMethod).To(Equal("POST"))
+			Expect(received.Path).To(Equal("/n/team-a/i/folder/info-id"))
+			Expect(received.Resource).To(Equal("/n/{namespace}/i/{id+}"))
+			Expect(received.PathParameters).To(Equal(map[string]string{"namespace": "team-a", "id": "folder/info-id"}))
+			Expect(received.Headers).To(HaveKeyWithValue("content-type", "text/plain"))
+			Expect(received.Headers).To(HaveKeyWithValue("cookie", "session=abc; theme=dark"))
+			Expect(received.RequestContext.RequestID).To(Equal("JKJaXmPLvHcESHA="))
+			Expect(received.RequestContext.Identity.SourceIP).To(Equal("198.51.100.7"))
+		})
+
+		It("should split multi value query string parameters", func() {
+			Expect(received.QueryStringParameters).To(HaveKeyWithValue("list", ""))
+			Expect(received.MultiValueQueryStringParameters).To(HaveKeyWithValue("tag", []string{"a", "b"}))
+		})
+
+		It("should decode the base64 encoded body", func() {
+			Expect(received.Body).To(Equal("info value"))
+			Expect(received.IsBase64Encoded).To(BeFalse())
+		})
+
+		It("should take the JWT claims as Cognito identity", func() {
+			Expect(auth.MethodOf(received)).To(Equal(auth.MethodCognito))
+			Expect(auth.OwnerOf(received)).To(Equal("5f3c1a2b-xmpl-4d6e-9f80-1a2b3c4d5e6f"))
+		})
+
+		It("should return an HTTP API response", func() {
+			v2, ok := output.(events.APIGatewayV2HTTPResponse)
+			Expect(ok).To(BeTrue())
+			Expect(v2.StatusCode).To(Equal(200))
+			Expect(v2.Body).To(Equal("info value"))
+			Expect(v2.Headers).To(HaveKeyWithValue("Content-Type", "text/plain"))
+			Expect(v2.Headers).To(HaveKeyWithValue("Vary", "Accept, Origin"))
+			Expect(v2.Headers).NotTo(HaveKey("Set-Cookie"))
+			Expect(v2.Cookies).To(Equal([]string{"a=1", "b=2"}))
+		})
+	})
+
+	When("invoked by a Function URL", func() {
+		BeforeEach(func() {
+			eventName = "function-url-get-value.json"
+		})
+
+		It("should adapt the request without resource", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(received.HTTPMethod).To(Equal("GET"))
+			Expect(received.Path).To(Equal("/i/info-id"))
+			Expect(received.Resource).To(BeEmpty())
+			Expect(received.PathParameters).To(BeNil())
+			Expect(received.QueryStringParameters).To(BeNil())
+			Expect(received.Headers).To(HaveKey("if-none-match"))
+		})
+
+		It("should take the IAM identity", func() {
+			Expect(auth.MethodOf(received)).To(Equal(auth.MethodIAM))
+			Expect(auth.OwnerOf(received)).To(Equal("arn:aws:sts::123456789012:assumed-role/ci/ci"))
+		})
+
+		It("should return an HTTP API response", func() {
+			Expect(output).To(BeAssignableToTypeOf(events.APIGatewayV2HTTPResponse{}))
+		})
+	})
+
+	When("invoked by an ALB", func() {
+		BeforeEach(func() {
+			eventName = "alb-get-value.json"
+		})
+
+		It("should adapt the request", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(received.HTTPMethod).To(Equal("GET"))
+			Expect(received.Path).To(Equal("/n/team-a/i/folder/"))
+			Expect(received.Headers).To(HaveKeyWithValue("accept", "application/json"))
+			Expect(received.RequestContext.Identity.SourceIP).To(Equal("203.0.113.9"))
+			Expect(auth.MethodOf(received)).To(Equal(auth.MethodNone))
+		})
+
+		It("should decode query string parameters", func() {
+			Expect(received.QueryStringParameters).To(HaveKeyWithValue("list", ""))
+			Expect(received.QueryStringParameters).To(HaveKeyWithValue("q", "a b"))
+		})
+
+		It("should return an ALB response with single value headers", func() {
+			alb, ok := output.(events.ALBTargetGroupResponse)
+			Expect(ok).To(BeTrue())
+			Expect(alb.StatusCode).To(Equal(200))
+			Expect(alb.StatusDescription).To(Equal("200 OK"))
+			Expect(alb.Body).To(Equal("info value"))
+			Expect(alb.Headers).To(HaveKeyWithValue("Vary", "Accept, Origin"))
+			Expect(alb.MultiValueHeaders).To(BeNil())
+		})
+	})
+
+	When("invoked by an ALB with multi value headers", func() {
+		BeforeEach(func() {
+			eventName = "alb-multi-value-headers.json"
+		})
+
+		It("should adapt the request", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(received.HTTPMethod).To(Equal("PUT"))
+			Expect(received.Headers).To(HaveKeyWithValue("content-type", "application/json"))
+			Expect(received.MultiValueQueryStringParameters).To(HaveKeyWithValue("tag", []string{"a,b", "c"}))
+			Expect(received.Body).To(Equal(`{"value":"info value"}`))
+		})
+
+		It("should return an ALB response with multi value headers", func() {
+			alb, ok := output.(events.ALBTargetGroupResponse)
+			Expect(ok).To(BeTrue())
+			Expect(alb.Headers).To(BeNil())
+			Expect(alb.MultiValueHeaders).To(HaveKeyWithValue("Content-Type", []string{"text/plain"}))
+			Expect(alb.MultiValueHeaders).To(HaveKeyWithValue("Set-Cookie", []string{"a=1", "b=2"}))
+		})
+	})
+})
+
+var _ = Describe("Handler() with an unknown event", func() {
+	It("should fail", func() {
+		handler := lambdaevent.Handler(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			return events.APIGatewayProxyResponse{}, nil
+		})
+		_, err := handler(context.Background(), json.RawMessage(`{"source": "aws.events"}`))
+		Expect(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("RequestOfV2()", func() {
+	It("should strip named stages from the path", func() {
+		request := lambdaevent.RequestOfV2(events.APIGatewayV2HTTPRequest{
+			RawPath: "/prod/i/info-id",
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				Stage: "prod",
+				HTTP:  events.APIGatewayV2HTTPRequestContextHTTPDescription{Path: "/prod/i/info-id"},
+			},
+		})
+		Expect(request.Path).To(Equal("/i/info-id"))
+	})
+})
+
+var _ = Describe("RequestOfALB()", func() {
+	It("should take the address appended by ALB as source IP", func() {
+		request := lambdaevent.RequestOfALB(events.ALBTargetGroupRequest{
+			HTTPMethod: "GET",
+			Path:       "/i/info-id",
+			Headers:    map[string]string{"x-forwarded-for": "203.0.113.9"},
+		})
+		Expect(request.RequestContext.Identity.SourceIP).To(Equal("203.0.113.9"))
+	})
+
+	It("should ignore addresses spoofed by the client", func() {
+		request := lambdaevent.RequestOfALB(events.ALBTargetGroupRequest{
+			HTTPMethod: "GET",
+			Path:       "/i/info-id",
+			MultiValueHeaders: map[string][]string{
+				"x-forwarded-for": {"192.0.2.66, 192.0.2.67,203.0.113.9"},
+			},
+		})
+		Expect(request.RequestContext.Identity.SourceIP).To(Equal("203.0.113.9"))
+	})
+})
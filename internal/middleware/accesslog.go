@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// accessLogEntry is a line of the access log.
+type accessLogEntry struct {
+	RequestID string  `json:"requestId"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	Duration  float64 `json:"durationMs"`
+	SourceIP  string  `json:"sourceIp,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// now returns the current time. It is replaced in tests.
+var now = time.Now
+
+// logOutput receives the access log. It is replaced in tests.
+var logOutput io.Writer = os.Stdout
+
+// handlerErrorKey is the context key of the slot in which ErrorMapping records
+// the error it mapped, so that the access log still sees it.
+type handlerErrorKey struct{}
+
+// recordHandlerError records the error of the handler for the access log of
+// the request, if any.
+func recordHandlerError(ctx context.Context, err error) {
+	if slot, ok := ctx.Value(handlerErrorKey{}).(*error); ok {
+		*slot = err
+	}
+}
+
+// AccessLog returns a middleware which logs each request as a JSON line.
+// Errors are logged whether they are returned or mapped to a response by an
+// inner ErrorMapping.
+func AccessLog() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			start := now()
+			var handlerErr error
+			response, err := next(context.WithValue(ctx, handlerErrorKey{}, &handlerErr), request)
+
+			entry := accessLogEntry{
+				RequestID: request.RequestContext.RequestID,
+				Method:    request.HTTPMethod,
+				Path:      request.Path,
+				Status:    response.StatusCode,
+				Duration:  float64(now().Sub(start)) / float64(time.Millisecond),
+				SourceIP:  request.RequestContext.Identity.SourceIP,
+			}
+
+			if err != nil {
+				entry.Error = err.Error()
+			} else if handlerErr != nil {
+				entry.Error = handlerErr.Error()
+			}
+
+			line, _ := json.Marshal(entry)
+			fmt.Fprintln(logOutput, string(line))
+			return response, err
+		}
+	}
+}
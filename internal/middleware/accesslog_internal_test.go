@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"simple-information-store-app/internal/config"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("access log", func() {
+	var (
+		output *bytes.Buffer
+		err    error
+	)
+
+	// logged returns the entry of the single logged line.
+	logged := func() accessLogEntry {
+		var entry accessLogEntry
+		Expect(json.Unmarshal(output.Bytes(), &entry)).To(Succeed())
+		return entry
+	}
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		logOutput = output
+		err = errors.New("table unavailable")
+	})
+
+	AfterEach(func() {
+		logOutput = os.Stdout
+	})
+
+	failing := func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	It("should log errors returned by the handler", func() {
+		handler := Chain(failing, AccessLog())
+
+		_, _ = handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/infos/a"})
+		entry := logged()
+		Expect(entry.Error).To(Equal("table unavailable"))
+		Expect(entry.Path).To(Equal("/infos/a"))
+	})
+
+	It("should log errors mapped to a response by an inner error mapping", func() {
+		handler := Chain(failing, AccessLog(), ErrorMapping())
+
+		response, handlerErr := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/infos/a"})
+		Expect(handlerErr).ShouldNot(HaveOccurred())
+		entry := logged()
+		Expect(entry.Error).To(Equal("table unavailable"))
+		Expect(entry.Status).To(Equal(response.StatusCode))
+		Expect(entry.Status).To(Equal(500))
+	})
+
+	It("should log the chain of Common() with the error", func() {
+		handler := Chain(failing, Common(config.Config{})...)
+
+		_, _ = handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+		Expect(logged().Error).To(Equal("table unavailable"))
+	})
+
+	It("should not log an error for successful requests", func() {
+		handler := Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+		}, AccessLog(), ErrorMapping())
+
+		_, _ = handler(context.Background(), events.APIGatewayProxyRequest{})
+		entry := logged()
+		Expect(entry.Error).To(BeEmpty())
+		Expect(entry.Status).To(Equal(200))
+	})
+})
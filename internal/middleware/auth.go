@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// NamespaceHandler handles a request in the namespace of its path.
+type NamespaceHandler func(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error)
+
+// Authenticated returns a handler which resolves the namespace of the
+// request path and checks that the request is authenticated with a method
+// and as a principal the namespace allows, before calling the namespace
+// handler.
+func Authenticated(namespaceGetter service.NamespaceGetter, handler NamespaceHandler) Handler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		ns, err := namespaceGetter.GetNamespace(request.PathParameters["namespace"])
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		if !ns.AllowsAuthMethod(auth.MethodOf(request)) {
+			return events.APIGatewayProxyResponse{}, problem.AuthMethodNotAllowed(ns.Name)
+		}
+
+		if !ns.AllowsPrincipal(auth.OwnerOf(request)) {
+			return events.APIGatewayProxyResponse{}, problem.PrincipalNotAllowed(ns.Name)
+		}
+
+		return handler(ctx, request, ns)
+	}
+}
+
+// API returns the handler of an API route in a namespace with the common
+// middlewares, rate limiting and authentication of the configuration.
+func API(c config.Config, limiter ratelimit.Limiter, namespaceGetter service.NamespaceGetter, handler NamespaceHandler) Handler {
+	return Chain(Authenticated(namespaceGetter, handler), append(Common(c), RateLimit(limiter, ratelimit.LimitsOf(c)))...)
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CORSConfig configures cross-origin requests.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to access the API, "*" for any.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// ExposedHeaders are the response headers readable by browsers.
+	ExposedHeaders []string
+
+	// AllowCredentials allows requests with credentials, e.g. cookies, from
+	// the explicitly allowed origins. "*" allows no origin then, since any
+	// site could read responses with the user's credentials otherwise.
+	AllowCredentials bool
+
+	// MaxAge is the number of seconds preflight responses may be cached.
+	MaxAge int
+}
+
+// DefaultCORSConfig allows any origin to use the API.
+var DefaultCORSConfig = CORSConfig{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"},
+	AllowedHeaders: []string{"Content-Type", "Accept", "Authorization", "If-None-Match", "If-Modified-Since", "If-Range", "Range", "X-Api-Key", "X-Request-Id"},
+	ExposedHeaders: []string{"ETag", "Last-Modified", "Content-Range", "Retry-After", "X-Request-Id", "X-Info-Version", "X-Info-Created-At", "X-Info-Tags"},
+	MaxAge:         600,
+}
+
+// CORSConfigOf returns DefaultCORSConfig with the origins, methods, headers
+// and credentials of the configuration.
+func CORSConfigOf(c config.CORS) CORSConfig {
+	corsConfig := DefaultCORSConfig
+	corsConfig.AllowedOrigins = c.AllowedOrigins
+	if len(c.AllowedMethods) > 0 {
+		corsConfig.AllowedMethods = c.AllowedMethods
+	}
+
+	if len(c.AllowedHeaders) > 0 {
+		corsConfig.AllowedHeaders = c.AllowedHeaders
+	}
+
+	corsConfig.AllowCredentials = c.AllowCredentials
+	return corsConfig
+}
+
+// allowedOrigin returns the value of Access-Control-Allow-Origin for the
+// origin, or an empty string if the origin is not allowed.
+func (config CORSConfig) allowedOrigin(origin string) string {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" && !config.AllowCredentials {
+			return "*"
+		}
+
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+
+	return ""
+}
+
+// CORS returns a middleware which adds CORS headers to responses to allowed
+// origins and answers preflight requests.
+func CORS(config CORSConfig) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			origin := helper.GetHeader(request.Headers, "Origin")
+			allowedOrigin := ""
+			if origin != "" {
+				allowedOrigin = config.allowedOrigin(origin)
+			}
+
+			preflight := request.HTTPMethod == http.MethodOptions &&
+				helper.GetHeader(request.Headers, "Access-Control-Request-Method") != ""
+			if preflight {
+				response := events.APIGatewayProxyResponse{StatusCode: 204}
+				if allowedOrigin != "" {
+					config.setOriginHeaders(&response, allowedOrigin)
+					setHeader(&response, "Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+					setHeader(&response, "Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+					setHeader(&response, "Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+				}
+
+				setHeader(&response, "Vary", "Origin")
+				return response, nil
+			}
+
+			response, err := next(ctx, request)
+			if allowedOrigin != "" {
+				config.setOriginHeaders(&response, allowedOrigin)
+				if len(config.ExposedHeaders) > 0 {
+					setHeader(&response, "Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+				}
+
+				if allowedOrigin != "*" {
+					addVary(&response, "Origin")
+				}
+			}
+
+			return response, err
+		}
+	}
+}
+
+// setOriginHeaders sets the headers which allow the origin to read the response.
+func (config CORSConfig) setOriginHeaders(response *events.APIGatewayProxyResponse, allowedOrigin string) {
+	setHeader(response, "Access-Control-Allow-Origin", allowedOrigin)
+	if config.AllowCredentials {
+		setHeader(response, "Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// addVary adds the header name to the Vary header of the response.
+func addVary(response *events.APIGatewayProxyResponse, name string) {
+	if vary := response.Headers["Vary"]; vary != "" {
+		setHeader(response, "Vary", vary+", "+name)
+		return
+	}
+
+	setHeader(response, "Vary", name)
+}
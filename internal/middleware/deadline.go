@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"simple-information-store-app/internal/problem"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeadlineMargin is the time reserved before the deadline of an invocation to
+// answer the request. Lambda stops functions at their deadline, which API
+// Gateway answers with an opaque 502.
+const DeadlineMargin = 500 * time.Millisecond
+
+// Deadline returns a middleware which cancels the context of the handler the
+// margin before the deadline of the request context, if it has one, and
+// answers requests which fail after that or arrive too late with 503.
+func Deadline(margin time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return next(ctx, request)
+			}
+
+			if time.Until(deadline) <= margin {
+				return events.APIGatewayProxyResponse{}, problem.ServiceUnavailable()
+			}
+
+			ctx, cancel := context.WithDeadline(ctx, deadline.Add(-margin))
+			defer cancel()
+
+			response, err := next(ctx, request)
+			if err != nil && ctx.Err() != nil {
+				return events.APIGatewayProxyResponse{}, problem.ServiceUnavailable()
+			}
+
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"simple-information-store-app/internal/problem"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrorMapping returns a middleware which turns errors returned by the
+// handler into problem+json responses, so that handlers only have to return
+// service errors.
+func ErrorMapping() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			response, err := next(ctx, request)
+			if err == nil {
+				return response, nil
+			}
+
+			recordHandlerError(ctx, err)
+			response = problem.Response(request, err)
+			if request.HTTPMethod == http.MethodHead {
+				// Responses to HEAD have no body.
+				response.Body = ""
+			}
+
+			return response, nil
+		}
+	}
+}
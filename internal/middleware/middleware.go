@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+
+	"simple-information-store-app/internal/config"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler handles an API Gateway proxy request. The context carries the
+// deadline of the invocation and should be passed to all calls of services.
+type Handler func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Middleware wraps a handler with additional behavior.
+type Middleware func(next Handler) Handler
+
+// Chain wraps the handler with the middlewares. The first middleware is the
+// outermost one, i.e. it sees the request first and the response last.
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// Common returns the middlewares every API handler should use: panic
+// recovery, request ids, access logging, CORS of the configuration, error
+// mapping and the deadline of the invocation.
+func Common(c config.Config) []Middleware {
+	return []Middleware{
+		Recovery(),
+		RequestID(),
+		AccessLog(),
+		CORS(CORSConfigOf(c.CORS)),
+		ErrorMapping(),
+		Deadline(DeadlineMargin),
+	}
+}
+
+// setHeader sets the header of the response, creating the header map if
+// necessary.
+func setHeader(response *events.APIGatewayProxyResponse, name, value string) {
+	if response.Headers == nil {
+		response.Headers = map[string]string{}
+	}
+
+	response.Headers[name] = value
+}
@@ -0,0 +1,346 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// respond returns a handler which returns the response and error.
+func respond(response events.APIGatewayProxyResponse, err error) middleware.Handler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return response, err
+	}
+}
+
+var _ = Describe("Chain()", func() {
+	It("should call the middlewares in order", func() {
+		calls := []string{}
+		trace := func(name string) middleware.Middleware {
+			return func(next middleware.Handler) middleware.Handler {
+				return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					calls = append(calls, name+" before")
+					response, err := next(ctx, request)
+					calls = append(calls, name+" after")
+					return response, err
+				}
+			}
+		}
+
+		handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			calls = append(calls, "handler")
+			return events.APIGatewayProxyResponse{}, nil
+		}, trace("outer"), trace("inner"))
+
+		handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(calls).To(Equal([]string{"outer before", "inner before", "handler", "inner after", "outer after"}))
+	})
+})
+
+var _ = Describe("Recovery()", func() {
+	It("should turn a panic into 500", func() {
+		handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			panic("panic")
+		}, middleware.Recovery())
+
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(500))
+		Expect(response.Body).To(ContainSubstring(problem.TypeInternalError))
+	})
+})
+
+var _ = Describe("RequestID()", func() {
+	var requestID string
+
+	handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		requestID = request.RequestContext.RequestID
+		return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+	}, middleware.RequestID())
+
+	It("should use the id of API Gateway", func() {
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+			Headers:        map[string]string{"X-Request-Id": "client-id"},
+			RequestContext: events.APIGatewayProxyRequestContext{RequestID: "gateway-id"},
+		})
+		Expect(requestID).To(Equal("gateway-id"))
+		Expect(response.Headers).To(HaveKeyWithValue("X-Request-Id", "gateway-id"))
+	})
+
+	It("should use the id of the client", func() {
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+			Headers: map[string]string{"x-request-id": "client-id"},
+		})
+		Expect(requestID).To(Equal("client-id"))
+		Expect(response.Headers).To(HaveKeyWithValue("X-Request-Id", "client-id"))
+	})
+
+	It("should generate an id", func() {
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(requestID).To(HaveLen(36))
+		Expect(response.Headers).To(HaveKeyWithValue("X-Request-Id", requestID))
+	})
+})
+
+var _ = Describe("AccessLog()", func() {
+	It("should pass the response and error through", func() {
+		expectedErr := errors.New("error")
+		handler := middleware.Chain(respond(events.APIGatewayProxyResponse{StatusCode: 418}, expectedErr), middleware.AccessLog())
+
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(err).To(Equal(expectedErr))
+		Expect(response.StatusCode).To(Equal(418))
+	})
+})
+
+var _ = Describe("ErrorMapping()", func() {
+	It("should map errors to problems", func() {
+		handler := middleware.Chain(respond(events.APIGatewayProxyResponse{}, service.InfoNotFoundError{InfoID: "a"}), middleware.ErrorMapping())
+
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(404))
+		Expect(response.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
+		Expect(response.Body).To(ContainSubstring(problem.TypeInfoNotFound))
+	})
+
+	It("should map errors of HEAD requests without body", func() {
+		handler := middleware.Chain(respond(events.APIGatewayProxyResponse{}, service.InfoNotFoundError{InfoID: "a"}), middleware.ErrorMapping())
+
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "HEAD"})
+		Expect(response.StatusCode).To(Equal(404))
+		Expect(response.Body).To(BeEmpty())
+	})
+
+	It("should keep successful responses", func() {
+		handler := middleware.Chain(respond(events.APIGatewayProxyResponse{StatusCode: 200, Body: "body"}, nil), middleware.ErrorMapping())
+
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(response).To(Equal(events.APIGatewayProxyResponse{StatusCode: 200, Body: "body"}))
+	})
+})
+
+var _ = Describe("Deadline()", func() {
+	const margin = 100 * time.Millisecond
+
+	It("should call the handler with the context without deadline", func() {
+		handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			_, ok := ctx.Deadline()
+			Expect(ok).To(BeFalse())
+			return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+		}, middleware.Deadline(margin))
+
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(200))
+	})
+
+	It("should cancel the context of the handler the margin before the deadline", func() {
+		deadline := time.Now().Add(time.Second)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+
+		handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			handlerDeadline, ok := ctx.Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(handlerDeadline).To(BeTemporally("==", deadline.Add(-margin)))
+			return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+		}, middleware.Deadline(margin))
+
+		response, err := handler(ctx, events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(200))
+	})
+
+	It("should answer requests which arrive too late with 503", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), margin/2)
+		defer cancel()
+
+		called := false
+		handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			called = true
+			return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+		}, middleware.ErrorMapping(), middleware.Deadline(margin))
+
+		response, err := handler(ctx, events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(503))
+		Expect(response.Body).To(ContainSubstring(problem.TypeServiceUnavailable))
+		Expect(called).To(BeFalse())
+	})
+
+	It("should answer requests which fail after the deadline with 503", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), margin+50*time.Millisecond)
+		defer cancel()
+
+		handler := middleware.Chain(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			<-ctx.Done()
+			return events.APIGatewayProxyResponse{}, ctx.Err()
+		}, middleware.ErrorMapping(), middleware.Deadline(margin))
+
+		response, err := handler(ctx, events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(503))
+		Expect(ctx.Err()).ShouldNot(HaveOccurred())
+	})
+
+	It("should return errors before the deadline", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		handler := middleware.Chain(respond(events.APIGatewayProxyResponse{}, service.InfoNotFoundError{InfoID: "a"}),
+			middleware.ErrorMapping(), middleware.Deadline(margin))
+
+		response, err := handler(ctx, events.APIGatewayProxyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(404))
+	})
+})
+
+var _ = Describe("CORS()", func() {
+	var handler middleware.Handler
+
+	BeforeEach(func() {
+		config := middleware.DefaultCORSConfig
+		config.AllowedOrigins = []string{"https://example.com"}
+		handler = middleware.Chain(respond(events.APIGatewayProxyResponse{StatusCode: 200}, nil), middleware.CORS(config))
+	})
+
+	It("should add CORS headers for allowed origins", func() {
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Headers:    map[string]string{"Origin": "https://example.com"},
+		})
+		Expect(response.StatusCode).To(Equal(200))
+		Expect(response.Headers).To(HaveKeyWithValue("Access-Control-Allow-Origin", "https://example.com"))
+		Expect(response.Headers).To(HaveKeyWithValue("Access-Control-Expose-Headers", ContainSubstring("ETag")))
+		Expect(response.Headers).To(HaveKeyWithValue("Vary", "Origin"))
+	})
+
+	It("should not add CORS headers for other origins", func() {
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Headers:    map[string]string{"Origin": "https://other.com"},
+		})
+		Expect(response.Headers).NotTo(HaveKey("Access-Control-Allow-Origin"))
+	})
+
+	It("should allow credentials to explicitly allowed origins only", func() {
+		config := middleware.DefaultCORSConfig
+		config.AllowedOrigins = []string{"*", "https://example.com"}
+		config.AllowCredentials = true
+		handler = middleware.Chain(respond(events.APIGatewayProxyResponse{StatusCode: 200}, nil), middleware.CORS(config))
+
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Headers:    map[string]string{"Origin": "https://example.com"},
+		})
+		Expect(response.Headers).To(HaveKeyWithValue("Access-Control-Allow-Origin", "https://example.com"))
+		Expect(response.Headers).To(HaveKeyWithValue("Access-Control-Allow-Credentials", "true"))
+		Expect(response.Headers).To(HaveKeyWithValue("Vary", "Origin"))
+
+		response, _ = handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Headers:    map[string]string{"Origin": "https://other.com"},
+		})
+		Expect(response.Headers).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		Expect(response.Headers).NotTo(HaveKey("Access-Control-Allow-Credentials"))
+	})
+
+	It("should answer preflight requests", func() {
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "OPTIONS",
+			Headers: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "PUT",
+			},
+		})
+		Expect(response.StatusCode).To(Equal(204))
+		Expect(response.Headers).To(HaveKeyWithValue("Access-Control-Allow-Methods", ContainSubstring("PUT")))
+		Expect(response.Headers).To(HaveKeyWithValue("Access-Control-Max-Age", "600"))
+	})
+})
+
+var _ = Describe("CORSConfigOf()", func() {
+	It("should override the defaults with the configuration", func() {
+		corsConfig := middleware.CORSConfigOf(config.CORS{
+			AllowedOrigins:   []string{"https://example.com"},
+			AllowedHeaders:   []string{"Content-Type"},
+			AllowCredentials: true,
+		})
+		Expect(corsConfig.AllowedOrigins).To(Equal([]string{"https://example.com"}))
+		Expect(corsConfig.AllowedMethods).To(Equal(middleware.DefaultCORSConfig.AllowedMethods))
+		Expect(corsConfig.AllowedHeaders).To(Equal([]string{"Content-Type"}))
+		Expect(corsConfig.ExposedHeaders).To(Equal(middleware.DefaultCORSConfig.ExposedHeaders))
+		Expect(corsConfig.AllowCredentials).To(BeTrue())
+	})
+})
+
+var _ = Describe("Authenticated()", func() {
+	var (
+		fakeNamespaceGetter servicefakes.FakeNamespaceGetter
+		handler             middleware.Handler
+		calledNamespace     *service.Namespace
+	)
+
+	BeforeEach(func() {
+		fakeNamespaceGetter = servicefakes.FakeNamespaceGetter{}
+		calledNamespace = nil
+		handler = middleware.Chain(middleware.Authenticated(&fakeNamespaceGetter, func(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error) {
+			calledNamespace = &ns
+			return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+		}), middleware.ErrorMapping())
+	})
+
+	It("should call the handler with the namespace of the path", func() {
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: "team-a"}, nil)
+
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{PathParameters: map[string]string{"namespace": "team-a"}})
+		Expect(response.StatusCode).To(Equal(200))
+		Expect(fakeNamespaceGetter.GetNamespaceArgsForCall(0)).To(Equal("team-a"))
+		Expect(calledNamespace.Name).To(Equal("team-a"))
+	})
+
+	It("should return 404 if the namespace does not exist", func() {
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{}, service.NamespaceNotFoundError{Namespace: "team-a"})
+
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(response.StatusCode).To(Equal(404))
+		Expect(calledNamespace).To(BeNil())
+	})
+
+	It("should return 403 if the authentication method is not allowed", func() {
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{AuthMethods: []string{auth.MethodIAM}}, nil)
+
+		response, _ := handler(context.Background(), events.APIGatewayProxyRequest{})
+		Expect(response.StatusCode).To(Equal(403))
+		Expect(response.Body).To(ContainSubstring(problem.TypeAuthMethodNotAllowed))
+		Expect(calledNamespace).To(BeNil())
+	})
+
+	It("should return 403 if the principal is not allowed", func() {
+		fakeNamespaceGetter.GetNamespaceReturns(service.Namespace{Name: "team-a", Principals: []string{"key-a"}}, nil)
+
+		request := events.APIGatewayProxyRequest{}
+		request.RequestContext.Identity = events.APIGatewayRequestIdentity{APIKey: "key", APIKeyID: "key-b"}
+		response, _ := handler(context.Background(), request)
+		Expect(response.StatusCode).To(Equal(403))
+		Expect(response.Body).To(ContainSubstring(problem.TypePrincipalNotAllowed))
+		Expect(calledNamespace).To(BeNil())
+
+		request.RequestContext.Identity.APIKeyID = "key-a"
+		response, _ = handler(context.Background(), request)
+		Expect(response.StatusCode).To(Equal(200))
+		Expect(calledNamespace.Name).To(Equal("team-a"))
+	})
+})
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+
+	"simple-information-store-app/internal/ratelimit"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RateLimit returns a middleware which answers requests exceeding the rate
+// limit of their route with 429.
+func RateLimit(limiter ratelimit.Limiter, limits ratelimit.Limits) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			if response, limited := ratelimit.Apply(ctx, limiter, limits, request); limited {
+				return response, nil
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"simple-information-store-app/internal/problem"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Recovery returns a middleware which turns panics of the handler into 500.
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (response events.APIGatewayProxyResponse, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("Panic when handling %s %s: %v\n%s", request.HTTPMethod, request.Path, r, debug.Stack())
+					response = problem.ProblemResponse(request, problem.New(500, problem.TypeInternalError, "Internal error", ""))
+					err = nil
+				}
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+
+	"simple-information-store-app/internal/helper"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header which carries the request id.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID returns a middleware which makes sure that the request has an id
+// and returns it in the X-Request-Id header. The id assigned by API Gateway
+// is used, otherwise the id sent by the client, otherwise a new one. Inner
+// handlers find it in request.RequestContext.RequestID.
+func RequestID() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			id := request.RequestContext.RequestID
+			if id == "" {
+				id = helper.GetHeader(request.Headers, RequestIDHeader)
+			}
+
+			if id == "" {
+				id = uuid.New().String()
+			}
+
+			request.RequestContext.RequestID = id
+			response, err := next(ctx, request)
+			setHeader(&response, RequestIDHeader, id)
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ContentType is the media type of problem details (RFC 7807).
+const ContentType = "application/problem+json"
+
+// TypePrefix is the prefix of the type URIs of all problems. Type URIs are
+// stable and may be used by clients to identify problems.
+const TypePrefix = "/problems/"
+
+// Types of problems.
+const (
+	TypeInvalidBody          = TypePrefix + "invalid-body"
+	TypeInvalidID            = TypePrefix + "invalid-id"
+	TypeValueTooLong         = TypePrefix + "value-too-long"
+	TypeIDMismatch           = TypePrefix + "id-mismatch"
+	TypeAuthMethodNotAllowed = TypePrefix + "auth-method-not-allowed"
+	TypePrincipalNotAllowed  = TypePrefix + "principal-not-allowed"
+	TypeNotOwner             = TypePrefix + "not-owner"
+	TypeQuotaExceeded        = TypePrefix + "quota-exceeded"
+	TypeNamespaceNotFound    = TypePrefix + "namespace-not-found"
+	TypeInfoNotFound         = TypePrefix + "info-not-found"
+	TypeNotAcceptable        = TypePrefix + "not-acceptable"
+	TypeInfoAlreadyExists    = TypePrefix + "info-already-exists"
+	TypeRangeNotSatisfiable  = TypePrefix + "range-not-satisfiable"
+	TypeRateLimited          = TypePrefix + "rate-limited"
+	TypeRouteNotFound        = TypePrefix + "route-not-found"
+	TypeMethodNotAllowed     = TypePrefix + "method-not-allowed"
+	TypeInternalError        = TypePrefix + "internal-error"
+	TypeServiceUnavailable   = TypePrefix + "service-unavailable"
+	TypeConflict             = TypePrefix + "conflict"
+	TypeInvalidRequest       = TypePrefix + "invalid-request"
+)
+
+// Problem presents the details of an error returned to clients (RFC 7807).
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the path of the request which caused the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// RequestID is the id of the request assigned by API Gateway.
+	RequestID string `json:"requestId,omitempty"`
+
+	// Extension members of the problems of the service, so that clients need
+	// not parse the detail.
+	AllowedLen int    `json:"allowedLen,omitempty"`
+	ActualLen  int    `json:"actualLen,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	Quota      string `json:"quota,omitempty"`
+	Limit      int64  `json:"limit,omitempty"`
+}
+
+// Error implements error, so that handlers can return problems which are not
+// caused by the service, e.g. 406, like any other error.
+func (p Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+
+	return p.Title
+}
+
+// New returns a problem of the type.
+func New(status int, problemType, title, detail string) Problem {
+	return Problem{
+		Type:   problemType,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// FromError returns the problem of the error. Other errors are presented by
+// their class: conflicts as 409, requests DynamoDB rejects as 400, transient
+// errors as 503 and fatal ones as internal errors, whose details are not
+// disclosed.
+func FromError(err error) Problem {
+	var (
+		p                 Problem
+		valueTooLong      service.ValueTooLongError
+		invalidID         service.InvalidIDError
+		infoNotFound      service.InfoNotFoundError
+		infoAlreadyExists service.InfoAlreadyExistsError
+		notOwner          service.NotOwnerError
+		quotaExceeded     service.QuotaExceededError
+		namespaceNotFound service.NamespaceNotFoundError
+		invalidEnvelope   content.InvalidEnvelopeError
+		invalidAttributes content.InvalidAttributesError
+	)
+
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.As(err, &valueTooLong):
+		p = New(400, TypeValueTooLong, "Value too long", err.Error())
+		p.AllowedLen, p.ActualLen = valueTooLong.AllowedLen, valueTooLong.ActualLen
+		return p
+	case errors.As(err, &invalidID):
+		p = New(400, TypeInvalidID, "Invalid id", err.Error())
+		p.Reason = invalidID.Reason
+		return p
+	case errors.As(err, &invalidEnvelope):
+		return New(400, TypeInvalidBody, "Invalid request body", err.Error())
+	case errors.As(err, &invalidAttributes):
+		return New(400, TypeInvalidRequest, "Invalid request", err.Error())
+	case errors.As(err, &quotaExceeded):
+		p = New(403, TypeQuotaExceeded, "Quota exceeded", err.Error())
+		p.Namespace, p.Owner = quotaExceeded.Namespace, quotaExceeded.Owner
+		p.Quota, p.Limit = quotaExceeded.Quota, quotaExceeded.Limit
+		return p
+	case errors.As(err, &notOwner):
+		return New(403, TypeNotOwner, "Not owner", err.Error())
+	case errors.As(err, &namespaceNotFound):
+		p = New(404, TypeNamespaceNotFound, "Namespace not found", err.Error())
+		p.Namespace = namespaceNotFound.Namespace
+		return p
+	case errors.As(err, &infoNotFound):
+		return New(404, TypeInfoNotFound, "Info not found", err.Error())
+	case errors.As(err, &infoAlreadyExists):
+		return New(409, TypeInfoAlreadyExists, "Info already exists", err.Error())
+	}
+
+	switch service.ClassOf(err) {
+	case service.ClassConflict:
+		return New(409, TypeConflict, "Conflict", "The info is modified concurrently. Please retry.")
+	case service.ClassValidation:
+		return New(400, TypeInvalidRequest, "Invalid request", "The request is rejected by the storage.")
+	case service.ClassTransient:
+		return New(503, TypeServiceUnavailable, "Service unavailable", "The storage is busy. Please retry later.")
+	default:
+		return New(500, TypeInternalError, "Internal error", "")
+	}
+}
+
+// Response returns the problem+json response of the error for the request.
+// Errors of the storage are logged with their class. Responses with 503 ask
+// clients to retry after a while with Retry-After.
+func Response(request events.APIGatewayProxyRequest, err error) events.APIGatewayProxyResponse {
+	p := FromError(err)
+	if p.Status >= 500 || p.Type == TypeInvalidRequest {
+		fmt.Printf("Error when handling %s %s (%s): %s\n", request.HTTPMethod, request.Path, service.ClassOf(err), err.Error())
+	}
+
+	response := ProblemResponse(request, p)
+	if p.Status == 503 {
+		response.Headers["Retry-After"] = strconv.Itoa(retryAfterSeconds(err))
+	}
+
+	return response
+}
+
+// retryAfterSeconds returns the seconds clients should wait before retrying
+// the request which failed with the error, at least 1.
+func retryAfterSeconds(err error) int {
+	var unavailable service.UnavailableError
+	if errors.As(err, &unavailable) && unavailable.RetryAfter > time.Second {
+		return int(math.Ceil(unavailable.RetryAfter.Seconds()))
+	}
+
+	return 1
+}
+
+// ProblemResponse returns the problem+json response of the problem for the
+// request.
+func ProblemResponse(request events.APIGatewayProxyRequest, p Problem) events.APIGatewayProxyResponse {
+	p.Instance = request.Path
+	p.RequestID = request.RequestContext.RequestID
+	body, _ := json.Marshal(p)
+	return events.APIGatewayProxyResponse{
+		StatusCode: p.Status,
+		Headers: map[string]string{
+			"Content-Type": ContentType,
+		},
+		Body: string(body),
+	}
+}
+
+// AuthMethodNotAllowed returns the problem of a request whose authentication
+// method is not allowed by the namespace.
+func AuthMethodNotAllowed(namespace string) Problem {
+	if namespace == "" {
+		namespace = service.DefaultNamespace
+	}
+
+	p := New(403, TypeAuthMethodNotAllowed, "Authentication method not allowed",
+		fmt.Sprintf("The authentication method is not allowed in namespace %s.", namespace))
+	p.Namespace = namespace
+	return p
+}
+
+// PrincipalNotAllowed returns the problem of a request whose principal is
+// not allowed by the namespace.
+func PrincipalNotAllowed(namespace string) Problem {
+	if namespace == "" {
+		namespace = service.DefaultNamespace
+	}
+
+	p := New(403, TypePrincipalNotAllowed, "Principal not allowed",
+		fmt.Sprintf("The principal is not allowed in namespace %s.", namespace))
+	p.Namespace = namespace
+	return p
+}
+
+// IDMismatch returns the problem of a request body whose id differs from the
+// id in the path.
+func IDMismatch(pathID, bodyID string) Problem {
+	return New(400, TypeIDMismatch, "Id mismatch",
+		fmt.Sprintf("Id %s in the body does not match id %s in the path.", bodyID, pathID))
+}
+
+// NotAcceptable returns the problem of a request which does not accept any of
+// the offered media types.
+func NotAcceptable(offers ...string) Problem {
+	return New(406, TypeNotAcceptable, "Not acceptable",
+		fmt.Sprintf("Supported media types are %s.", strings.Join(offers, ", ")))
+}
+
+// RouteNotFound returns the problem of a request whose path matches no route.
+func RouteNotFound(path string) Problem {
+	return New(404, TypeRouteNotFound, "Route not found",
+		fmt.Sprintf("No route matches path %s.", path))
+}
+
+// MethodNotAllowed returns the problem of a request whose path matches routes
+// of other methods only.
+func MethodNotAllowed(method string, allowed ...string) Problem {
+	return New(405, TypeMethodNotAllowed, "Method not allowed",
+		fmt.Sprintf("Method %s is not allowed. Allowed methods are %s.", method, strings.Join(allowed, ", ")))
+}
+
+// ServiceUnavailable returns the problem of a request which could not be
+// handled before the deadline of the invocation.
+func ServiceUnavailable() Problem {
+	return New(503, TypeServiceUnavailable, "Service unavailable",
+		"The request could not be handled in time. Please retry later.")
+}
@@ -0,0 +1,13 @@
+package problem_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestProblem(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Problem Suite")
+}
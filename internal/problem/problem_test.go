@@ -0,0 +1,128 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/service"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FromError()", func() {
+	DescribeTable("service errors",
+		func(err error, status int, problemType string) {
+			p := problem.FromError(err)
+			Expect(p.Status).To(Equal(status))
+			Expect(p.Type).To(Equal(problemType))
+			Expect(p.Title).NotTo(BeEmpty())
+			Expect(p.Detail).To(Equal(err.Error()))
+		},
+		Entry("ValueTooLongError", service.ValueTooLongError{AllowedLen: 1, ActualLen: 2}, 400, problem.TypeValueTooLong),
+		Entry("InvalidIDError", service.InvalidIDError{InfoID: "a//b", Reason: "reason"}, 400, problem.TypeInvalidID),
+		Entry("InvalidEnvelopeError", content.InvalidEnvelopeError{Reason: "reason"}, 400, problem.TypeInvalidBody),
+		Entry("QuotaExceededError", service.QuotaExceededError{Namespace: "a", Quota: service.QuotaItems, Limit: 1}, 403, problem.TypeQuotaExceeded),
+		Entry("NotOwnerError", service.NotOwnerError{InfoID: "a"}, 403, problem.TypeNotOwner),
+		Entry("NamespaceNotFoundError", service.NamespaceNotFoundError{Namespace: "a"}, 404, problem.TypeNamespaceNotFound),
+		Entry("InfoNotFoundError", service.InfoNotFoundError{InfoID: "a"}, 404, problem.TypeInfoNotFound),
+		Entry("InfoAlreadyExistsError", service.InfoAlreadyExistsError{InfoID: "a"}, 409, problem.TypeInfoAlreadyExists),
+		Entry("wrapped error", fmt.Errorf("wrapped: %w", service.InfoNotFoundError{InfoID: "a"}), 404, problem.TypeInfoNotFound),
+	)
+
+	It("should have the fields of errors as extension members", func() {
+		p := problem.FromError(service.ValueTooLongError{AllowedLen: 1, ActualLen: 2})
+		Expect(p.AllowedLen).To(Equal(1))
+		Expect(p.ActualLen).To(Equal(2))
+
+		p = problem.FromError(service.QuotaExceededError{Namespace: "a", Owner: "b", Quota: service.QuotaItems, Limit: 1})
+		Expect(p.Namespace).To(Equal("a"))
+		Expect(p.Owner).To(Equal("b"))
+		Expect(p.Quota).To(Equal(service.QuotaItems))
+		Expect(p.Limit).To(Equal(int64(1)))
+
+		p = problem.FromError(service.InvalidIDError{InfoID: "a//b", Reason: "reason"})
+		Expect(p.Reason).To(Equal("reason"))
+
+		p = problem.FromError(service.NamespaceNotFoundError{Namespace: "a"})
+		Expect(p.Namespace).To(Equal("a"))
+
+		body, err := json.Marshal(problem.PrincipalNotAllowed("a"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring(`"namespace":"a"`))
+	})
+
+	It("should return problems as they are", func() {
+		p := problem.NotAcceptable(content.TypeText)
+		Expect(problem.FromError(p)).To(Equal(p))
+	})
+
+	DescribeTable("errors of the storage",
+		func(err error, status int, problemType string) {
+			p := problem.FromError(err)
+			Expect(p.Status).To(Equal(status))
+			Expect(p.Type).To(Equal(problemType))
+			Expect(p.Detail).NotTo(ContainSubstring("secret"))
+		},
+		Entry("transient", awserr.New(dynamodb.ErrCodeInternalServerError, "secret", nil), 503, problem.TypeServiceUnavailable),
+		Entry("throttled", service.UnavailableError{Err: awserr.New("ThrottlingException", "secret", nil)}, 503, problem.TypeServiceUnavailable),
+		Entry("conflict", awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "secret", nil), 409, problem.TypeConflict),
+		Entry("validation", awserr.New("ValidationException", "secret", nil), 400, problem.TypeInvalidRequest),
+		Entry("fatal", awserr.New(dynamodb.ErrCodeResourceNotFoundException, "secret", nil), 500, problem.TypeInternalError),
+	)
+
+	It("should not disclose unknown errors", func() {
+		p := problem.FromError(errors.New("secret"))
+		Expect(p.Status).To(Equal(500))
+		Expect(p.Type).To(Equal(problem.TypeInternalError))
+		Expect(p.Detail).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Response()", func() {
+	It("should return the problem as problem+json with the request id", func() {
+		response := problem.Response(events.APIGatewayProxyRequest{
+			Path: "/i/a",
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: "request-id",
+			},
+		}, service.InfoNotFoundError{InfoID: "a"})
+
+		Expect(response.StatusCode).To(Equal(404))
+		Expect(response.Headers).To(Equal(map[string]string{"Content-Type": problem.ContentType}))
+
+		body := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(response.Body), &body)).To(Succeed())
+		Expect(body).To(Equal(map[string]interface{}{
+			"type":      problem.TypeInfoNotFound,
+			"title":     "Info not found",
+			"status":    float64(404),
+			"detail":    service.InfoNotFoundError{InfoID: "a"}.Error(),
+			"instance":  "/i/a",
+			"requestId": "request-id",
+		}))
+	})
+
+	It("should ask to retry after the time of UnavailableError", func() {
+		response := problem.Response(events.APIGatewayProxyRequest{}, service.UnavailableError{
+			RetryAfter: 1500 * time.Millisecond,
+			Err:        awserr.New("ThrottlingException", "throttled", nil),
+		})
+
+		Expect(response.StatusCode).To(Equal(503))
+		Expect(response.Headers).To(HaveKeyWithValue("Retry-After", "2"))
+	})
+
+	It("should ask to retry after a second by default", func() {
+		response := problem.Response(events.APIGatewayProxyRequest{}, problem.ServiceUnavailable())
+		Expect(response.StatusCode).To(Equal(503))
+		Expect(response.Headers).To(HaveKeyWithValue("Retry-After", "1"))
+	})
+})
@@ -0,0 +1,21 @@
+// Package problemtest provides helpers for tests of handlers which respond
+// with problems.
+package problemtest
+
+import (
+	"encoding/json"
+
+	"simple-information-store-app/internal/problem"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/gomega"
+)
+
+// Of returns the problem in the body of the response. It fails the running
+// spec if the response is not a problem+json response.
+func Of(response events.APIGatewayProxyResponse) problem.Problem {
+	var p problem.Problem
+	ExpectWithOffset(1, response.Headers).To(HaveKeyWithValue("Content-Type", problem.ContentType))
+	ExpectWithOffset(1, json.Unmarshal([]byte(response.Body), &p)).To(Succeed())
+	return p
+}
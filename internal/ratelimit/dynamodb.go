@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/helper/awshelper"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// keyPrefix is the prefix of storage keys of buckets in the value table.
+const keyPrefix = service.SystemKeyPrefix + "ratelimit" + service.NamespaceSeparator
+
+type dynamoDbLimiter struct {
+	dynamoDbClient *dynamodb.DynamoDB
+	valueTableName string
+}
+
+// NewDynamoDbLimiter returns a limiter which keeps the buckets in the value
+// table, so that they are shared by all Lambda containers. A bucket is stored
+// as the time it is full again, its theoretical arrival time (GCRA), which is
+// advanced by atomic conditional updates, so that concurrent requests never
+// overwrite each other's tokens. It is removed by DynamoDB TTL once the bucket
+// is full.
+func NewDynamoDbLimiter(c config.Config) Limiter {
+	return dynamoDbLimiter{
+		dynamoDbClient: awshelper.GetDynamoDbClient(c.DynamoDbEndpoint, c.Region),
+		valueTableName: c.ValueTableName,
+	}
+}
+
+func (l dynamoDbLimiter) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	storageKey := keyPrefix + key
+	interval := intervalOf(limit)
+
+	// A full bucket starts over at now. Otherwise a token is taken by adding
+	// the interval, unless the bucket is empty.
+	tat, err := l.update(ctx, storageKey, "SET Tat = :next, ExpiresAt = :expiresAt",
+		"attribute_not_exists(Tat) OR Tat <= :now", map[string]*dynamodb.AttributeValue{
+			":next":      nanosAttr(now.Add(interval)),
+			":now":       nanosAttr(now),
+			":expiresAt": expiresAtAttr(now.Add(interval)),
+		})
+
+	if isConditionalCheckFailed(err) {
+		tat, err = l.update(ctx, storageKey, "ADD Tat :interval SET ExpiresAt = :expiresAt",
+			"Tat <= :max", map[string]*dynamodb.AttributeValue{
+				":interval":  {N: helper.StringPtr(strconv.FormatInt(int64(interval), 10))},
+				":max":       nanosAttr(now.Add(burstWindowOf(limit) - interval)),
+				":expiresAt": expiresAtAttr(now.Add(burstWindowOf(limit))),
+			})
+	}
+
+	if isConditionalCheckFailed(err) {
+		return l.denied(ctx, storageKey, limit, now), nil
+	}
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	return resultOfTat(tat, limit, now, true), nil
+}
+
+// update updates the bucket with the storage key and returns its new
+// theoretical arrival time.
+func (l dynamoDbLimiter) update(ctx context.Context, storageKey string, expression, condition string, values map[string]*dynamodb.AttributeValue) (time.Time, error) {
+	output, err := l.dynamoDbClient.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: &l.valueTableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			"Id": {S: &storageKey},
+		},
+		UpdateExpression:          &expression,
+		ConditionExpression:       &condition,
+		ExpressionAttributeValues: values,
+		ReturnValues:              helper.StringPtr(dynamodb.ReturnValueUpdatedNew),
+	})
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return tatOf(output.Attributes), nil
+}
+
+// denied returns the result of a request over the limit. The bucket is read
+// to tell the client when to retry, which is estimated if reading fails.
+func (l dynamoDbLimiter) denied(ctx context.Context, storageKey string, limit Limit, now time.Time) Result {
+	tat := now.Add(burstWindowOf(limit))
+	output, err := l.dynamoDbClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: &l.valueTableName,
+		Key: map[string]*dynamodb.AttributeValue{
+			"Id": {S: &storageKey},
+		},
+		ConsistentRead: helper.BoolPtr(true),
+	})
+
+	if err == nil && output.Item != nil {
+		tat = tatOf(output.Item)
+	}
+
+	return resultOfTat(tat, limit, now, false)
+}
+
+// resultOfTat returns the result of taking a token from a bucket whose
+// theoretical arrival time is tat after taking it, or before the token was
+// denied.
+func resultOfTat(tat time.Time, limit Limit, now time.Time, allowed bool) Result {
+	interval := intervalOf(limit)
+	result := Result{
+		Allowed: allowed,
+		Limit:   limit.Burst,
+		Reset:   tat.Sub(now),
+	}
+
+	if allowed {
+		remaining := float64(now.Add(burstWindowOf(limit)).Sub(tat)) / float64(interval)
+		result.Remaining = int(math.Max(0, math.Floor(remaining+1e-9)))
+	} else {
+		result.RetryAfter = tat.Add(interval).Sub(now.Add(burstWindowOf(limit)))
+	}
+
+	if result.Reset < 0 {
+		result.Reset = 0
+	}
+
+	if result.RetryAfter < 0 {
+		result.RetryAfter = 0
+	}
+
+	return result
+}
+
+// intervalOf returns the time in which one token is added to the bucket.
+func intervalOf(limit Limit) time.Duration {
+	return secondsToDuration(1 / limit.Rate)
+}
+
+// burstWindowOf returns the time in which an empty bucket is filled.
+func burstWindowOf(limit Limit) time.Duration {
+	return time.Duration(limit.Burst) * intervalOf(limit)
+}
+
+func tatOf(item map[string]*dynamodb.AttributeValue) time.Time {
+	attr, ok := item["Tat"]
+	if !ok || attr.N == nil {
+		return time.Time{}
+	}
+
+	nanos, _ := strconv.ParseInt(*attr.N, 10, 64)
+	return time.Unix(0, nanos)
+}
+
+func nanosAttr(t time.Time) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: helper.StringPtr(strconv.FormatInt(t.UnixNano(), 10))}
+}
+
+// expiresAtAttr returns the TTL of a bucket which is full at the time. It is
+// kept a minute longer, since DynamoDB deletes expired items with a delay
+// anyway.
+func expiresAtAttr(fullAt time.Time) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: helper.StringPtr(strconv.FormatInt(fullAt.Add(time.Minute).Unix(), 10))}
+}
+
+func isConditionalCheckFailed(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("memoryLimiter", func() {
+	var (
+		limiter *memoryLimiter
+		limit   Limit
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		limiter = NewMemoryLimiter().(*memoryLimiter)
+		limit = Limit{Rate: 1, Burst: 2}
+		now = time.Unix(1600000000, 0)
+	})
+
+	It("should evict buckets which are full again", func() {
+		limiter.Take(context.Background(), "key", limit, now)
+		limiter.Take(context.Background(), "other-key", limit, now.Add(sweepInterval-time.Second))
+		Expect(limiter.buckets).To(HaveLen(2))
+
+		limiter.Take(context.Background(), "other-key", limit, now.Add(sweepInterval))
+		Expect(limiter.buckets).To(HaveLen(1))
+		Expect(limiter.buckets).To(HaveKey("other-key"))
+	})
+
+	It("should keep buckets which are not full", func() {
+		limit = Limit{Rate: 0.01, Burst: 2}
+		limiter.Take(context.Background(), "key", limit, now)
+
+		limiter.Take(context.Background(), "other-key", limit, now.Add(sweepInterval))
+		Expect(limiter.buckets).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("resultOfTat()", func() {
+	var (
+		limit Limit
+		now   time.Time
+	)
+
+	BeforeEach(func() {
+		limit = Limit{Rate: 1, Burst: 2}
+		now = time.Unix(1600000000, 0)
+	})
+
+	It("should return the remaining tokens of an allowed request", func() {
+		result := resultOfTat(now.Add(time.Second), limit, now, true)
+		Expect(result).To(Equal(Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}))
+
+		result = resultOfTat(now.Add(2*time.Second), limit, now, true)
+		Expect(result).To(Equal(Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}))
+	})
+
+	It("should return when to retry a denied request", func() {
+		result := resultOfTat(now.Add(2*time.Second), limit, now, false)
+		Expect(result).To(Equal(Result{Limit: 2, RetryAfter: time.Second, Reset: 2 * time.Second}))
+
+		result = resultOfTat(now.Add(1500*time.Millisecond), limit, now, false)
+		Expect(result.RetryAfter).To(Equal(500 * time.Millisecond))
+	})
+})
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the min. time between two sweeps of full buckets.
+const sweepInterval = time.Minute
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+	sweptAt time.Time
+}
+
+// memoryBucket is a bucket and the time it is full again.
+type memoryBucket struct {
+	bucket
+	fullAt time.Time
+}
+
+// NewMemoryLimiter returns a limiter which keeps the buckets in memory. It is
+// only suitable for a single process, e.g. the standalone server. Full buckets
+// are removed, since they are the same as absent ones, so that the memory is
+// bounded by the clients seen within the reset time.
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{
+		buckets: make(map[string]memoryBucket),
+	}
+}
+
+func (l *memoryLimiter) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, result := take(l.buckets[key].bucket, limit, now)
+	l.buckets[key] = memoryBucket{bucket: b, fullAt: now.Add(result.Reset)}
+	return result, nil
+}
+
+// sweep removes the buckets which are full at now, at most once per sweep interval.
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < sweepInterval {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if !now.Before(b.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.sweptAt = now
+}
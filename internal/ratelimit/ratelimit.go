@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultRoute is the route key of the limit applied to routes without their own limit.
+const DefaultRoute = "*"
+
+// Limit is the configuration of a token bucket.
+type Limit struct {
+	// Rate is the number of tokens added to the bucket per second.
+	Rate float64 `json:"rate"`
+
+	// Burst is the capacity of the bucket.
+	Burst int `json:"burst"`
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// RetryAfter is the time until a token is available, 0 if allowed.
+	RetryAfter time.Duration
+
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+type Limiter interface {
+	// Take takes a token from the bucket with the key.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// bucket is the state of a token bucket.
+type bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// take refills the bucket since its last update and takes a token if available.
+// A zero bucket is full.
+func take(b bucket, limit Limit, now time.Time) (bucket, Result) {
+	burst := float64(limit.Burst)
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*limit.Rate)
+	}
+	b.UpdatedAt = now
+
+	result := Result{
+		Limit: limit.Burst,
+	}
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.Tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(math.Floor(b.Tokens))
+	result.Reset = secondsToDuration((burst - b.Tokens) / limit.Rate)
+	return b, result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// routeOf returns the route key of the request, e.g. "POST /i".
+func routeOf(request events.APIGatewayProxyRequest) string {
+	return request.HTTPMethod + " " + request.Resource
+}
+
+// clientOf returns the client key of the request, i.e. the API key if
+// authenticated with one, otherwise the source IP.
+func clientOf(request events.APIGatewayProxyRequest) string {
+	if auth.MethodOf(request) == auth.MethodAPIKey {
+		return "key:" + auth.OwnerOf(request)
+	}
+
+	return "ip:" + request.RequestContext.Identity.SourceIP
+}
+
+// Limits are the limits by route key, e.g. "POST /i", or DefaultRoute.
+type Limits map[string]Limit
+
+// LimitsOf returns the rate limits of the configuration.
+func LimitsOf(c config.Config) Limits {
+	limits := make(Limits, len(c.RateLimits))
+	for route, limit := range c.RateLimits {
+		limits[route] = Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+
+	return limits
+}
+
+// of returns the limit of the route, or false if the route is not limited.
+func (limits Limits) of(route string) (Limit, bool) {
+	limit, ok := limits[route]
+	if !ok {
+		limit, ok = limits[DefaultRoute]
+	}
+
+	return limit, ok
+}
+
+// Apply takes a token for the client of the request from the bucket of its
+// route of the limits. It returns a 429 response and true if the request is over the limit.
+// If the limiter is throttled or unavailable, it returns a 503 response and
+// true, since the limit cannot be enforced. Requests are allowed if the
+// limiter fails otherwise, so that a bug of the limiter does not take down the API.
+func Apply(ctx context.Context, limiter Limiter, limits Limits, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, bool) {
+	route := routeOf(request)
+	limit, ok := limits.of(route)
+	if !ok {
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	result, err := limiter.Take(ctx, route+" "+clientOf(request), limit, time.Now())
+	if err != nil && service.ClassOf(err) == service.ClassTransient {
+		return problem.Response(request, err), true
+	}
+
+	if err != nil {
+		fmt.Printf("Error when taking rate limit token: %s\n", err.Error())
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	if result.Allowed {
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	response := problem.ProblemResponse(request, problem.New(429, problem.TypeRateLimited, "Too many requests",
+		fmt.Sprintf("The rate limit of %s is exceeded.", route)))
+	for k, v := range Headers(result) {
+		response.Headers[k] = v
+	}
+
+	return response, true
+}
+
+// Headers returns the Retry-After and X-RateLimit-* headers of the result.
+func Headers(result Result) map[string]string {
+	headers := map[string]string{
+		"X-RateLimit-Limit":     strconv.Itoa(result.Limit),
+		"X-RateLimit-Remaining": strconv.Itoa(result.Remaining),
+		"X-RateLimit-Reset":     strconv.Itoa(ceilSeconds(result.Reset)),
+	}
+
+	if !result.Allowed {
+		headers["Retry-After"] = strconv.Itoa(ceilSeconds(result.RetryAfter))
+	}
+
+	return headers
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
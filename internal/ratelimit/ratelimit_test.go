@@ -0,0 +1,201 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/ratelimit"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryLimiter", func() {
+	var (
+		limiter ratelimit.Limiter
+		limit   ratelimit.Limit
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		limiter = ratelimit.NewMemoryLimiter()
+		limit = ratelimit.Limit{Rate: 1, Burst: 2}
+		now = time.Unix(1600000000, 0)
+	})
+
+	It("should allow a burst and then limit", func() {
+		result, err := limiter.Take(context.Background(), "key", limit, now)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.Allowed).To(BeTrue())
+		Expect(result.Limit).To(Equal(2))
+		Expect(result.Remaining).To(Equal(1))
+
+		result, _ = limiter.Take(context.Background(), "key", limit, now)
+		Expect(result.Allowed).To(BeTrue())
+		Expect(result.Remaining).To(Equal(0))
+
+		result, _ = limiter.Take(context.Background(), "key", limit, now)
+		Expect(result.Allowed).To(BeFalse())
+		Expect(result.RetryAfter).To(Equal(time.Second))
+		Expect(result.Reset).To(Equal(2 * time.Second))
+	})
+
+	It("should refill tokens over time", func() {
+		limiter.Take(context.Background(), "key", limit, now)
+		limiter.Take(context.Background(), "key", limit, now)
+
+		result, _ := limiter.Take(context.Background(), "key", limit, now.Add(1500*time.Millisecond))
+		Expect(result.Allowed).To(BeTrue())
+		Expect(result.Remaining).To(Equal(0))
+		Expect(result.Reset).To(Equal(1500 * time.Millisecond))
+	})
+
+	It("should not refill more than the burst", func() {
+		limiter.Take(context.Background(), "key", limit, now)
+
+		result, _ := limiter.Take(context.Background(), "key", limit, now.Add(time.Hour))
+		Expect(result.Remaining).To(Equal(1))
+	})
+
+	It("should keep buckets of different keys apart", func() {
+		limiter.Take(context.Background(), "key", limit, now)
+		limiter.Take(context.Background(), "key", limit, now)
+
+		result, _ := limiter.Take(context.Background(), "other-key", limit, now)
+		Expect(result.Allowed).To(BeTrue())
+	})
+})
+
+var _ = Describe("Apply()", func() {
+	var (
+		limiter  ratelimit.Limiter
+		limits   ratelimit.Limits
+		request  events.APIGatewayProxyRequest
+		response events.APIGatewayProxyResponse
+		limited  bool
+	)
+
+	BeforeEach(func() {
+		limiter = ratelimit.NewMemoryLimiter()
+		request = events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Resource:   "/i",
+		}
+		request.RequestContext.Identity.SourceIP = "192.0.2.1"
+		limits = ratelimit.Limits{"POST /i": {Rate: 0.5, Burst: 1}}
+	})
+
+	JustBeforeEach(func() {
+		ratelimit.Apply(context.Background(), limiter, limits, request)
+		response, limited = ratelimit.Apply(context.Background(), limiter, limits, request)
+	})
+
+	When("the client exceeds the limit of the route", func() {
+		It("should return 429 with rate limit headers", func() {
+			Expect(limited).To(BeTrue())
+			Expect(response.StatusCode).To(Equal(429))
+			Expect(response.Headers).To(Equal(map[string]string{
+				"Content-Type":          problem.ContentType,
+				"Retry-After":           "2",
+				"X-RateLimit-Limit":     "1",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "2",
+			}))
+			Expect(response.Body).To(ContainSubstring(problem.TypeRateLimited))
+		})
+	})
+
+	When("another client sends a request", func() {
+		JustBeforeEach(func() {
+			request.RequestContext.Identity.SourceIP = "192.0.2.2"
+			response, limited = ratelimit.Apply(context.Background(), limiter, limits, request)
+		})
+
+		It("should not limit it", func() {
+			Expect(limited).To(BeFalse())
+		})
+	})
+
+	When("the client uses an API key", func() {
+		BeforeEach(func() {
+			request.RequestContext.Identity.APIKey = "key"
+			request.RequestContext.Identity.APIKeyID = "key-id"
+		})
+
+		JustBeforeEach(func() {
+			request.RequestContext.Identity.SourceIP = "192.0.2.2"
+			response, limited = ratelimit.Apply(context.Background(), limiter, limits, request)
+		})
+
+		It("should limit by API key regardless of the source IP", func() {
+			Expect(limited).To(BeTrue())
+		})
+	})
+
+	When("the route has no limit", func() {
+		BeforeEach(func() {
+			request.HTTPMethod = "GET"
+			request.Resource = "/i/{id+}"
+		})
+
+		It("should not limit it", func() {
+			Expect(limited).To(BeFalse())
+		})
+	})
+
+	When("a default limit is configured", func() {
+		BeforeEach(func() {
+			request.HTTPMethod = "GET"
+			request.Resource = "/i/{id+}"
+			limits = ratelimit.Limits{ratelimit.DefaultRoute: {Rate: 1, Burst: 1}}
+		})
+
+		It("should apply it to all routes", func() {
+			Expect(limited).To(BeTrue())
+		})
+	})
+
+	When("the limiter is throttled", func() {
+		BeforeEach(func() {
+			limiter = failingLimiter{awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "", nil)}
+		})
+
+		It("should return 503 with Retry-After", func() {
+			Expect(limited).To(BeTrue())
+			Expect(response.StatusCode).To(Equal(503))
+			Expect(response.Headers).To(HaveKey("Retry-After"))
+			Expect(response.Body).To(ContainSubstring(problem.TypeServiceUnavailable))
+		})
+	})
+
+	When("the limiter fails otherwise", func() {
+		BeforeEach(func() {
+			limiter = failingLimiter{errors.New("error")}
+		})
+
+		It("should not limit it", func() {
+			Expect(limited).To(BeFalse())
+		})
+	})
+})
+
+type failingLimiter struct {
+	err error
+}
+
+func (l failingLimiter) Take(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, l.err
+}
+
+var _ = Describe("LimitsOf()", func() {
+	It("should return the rate limits of the configuration", func() {
+		c := config.Default()
+		c.RateLimits = map[string]config.RateLimit{"POST /i": {Rate: 0.5, Burst: 1}}
+		Expect(ratelimit.LimitsOf(c)).To(Equal(ratelimit.Limits{"POST /i": {Rate: 0.5, Burst: 1}}))
+	})
+})
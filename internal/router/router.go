@@ -0,0 +1,175 @@
+// Package router dispatches API Gateway proxy requests in process by HTTP
+// method and resource path, so that all routes can be served by one Lambda
+// function.
+package router
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/problem"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Router dispatches requests to the handler of the route matching the
+// method and path of the request.
+type Router struct {
+	routes []route
+}
+
+// route is a handler of a method and a resource path pattern.
+type route struct {
+	method   string
+	resource string
+	segments []segment
+	handler  middleware.Handler
+}
+
+// segment is a segment of a resource path pattern. A segment is either a
+// literal or a path parameter in braces, e.g. {id}. A greedy path parameter,
+// e.g. {id+}, must be the last segment and matches the rest of the path.
+type segment struct {
+	literal string
+	param   string
+	greedy  bool
+}
+
+// New returns a router without routes.
+func New() *Router {
+	return &Router{}
+}
+
+// Handle registers the handler of the method and the resource path pattern,
+// e.g. /n/{namespace}/i/{id+}. Patterns have the syntax of API Gateway
+// resources. Routes are matched in the order they are registered.
+func (r *Router) Handle(method, resource string, handler middleware.Handler) {
+	r.routes = append(r.routes, route{
+		method:   method,
+		resource: resource,
+		segments: parsePattern(resource),
+		handler:  handler,
+	})
+}
+
+// Handler dispatches the request to the handler of the matching route. The
+// handler is called with the path parameters and the resource of the route
+// as API Gateway would set them. A request whose path matches no route is
+// answered with 404, one whose path matches routes of other methods only
+// with 405.
+func (r *Router) Handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var allowed []string
+	for _, rt := range r.routes {
+		params, ok := match(rt.segments, request.Path)
+		if !ok {
+			continue
+		}
+
+		if rt.method != request.HTTPMethod {
+			allowed = appendUnique(allowed, rt.method)
+			continue
+		}
+
+		request.Resource = rt.resource
+		request.PathParameters = params
+		return rt.handler(ctx, request)
+	}
+
+	if len(allowed) == 0 {
+		return events.APIGatewayProxyResponse{}, problem.RouteNotFound(request.Path)
+	}
+
+	sort.Strings(allowed)
+	response := problem.ProblemResponse(request, problem.MethodNotAllowed(request.HTTPMethod, allowed...))
+	response.Headers["Allow"] = strings.Join(allowed, ", ")
+	return response, nil
+}
+
+// parsePattern returns the segments of the resource path pattern.
+func parsePattern(resource string) []segment {
+	parts := splitPath(resource)
+	segments := make([]segment, len(parts))
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			segments[i] = segment{literal: part}
+			continue
+		}
+
+		param := strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+		if strings.HasSuffix(param, "+") {
+			if i != len(parts)-1 {
+				panic("router: greedy path parameter " + part + " is not the last segment of " + resource)
+			}
+
+			segments[i] = segment{param: strings.TrimSuffix(param, "+"), greedy: true}
+			continue
+		}
+
+		segments[i] = segment{param: param}
+	}
+
+	return segments
+}
+
+// match returns the path parameters if the path matches the segments.
+func match(segments []segment, path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	params := map[string]string{}
+	for i, s := range segments {
+		if i >= len(parts) {
+			return nil, false
+		}
+
+		switch {
+		case s.greedy:
+			rest := strings.Join(parts[i:], "/")
+			if rest == "" {
+				return nil, false
+			}
+
+			params[s.param] = rest
+			return params, true
+		case s.param != "":
+			if parts[i] == "" {
+				return nil, false
+			}
+
+			params[s.param] = parts[i]
+		case s.literal != parts[i]:
+			return nil, false
+		}
+	}
+
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+
+	if len(params) == 0 {
+		// API Gateway sets no path parameters on routes without any.
+		return nil, true
+	}
+
+	return params, true
+}
+
+// splitPath returns the segments of the path without the leading slash.
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
@@ -0,0 +1,116 @@
+package router_test
+
+import (
+	"context"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/problem/problemtest"
+	"simple-information-store-app/internal/router"
+
+	"github.com/aws/aws-lambda-go/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router", func() {
+	var (
+		r        *router.Router
+		received events.APIGatewayProxyRequest
+		called   string
+	)
+
+	handlerOf := func(name string) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			called = name
+			received = request
+			return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+		}
+	}
+
+	BeforeEach(func() {
+		called = ""
+		received = events.APIGatewayProxyRequest{}
+
+		r = router.New()
+		r.Handle("POST", "/i", handlerOf("create"))
+		r.Handle("POST", "/i/{id+}", handlerOf("create id"))
+		r.Handle("GET", "/i/{id+}", handlerOf("get"))
+		r.Handle("PUT", "/i/{id+}", handlerOf("update"))
+		r.Handle("GET", "/n/{namespace}/i/{id+}", handlerOf("get in namespace"))
+		r.Handle("GET", "/usage", handlerOf("usage"))
+	})
+
+	DescribeTable("matching routes",
+		func(method, path, expectedHandler, expectedResource string, expectedParameters map[string]string) {
+			response, err := r.Handler(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod: method,
+				Path:       path,
+				Resource:   "/{proxy+}",
+				PathParameters: map[string]string{
+					"proxy": path,
+				},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(200))
+			Expect(called).To(Equal(expectedHandler))
+			Expect(received.Resource).To(Equal(expectedResource))
+			Expect(received.PathParameters).To(Equal(expectedParameters))
+		},
+		Entry("route without parameters", "POST", "/i", "create", "/i", nil),
+		Entry("greedy parameter", "POST", "/i/info-id", "create id", "/i/{id+}", map[string]string{"id": "info-id"}),
+		Entry("greedy parameter of several segments", "GET", "/i/folder/info-id", "get", "/i/{id+}", map[string]string{"id": "folder/info-id"}),
+		Entry("greedy parameter with trailing slash", "GET", "/i/folder/", "get", "/i/{id+}", map[string]string{"id": "folder/"}),
+		Entry("same path of another method", "PUT", "/i/info-id", "update", "/i/{id+}", map[string]string{"id": "info-id"}),
+		Entry("parameter and greedy parameter", "GET", "/n/team-a/i/folder/info-id", "get in namespace", "/n/{namespace}/i/{id+}",
+			map[string]string{"namespace": "team-a", "id": "folder/info-id"}),
+	)
+
+	DescribeTable("paths matching no route",
+		func(path string) {
+			_, err := r.Handler(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod: "GET",
+				Path:       path,
+			})
+			Expect(called).To(BeEmpty())
+
+			p, ok := err.(problem.Problem)
+			Expect(ok).To(BeTrue())
+			Expect(p.Status).To(Equal(404))
+			Expect(p.Type).To(Equal(problem.TypeRouteNotFound))
+		},
+		Entry("unknown path", "/unknown"),
+		Entry("root", "/"),
+		Entry("empty greedy parameter", "/i/"),
+		Entry("empty parameter", "/n//i/info-id"),
+		Entry("extra segment", "/usage/extra"),
+	)
+
+	It("should return 405 with the allowed methods if only other methods match", func() {
+		response, err := r.Handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "DELETE",
+			Path:       "/i/info-id",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(called).To(BeEmpty())
+		Expect(response.StatusCode).To(Equal(405))
+		Expect(response.Headers).To(HaveKeyWithValue("Allow", "GET, POST, PUT"))
+
+		Expect(problemtest.Of(response).Type).To(Equal(problem.TypeMethodNotAllowed))
+	})
+
+	It("should match routes in the order they are registered", func() {
+		r.Handle("GET", "/i/{id+}", handlerOf("shadowed"))
+		_, err := r.Handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/i/info-id",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(called).To(Equal("get"))
+	})
+
+	It("should panic on a greedy parameter which is not the last segment", func() {
+		Expect(func() {
+			r.Handle("GET", "/i/{id+}/meta", handlerOf("invalid"))
+		}).To(Panic())
+	})
+})
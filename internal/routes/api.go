@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"net/http"
+
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/ratelimit"
+	"simple-information-store-app/internal/router"
+	"simple-information-store-app/internal/service"
+)
+
+// Services are the services the API routes depend on.
+type Services struct {
+	RateLimiter     ratelimit.Limiter
+	RateLimits      ratelimit.Limits
+	NamespaceGetter service.NamespaceGetter
+	InfoCreator     service.InfoCreator
+	InfoGetter      service.InfoGetter
+	InfoLister      service.InfoLister
+	InfoMetaGetter  service.InfoMetaGetter
+	InfoUpdater     service.InfoUpdater
+	InfoDeleter     service.InfoDeleter
+	UsageGetter     service.UsageGetter
+}
+
+// NewServices returns the services of the configuration backed by DynamoDB.
+func NewServices(c config.Config) Services {
+	infoService := service.NewInfoService(c)
+	return Services{
+		RateLimiter:     ratelimit.NewDynamoDbLimiter(c),
+		RateLimits:      ratelimit.LimitsOf(c),
+		NamespaceGetter: service.NewNamespaceGetter(c),
+		InfoCreator:     infoService,
+		InfoGetter:      infoService,
+		InfoLister:      infoService,
+		InfoMetaGetter:  infoService,
+		InfoUpdater:     infoService,
+		InfoDeleter:     infoService,
+		UsageGetter:     service.NewUsageService(c),
+	}
+}
+
+// NewHandler returns the router of the services wrapped in the common
+// middlewares of the configuration. It is built once per process, e.g. in
+// main, so that warm invocations reuse it.
+func NewHandler(c config.Config, s Services) middleware.Handler {
+	return middleware.Chain(NewRouter(s).Handler, middleware.Common(c)...)
+}
+
+// NewRouter returns the router of all API routes. The routes are the same as
+// the routes of the per-route functions in template.yaml. The common
+// middlewares are not applied, they should wrap the router instead.
+func NewRouter(s Services) *router.Router {
+	r := router.New()
+
+	// Each route is rate limited and authenticated like in the per-route
+	// functions.
+	api := func(h middleware.NamespaceHandler) middleware.Handler {
+		return middleware.Chain(middleware.Authenticated(s.NamespaceGetter, h), middleware.RateLimit(s.RateLimiter, s.RateLimits))
+	}
+
+	createValue := api(CreateValue(s.InfoCreator))
+	getValue := api(GetValue(s.InfoGetter, s.InfoLister, s.InfoMetaGetter))
+	updateValue := api(UpdateValue(s.InfoUpdater))
+	deleteValue := api(DeleteValue(s.InfoDeleter))
+	getUsage := api(GetUsage(s.UsageGetter))
+
+	for _, prefix := range []string{"", "/n/{namespace}"} {
+		r.Handle(http.MethodPost, prefix+"/i", createValue)
+		r.Handle(http.MethodPost, prefix+"/i/{id+}", createValue)
+		r.Handle(http.MethodGet, prefix+"/i/{id+}", getValue)
+		r.Handle(http.MethodHead, prefix+"/i/{id+}", getValue)
+		r.Handle(http.MethodPut, prefix+"/i/{id+}", updateValue)
+		r.Handle(http.MethodDelete, prefix+"/i/{id+}", deleteValue)
+		r.Handle(http.MethodGet, prefix+"/usage", getUsage)
+
+		for _, resource := range []string{prefix + "/i", prefix + "/i/{id+}", prefix + "/usage"} {
+			r.Handle(http.MethodOptions, resource, Options)
+		}
+	}
+
+	return r
+}
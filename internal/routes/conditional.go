@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// validators are the values of an info which identify its current value and
+// metadata.
+type validators struct {
+	contentHash string
+	version     int64
+	updatedAt   time.Time
+
+	// representation is the suffix of the entity tag of a representation
+	// other than the raw value, e.g. "json" for the envelope.
+	representation string
+}
+
+func validatorsOfInfo(info service.Info) validators {
+	return validators{contentHash: info.ContentHash, version: info.Version, updatedAt: info.UpdatedAt}
+}
+
+func validatorsOfMeta(meta service.InfoMeta) validators {
+	return validators{contentHash: meta.ContentHash, version: meta.Version, updatedAt: meta.UpdatedAt}
+}
+
+// etag returns the strong entity tag of the info's value. Representations
+// other than the raw value have their own entity tags, since they differ in
+// their bytes.
+func (v validators) etag() string {
+	etag := content.ETag(v.contentHash, v.version)
+	if v.representation != "" {
+		return strings.TrimSuffix(etag, `"`) + "-" + v.representation + `"`
+	}
+
+	return etag
+}
+
+// headers returns the ETag and Last-Modified headers of the info.
+func (v validators) headers() map[string]string {
+	headers := map[string]string{
+		"ETag": v.etag(),
+	}
+
+	if !v.updatedAt.IsZero() {
+		headers["Last-Modified"] = v.updatedAt.UTC().Format(http.TimeFormat)
+	}
+
+	return headers
+}
+
+// notModified returns if the client's cached copy of the info is still valid
+// according to If-None-Match or, if absent, If-Modified-Since (RFC 7232).
+func notModified(request events.APIGatewayProxyRequest, v validators) bool {
+	if ifNoneMatch := helper.GetHeader(request.Headers, "If-None-Match"); ifNoneMatch != "" {
+		etag := v.etag()
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			// If-None-Match uses the weak comparison.
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := helper.GetHeader(request.Headers, "If-Modified-Since"); ifModifiedSince != "" && !v.updatedAt.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+
+		// Last-Modified has a resolution of seconds.
+		return !v.updatedAt.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
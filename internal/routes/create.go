@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+)
+
+// CreateValue returns the handler which creates an info.
+func CreateValue(infoCreator service.InfoCreator) middleware.NamespaceHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error) {
+		contentType := helper.GetHeader(request.Headers, "Content-Type")
+		bodyID, value, err := content.ParseBody(contentType, request.Body)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		attrs, err := content.ParseAttributes(contentType, helper.GetHeader(request.Headers, content.TagsHeader))
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		// Use the path or the id in the body if given, otherwise generate an Id
+		id := request.PathParameters["id"]
+		switch {
+		case id != "" && bodyID != "" && bodyID != id:
+			return events.APIGatewayProxyResponse{}, problem.IDMismatch(id, bodyID)
+		case id == "" && bodyID != "":
+			id = bodyID
+		case id == "":
+			id = uuid.New().String()
+		}
+
+		info, err := infoCreator.CreateInfo(ctx, ns, auth.OwnerOf(request), id, value, attrs)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		responseBody := map[string]string{
+			"id": info.ID,
+		}
+		responseBodyBytes, _ := json.Marshal(responseBody)
+		return events.APIGatewayProxyResponse{
+			StatusCode: 201,
+			Headers: map[string]string{
+				"Content-Type": content.TypeJSON,
+			},
+			Body: string(responseBodyBytes),
+		}, nil
+	}
+}
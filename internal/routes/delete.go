@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteValue returns the handler which deletes an info, or a page of the
+// infos under a folder prefix if the query parameter recursive is given.
+func DeleteValue(infoDeleter service.InfoDeleter) middleware.NamespaceHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error) {
+		id := request.PathParameters["id"]
+
+		if _, ok := request.QueryStringParameters["recursive"]; ok {
+			return recursiveHandler(ctx, infoDeleter, ns, auth.OwnerOf(request), id, request.QueryStringParameters["after"])
+		}
+
+		err := infoDeleter.DeleteInfo(ctx, ns, auth.OwnerOf(request), id)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		return events.APIGatewayProxyResponse{
+			StatusCode: 204,
+		}, nil
+	}
+}
+
+// recursiveHandler deletes a page of the infos under the folder prefix after
+// the id after. The id to continue after is returned as next, unless all
+// infos are deleted.
+func recursiveHandler(ctx context.Context, infoDeleter service.InfoDeleter, ns service.Namespace, principal, prefix, after string) (events.APIGatewayProxyResponse, error) {
+	result, err := infoDeleter.DeleteInfos(ctx, ns, principal, prefix, after)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	responseBody := map[string]interface{}{
+		"deleted": result.Deleted,
+	}
+	if result.Next != "" {
+		responseBody["next"] = result.Next
+	}
+	responseBodyBytes, _ := json.Marshal(responseBody)
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": content.TypeJSON,
+		},
+		Body: string(responseBodyBytes),
+	}, nil
+}
@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// valueGetter handles the GET and HEAD requests of infos.
+type valueGetter struct {
+	infoGetter     service.InfoGetter
+	infoLister     service.InfoLister
+	infoMetaGetter service.InfoMetaGetter
+}
+
+// GetValue returns the handler which gets the value, the metadata or the
+// children of an info.
+func GetValue(infoGetter service.InfoGetter, infoLister service.InfoLister, infoMetaGetter service.InfoMetaGetter) middleware.NamespaceHandler {
+	return valueGetter{
+		infoGetter:     infoGetter,
+		infoLister:     infoLister,
+		infoMetaGetter: infoMetaGetter,
+	}.getValue
+}
+
+func (g valueGetter) getValue(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error) {
+	id := request.PathParameters["id"]
+
+	if _, ok := request.QueryStringParameters["list"]; ok {
+		return g.listHandler(ctx, ns, id)
+	}
+
+	if request.HTTPMethod == http.MethodHead {
+		return g.headHandler(ctx, request, ns, id)
+	}
+
+	if metaID, ok := service.MetaIDOf(id); ok {
+		return g.metaHandler(ctx, ns, metaID)
+	}
+
+	info, err := g.infoGetter.GetInfo(ctx, ns, id)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	// The raw value is offered with its content type, so that e.g. JSON values
+	// are returned as they are stored.
+	offers := []string{content.MediaTypeOf(info.ContentType), content.TypeEnvelope}
+	mediaType, ok := content.Negotiate(helper.GetHeader(request.Headers, "Accept"), offers...)
+	if !ok {
+		return events.APIGatewayProxyResponse{}, problem.NotAcceptable(offers...)
+	}
+
+	v := validatorsOfInfo(info)
+	if mediaType == content.TypeEnvelope {
+		v.representation = "json"
+	}
+
+	headers := v.headers()
+	headers["Vary"] = "Accept"
+	if notModified(request, v) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 304,
+			Headers:    headers,
+		}, nil
+	}
+
+	if mediaType == content.TypeEnvelope {
+		headers["Content-Type"] = content.TypeEnvelope
+		responseBodyBytes, _ := json.Marshal(content.NewEnvelope(ns, info))
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       string(responseBodyBytes),
+		}, nil
+	}
+
+	headers["Content-Type"] = info.ContentType
+	headers["Accept-Ranges"] = "bytes"
+	if rangeHeader := helper.GetHeader(request.Headers, "Range"); rangeHeader != "" && rangeApplies(request, v) {
+		ranges, err := parseRange(rangeHeader, int64(len(info.Value)))
+		if err == errRangeNotSatisfiable {
+			return rangeNotSatisfiableResponse(request, info.Value), nil
+		}
+
+		if len(ranges) > 0 {
+			return rangeResponse(info.Value, ranges, headers), nil
+		}
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       info.Value,
+	}, nil
+}
+
+// listHandler returns the direct children of the folder prefix.
+func (g valueGetter) listHandler(ctx context.Context, ns service.Namespace, prefix string) (events.APIGatewayProxyResponse, error) {
+	list, err := g.infoLister.ListInfos(ctx, ns, prefix)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	// Empty lists are returned as empty arrays rather than null.
+	if list.IDs == nil {
+		list.IDs = []string{}
+	}
+
+	if list.Folders == nil {
+		list.Folders = []string{}
+	}
+
+	responseBody := map[string]interface{}{
+		"prefix":  list.Prefix,
+		"ids":     list.IDs,
+		"folders": list.Folders,
+	}
+	responseBodyBytes, _ := json.Marshal(responseBody)
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": content.TypeJSON,
+		},
+		Body: string(responseBodyBytes),
+	}, nil
+}
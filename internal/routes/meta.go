@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// headHandler returns the metadata of the info as headers without body.
+func (g valueGetter) headHandler(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace, id string) (events.APIGatewayProxyResponse, error) {
+	meta, err := g.infoMetaGetter.GetInfoMeta(ctx, ns, id)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	headers := validatorsOfMeta(meta).headers()
+	if notModified(request, validatorsOfMeta(meta)) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 304,
+			Headers:    headers,
+		}, nil
+	}
+
+	headers["Content-Length"] = strconv.FormatInt(meta.Size, 10)
+	headers["Accept-Ranges"] = "bytes"
+	headers["Content-Type"] = meta.ContentType
+	headers["X-Info-Version"] = strconv.FormatInt(meta.Version, 10)
+	if !meta.CreatedAt.IsZero() {
+		headers["X-Info-Created-At"] = meta.CreatedAt.UTC().Format(http.TimeFormat)
+	}
+
+	if len(meta.Tags) > 0 {
+		headers[content.TagsHeader] = content.FormatTags(meta.Tags)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    headers,
+	}, nil
+}
+
+// metaHandler returns the metadata of the info as JSON.
+func (g valueGetter) metaHandler(ctx context.Context, ns service.Namespace, id string) (events.APIGatewayProxyResponse, error) {
+	meta, err := g.infoMetaGetter.GetInfoMeta(ctx, ns, id)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	responseBody := map[string]interface{}{
+		"id":          meta.ID,
+		"size":        meta.Size,
+		"contentType": meta.ContentType,
+		"createdAt":   formatOptionalTime(meta.CreatedAt),
+		"updatedAt":   formatOptionalTime(meta.UpdatedAt),
+		"expiresAt":   formatOptionalTime(meta.ExpiresAt),
+		"version":     meta.Version,
+		"etag":        validatorsOfMeta(meta).etag(),
+		"tags":        meta.Tags,
+	}
+	responseBodyBytes, _ := json.Marshal(responseBody)
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": content.TypeJSON,
+		},
+		Body: string(responseBodyBytes),
+	}, nil
+}
+
+// formatOptionalTime formats the time in RFC 3339, or returns nil if it is zero.
+func formatOptionalTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// AllowedMethods are the methods of the info routes.
+var AllowedMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}
+
+// Options answers OPTIONS requests which are not CORS preflight requests.
+// Preflight requests are answered by the CORS middleware.
+func Options(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusNoContent,
+		Headers: map[string]string{
+			"Allow": strings.Join(AllowedMethods, ", "),
+		},
+	}, nil
+}
@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/problem"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// maxRanges is the max. number of ranges served in one response. Requests
+// with more ranges are answered with the full value.
+const maxRanges = 16
+
+// byteRange presents the bytes from start to end inclusive.
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.end, size)
+}
+
+// errRangeNotSatisfiable indicates that none of the requested ranges overlaps
+// the value.
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// parseRange parses the Range header of a value of the size (RFC 7233). It
+// returns no ranges if the header should be ignored, e.g. because of invalid
+// syntax or an unknown unit, and errRangeNotSatisfiable if no range is
+// satisfiable.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	const unit = "bytes="
+	if len(header) < len(unit) || !strings.EqualFold(header[:len(unit)], unit) {
+		return nil, nil
+	}
+
+	specs := strings.Split(header[len(unit):], ",")
+	if len(specs) > maxRanges {
+		return nil, nil
+	}
+
+	ranges := []byteRange{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		i := strings.Index(spec, "-")
+		if i < 0 {
+			return nil, nil
+		}
+
+		first, last := spec[:i], spec[i+1:]
+		if first == "" {
+			// Suffix range of the last n bytes.
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, nil
+			}
+
+			if n == 0 || size == 0 {
+				continue
+			}
+
+			if n > size {
+				n = size
+			}
+
+			ranges = append(ranges, byteRange{start: size - n, end: size - 1})
+			continue
+		}
+
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return nil, nil
+		}
+
+		end := size - 1
+		if last != "" {
+			end, err = strconv.ParseInt(last, 10, 64)
+			if err != nil || end < start {
+				return nil, nil
+			}
+		}
+
+		if start >= size {
+			continue
+		}
+
+		if end >= size {
+			end = size - 1
+		}
+
+		ranges = append(ranges, byteRange{start: start, end: end})
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+
+	return ranges, nil
+}
+
+// rangeApplies returns if the Range header of the request should be honored
+// according to If-Range, i.e. if the client's partial copy is still current.
+func rangeApplies(request events.APIGatewayProxyRequest, v validators) bool {
+	ifRange := helper.GetHeader(request.Headers, "If-Range")
+	if ifRange == "" {
+		return true
+	}
+
+	// If-Range uses the strong comparison, weak entity tags never match.
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == v.etag()
+	}
+
+	return !v.updatedAt.IsZero() && ifRange == v.headers()["Last-Modified"]
+}
+
+// rangeResponse returns the response with the ranges of the value: 206 with
+// the range as body if there is one range, or as multipart/byteranges if
+// there are several. The body is base64 encoded, since ranges may split
+// UTF-8 characters, which a text body cannot carry.
+func rangeResponse(value string, ranges []byteRange, headers map[string]string) events.APIGatewayProxyResponse {
+	size := int64(len(value))
+	if len(ranges) == 1 {
+		r := ranges[0]
+		headers["Content-Range"] = r.contentRange(size)
+		return events.APIGatewayProxyResponse{
+			StatusCode:      206,
+			Headers:         headers,
+			Body:            base64.StdEncoding.EncodeToString([]byte(value[r.start : r.end+1])),
+			IsBase64Encoded: true,
+		}
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, r := range ranges {
+		part, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {headers["Content-Type"]},
+			"Content-Range": {r.contentRange(size)},
+		})
+		part.Write([]byte(value[r.start : r.end+1]))
+	}
+	writer.Close()
+
+	headers["Content-Type"] = "multipart/byteranges; boundary=" + writer.Boundary()
+	return events.APIGatewayProxyResponse{
+		StatusCode:      206,
+		Headers:         headers,
+		Body:            base64.StdEncoding.EncodeToString(body.Bytes()),
+		IsBase64Encoded: true,
+	}
+}
+
+// rangeNotSatisfiableResponse returns 416 with the size of the value.
+func rangeNotSatisfiableResponse(request events.APIGatewayProxyRequest, value string) events.APIGatewayProxyResponse {
+	response := problem.ProblemResponse(request, problem.New(416, problem.TypeRangeNotSatisfiable,
+		"Range not satisfiable", fmt.Sprintf("The value has %d bytes.", len(value))))
+	response.Headers["Content-Range"] = fmt.Sprintf("bytes */%d", len(value))
+	return response
+}
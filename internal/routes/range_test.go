@@ -0,0 +1,49 @@
+package routes
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseRange()", func() {
+	const size = 10
+
+	DescribeTable("satisfiable ranges",
+		func(header string, expected []byteRange) {
+			ranges, err := parseRange(header, size)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ranges).To(Equal(expected))
+		},
+		Entry("first and last byte", "bytes=0-4", []byteRange{{0, 4}}),
+		Entry("open end", "bytes=5-", []byteRange{{5, 9}}),
+		Entry("suffix", "bytes=-3", []byteRange{{7, 9}}),
+		Entry("suffix longer than the value", "bytes=-20", []byteRange{{0, 9}}),
+		Entry("end beyond the value", "bytes=8-20", []byteRange{{8, 9}}),
+		Entry("multiple ranges", "bytes=0-1, 4-5", []byteRange{{0, 1}, {4, 5}}),
+		Entry("unsatisfiable ranges among others", "bytes=20-30,0-0", []byteRange{{0, 0}}),
+		Entry("unit in other case", "Bytes=0-0", []byteRange{{0, 0}}),
+	)
+
+	DescribeTable("ignored headers",
+		func(header string) {
+			ranges, err := parseRange(header, size)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ranges).To(BeEmpty())
+		},
+		Entry("other unit", "items=0-1"),
+		Entry("missing dash", "bytes=5"),
+		Entry("last before first", "bytes=5-4"),
+		Entry("not a number", "bytes=a-b"),
+		Entry("too many ranges", "bytes=0-0,1-1,2-2,3-3,4-4,5-5,6-6,7-7,8-8,9-9,0-0,1-1,2-2,3-3,4-4,5-5,6-6"),
+	)
+
+	DescribeTable("unsatisfiable ranges",
+		func(header string) {
+			_, err := parseRange(header, size)
+			Expect(err).To(Equal(errRangeNotSatisfiable))
+		},
+		Entry("start beyond the value", "bytes=10-"),
+		Entry("empty suffix", "bytes=-0"),
+	)
+})
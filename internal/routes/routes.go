@@ -0,0 +1,4 @@
+// Package routes implements the handlers of the API routes. The handlers
+// are shared by the per-route Lambda functions and the api function, which
+// dispatches all routes in process.
+package routes
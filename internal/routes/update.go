@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"context"
+
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/problem"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UpdateValue returns the handler which updates the value of an info.
+func UpdateValue(infoUpdater service.InfoUpdater) middleware.NamespaceHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error) {
+		id := request.PathParameters["id"]
+		contentType := helper.GetHeader(request.Headers, "Content-Type")
+		bodyID, value, err := content.ParseBody(contentType, request.Body)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		attrs, err := content.ParseAttributes(contentType, helper.GetHeader(request.Headers, content.TagsHeader))
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		if bodyID != "" && bodyID != id {
+			return events.APIGatewayProxyResponse{}, problem.IDMismatch(id, bodyID)
+		}
+
+		_, err = infoUpdater.UpdateInfo(ctx, ns, auth.OwnerOf(request), id, value, attrs)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+		}, nil
+	}
+}
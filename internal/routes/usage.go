@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+
+	"simple-information-store-app/internal/auth"
+	"simple-information-store-app/internal/content"
+	"simple-information-store-app/internal/middleware"
+	"simple-information-store-app/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetUsage returns the handler which gets the usage of the namespace and,
+// if authenticated, of the caller.
+func GetUsage(usageGetter service.UsageGetter) middleware.NamespaceHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, ns service.Namespace) (events.APIGatewayProxyResponse, error) {
+		namespaceUsage, err := usageGetter.GetUsage(ctx, ns, "")
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+
+		responseBody := map[string]interface{}{
+			"namespace": usageToMap(namespaceUsage),
+		}
+
+		// The usage of the caller is only known if it is authenticated.
+		if owner := auth.OwnerOf(request); owner != "" {
+			ownerUsage, err := usageGetter.GetUsage(ctx, ns, owner)
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, err
+			}
+
+			responseBody["owner"] = usageToMap(ownerUsage)
+		}
+
+		responseBodyBytes, _ := json.Marshal(responseBody)
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers: map[string]string{
+				"Content-Type": content.TypeJSON,
+			},
+			Body: string(responseBodyBytes),
+		}, nil
+	}
+}
+
+func usageToMap(usage service.Usage) map[string]interface{} {
+	m := map[string]interface{}{
+		"namespace": usage.Namespace,
+		"itemCount": usage.ItemCount,
+		"byteCount": usage.ByteCount,
+		"itemQuota": usage.ItemQuota,
+		"byteQuota": usage.ByteQuota,
+	}
+
+	if usage.Owner != "" {
+		m["owner"] = usage.Owner
+	}
+
+	return m
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"simple-information-store-app/internal/helper"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+// ErrorClass is the class of an error of DynamoDB, which tells whether a
+// request should be retried and how the error is presented to clients.
+type ErrorClass int
+
+// Classes of errors.
+const (
+	// ClassFatal errors are not expected to go away, e.g. a missing table or
+	// missing permissions.
+	ClassFatal ErrorClass = iota
+
+	// ClassTransient errors are throttled requests and temporary failures of
+	// DynamoDB or the network, which are retried.
+	ClassTransient
+
+	// ClassConflict errors are failed conditions of writes, e.g. because the
+	// item is modified concurrently.
+	ClassConflict
+
+	// ClassValidation errors are requests DynamoDB rejects as invalid, e.g.
+	// because an item is too large.
+	ClassValidation
+)
+
+func (class ErrorClass) String() string {
+	switch class {
+	case ClassTransient:
+		return "transient"
+	case ClassConflict:
+		return "conflict"
+	case ClassValidation:
+		return "validation"
+	default:
+		return "fatal"
+	}
+}
+
+// ClassOf returns the class of an error returned by a service. Errors
+// unknown to it are fatal.
+func ClassOf(err error) ErrorClass {
+	var unavailable UnavailableError
+	if errors.As(err, &unavailable) {
+		return ClassTransient
+	}
+
+	if errors.Is(err, errConcurrentModification) {
+		return ClassConflict
+	}
+
+	if canceledErr, ok := err.(*dynamodb.TransactionCanceledException); ok {
+		return classOfCancellation(canceledErr.CancellationReasons)
+	}
+
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return ClassFatal
+	}
+
+	if isThrottled(err) {
+		return ClassTransient
+	}
+
+	switch aerr.Code() {
+	case request.ErrCodeRequestError, request.ErrCodeResponseTimeout, dynamodb.ErrCodeInternalServerError,
+		"ServiceUnavailable", dynamodb.ErrCodeTransactionConflictException, dynamodb.ErrCodeTransactionInProgressException:
+		return ClassTransient
+	case dynamodb.ErrCodeConditionalCheckFailedException:
+		return ClassConflict
+	case "ValidationException", dynamodb.ErrCodeItemCollectionSizeLimitExceededException:
+		return ClassValidation
+	default:
+		return ClassFatal
+	}
+}
+
+// classOfCancellation returns the class of a canceled transaction by the
+// reasons of its items. Failed conditions take precedence, since they are
+// handled by the services.
+func classOfCancellation(reasons []*dynamodb.CancellationReason) ErrorClass {
+	class := ClassFatal
+	for _, reason := range reasons {
+		if reason.Code == nil {
+			continue
+		}
+
+		switch *reason.Code {
+		case "ConditionalCheckFailed":
+			return ClassConflict
+		case "ThrottlingError", "ProvisionedThroughputExceeded", "TransactionConflict":
+			class = ClassTransient
+		case "ValidationError", "ItemCollectionSizeLimitExceeded":
+			if class != ClassTransient {
+				class = ClassValidation
+			}
+		}
+	}
+
+	return class
+}
+
+// UnavailableError indicates that DynamoDB kept throttling or failing a
+// request, which may be retried after RetryAfter.
+type UnavailableError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (err UnavailableError) Error() string {
+	return "storage unavailable: " + err.Err.Error()
+}
+
+func (err UnavailableError) Unwrap() error {
+	return err.Err
+}
+
+// retryPolicy is how often and how long transient errors of calls are
+// retried.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+
+	// budgeted policies only retry as far as the retry budget allows.
+	budgeted bool
+}
+
+var (
+	// apiRetries are the retries of requests of the API. They are few and
+	// short, since requests have to be answered before the function times
+	// out.
+	apiRetries = retryPolicy{
+		maxAttempts: 3,
+		baseDelay:   25 * time.Millisecond,
+		maxDelay:    400 * time.Millisecond,
+		budgeted:    true,
+	}
+
+	// batchRetries are the retries of batch jobs, e.g. imports and
+	// migrations, which keep the table busy for a while and back off long
+	// enough to get through throttling rather than fail.
+	batchRetries = retryPolicy{
+		maxAttempts: 8,
+		baseDelay:   100 * time.Millisecond,
+		maxDelay:    10 * time.Second,
+	}
+)
+
+// unavailableRetryAfter is the time clients are asked to wait before retrying
+// a request which failed with UnavailableError.
+const unavailableRetryAfter = time.Second
+
+// retryBudget limits the retries of a service to a ratio of its calls, so
+// that retries do not multiply the load on a throttled table. Each call
+// deposits retryRatio tokens up to maxRetryTokens, and each retry takes one.
+type retryBudget struct {
+	mutex  sync.Mutex
+	tokens float64
+}
+
+const (
+	retryRatio     = 0.2
+	maxRetryTokens = 10
+)
+
+// newRetryBudget returns a full budget.
+func newRetryBudget() *retryBudget {
+	return &retryBudget{tokens: maxRetryTokens}
+}
+
+func (b *retryBudget) deposit() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.tokens += retryRatio; b.tokens > maxRetryTokens {
+		b.tokens = maxRetryTokens
+	}
+}
+
+// withdraw takes a token for a retry and returns false if the budget is
+// spent.
+func (b *retryBudget) withdraw() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// call calls fn, which sends a request to DynamoDB, and retries it with
+// jittered exponential backoff according to the retry policy of the table
+// while its error is transient and the backoff ends before the deadline of
+// ctx. The DynamoDB client does not retry itself, so that these are all
+// retries. A transient error which persists is returned as UnavailableError,
+// other errors as they are.
+func (t valueTable) call(ctx context.Context, fn func() error) error {
+	policy := t.retryPolicy()
+	if policy.budgeted {
+		t.retries.deposit()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || ClassOf(err) != ClassTransient {
+			return err
+		}
+
+		if attempt >= policy.maxAttempts {
+			return unavailable(err)
+		}
+
+		delay := policy.backoff(attempt)
+		if !before(ctx, delay) || (policy.budgeted && !t.retries.withdraw()) {
+			return unavailable(err)
+		}
+
+		sleep(delay)
+	}
+}
+
+// transactWrite writes the items in a transaction through call. All attempts
+// send the same client request token, so that DynamoDB takes a retry of a
+// transaction which succeeded, although its response was lost, as the same
+// transaction, rather than failing its conditions.
+func (t valueTable) transactWrite(ctx context.Context, items []*dynamodb.TransactWriteItem) error {
+	dynamoDbClient := t.client()
+	input := &dynamodb.TransactWriteItemsInput{
+		ClientRequestToken: helper.StringPtr(uuid.New().String()),
+		TransactItems:      items,
+	}
+
+	return t.call(ctx, func() error {
+		_, err := dynamoDbClient.TransactWriteItemsWithContext(ctx, input)
+		return err
+	})
+}
+
+func unavailable(err error) error {
+	return UnavailableError{
+		RetryAfter: unavailableRetryAfter,
+		Err:        err,
+	}
+}
+
+// sleep is time.Sleep, replaced in tests.
+var sleep = time.Sleep
+
+// backoff returns a random delay before the retry after the attempt, up to
+// the base delay doubled with each attempt ("full jitter").
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	limit := p.baseDelay << uint(attempt-1)
+	if limit > p.maxDelay || limit <= 0 {
+		limit = p.maxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(limit))) + 1
+}
+
+// before returns if the delay ends before the deadline of ctx, if any.
+func before(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Now().Add(delay).Before(deadline)
+}
+
+// isThrottled returns if the error indicates that DynamoDB throttled the
+// request because the capacity of the table or the account is exceeded.
+func isThrottled(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	switch aerr.Code() {
+	case dynamodb.ErrCodeProvisionedThroughputExceededException, dynamodb.ErrCodeRequestLimitExceeded, "ThrottlingException":
+		return true
+	default:
+		return false
+	}
+}
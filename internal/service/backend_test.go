@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClassOf()", func() {
+	canceled := func(codes ...string) error {
+		reasons := make([]*dynamodb.CancellationReason, len(codes))
+		for i, code := range codes {
+			reasons[i] = &dynamodb.CancellationReason{Code: aws.String(code)}
+		}
+
+		return &dynamodb.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	DescribeTable("classes",
+		func(err error, class ErrorClass) {
+			Expect(ClassOf(err)).To(Equal(class))
+		},
+		Entry("throttled", awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "", nil), ClassTransient),
+		Entry("throttled account", awserr.New("ThrottlingException", "", nil), ClassTransient),
+		Entry("internal server error", awserr.New(dynamodb.ErrCodeInternalServerError, "", nil), ClassTransient),
+		Entry("network error", awserr.New("RequestError", "", nil), ClassTransient),
+		Entry("transaction conflict", awserr.New(dynamodb.ErrCodeTransactionConflictException, "", nil), ClassTransient),
+		Entry("UnavailableError", fmt.Errorf("wrapped: %w", UnavailableError{Err: errors.New("throttled")}), ClassTransient),
+		Entry("throttled transaction", canceled("None", "ThrottlingError"), ClassTransient),
+		Entry("failed condition", awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "", nil), ClassConflict),
+		Entry("failed condition of transaction", canceled("ThrottlingError", "ConditionalCheckFailed"), ClassConflict),
+		Entry("concurrent modification", errConcurrentModification, ClassConflict),
+		Entry("validation", awserr.New("ValidationException", "", nil), ClassValidation),
+		Entry("invalid transaction", canceled("ValidationError", "None"), ClassValidation),
+		Entry("missing table", awserr.New(dynamodb.ErrCodeResourceNotFoundException, "", nil), ClassFatal),
+		Entry("canceled request", awserr.New("RequestCanceled", "", context.Canceled), ClassFatal),
+		Entry("unknown error", errors.New("unknown"), ClassFatal),
+	)
+})
+
+var _ = Describe("retryBudget", func() {
+	It("should allow retries of a ratio of the calls once spent", func() {
+		budget := newRetryBudget()
+		for i := 0; i < maxRetryTokens; i++ {
+			Expect(budget.withdraw()).To(BeTrue())
+		}
+		Expect(budget.withdraw()).To(BeFalse())
+
+		for i := 0; i < 5; i++ {
+			budget.deposit()
+		}
+		Expect(budget.withdraw()).To(BeTrue())
+		Expect(budget.withdraw()).To(BeFalse())
+	})
+})
+
+var _ = Describe("before()", func() {
+	It("should return if the delay ends before the deadline", func() {
+		Expect(before(context.Background(), time.Hour)).To(BeTrue())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		Expect(before(ctx, time.Second)).To(BeTrue())
+		Expect(before(ctx, time.Hour)).To(BeFalse())
+	})
+})
+
+// fault is an error response of DynamoDB.
+type fault struct {
+	status int
+	body   string
+}
+
+func awsFault(status int, code string) fault {
+	return fault{status, fmt.Sprintf(`{"__type":"com.amazonaws.dynamodb.v20120810#%s","message":"injected"}`, code)}
+}
+
+func canceledFault(codes ...string) fault {
+	reasons := ""
+	for i, code := range codes {
+		if i > 0 {
+			reasons += ","
+		}
+		reasons += fmt.Sprintf(`{"Code":"%s"}`, code)
+	}
+
+	return fault{400, `{"__type":"com.amazonaws.dynamodb.v20120810#TransactionCanceledException","Message":"injected","CancellationReasons":[` + reasons + `]}`}
+}
+
+// faultInjector is a stand-in of DynamoDB which answers requests with the
+// injected faults in order, and then with an item. It keeps the bodies of
+// the requests.
+type faultInjector struct {
+	mutex    sync.Mutex
+	faults   []fault
+	requests int
+	bodies   []string
+}
+
+func (f *faultInjector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests++
+	f.bodies = append(f.bodies, string(body))
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	if len(f.faults) > 0 {
+		next := f.faults[0]
+		f.faults = f.faults[1:]
+		w.WriteHeader(next.status)
+		io.WriteString(w, next.body)
+		return
+	}
+
+	io.WriteString(w, `{"Item":{"Id":{"S":"a"},"Value":{"S":"info value"}}}`)
+}
+
+var _ = Describe("Fault injection", func() {
+	var (
+		injector *faultInjector
+		server   *httptest.Server
+		s        infoService
+		sleeps   []time.Duration
+	)
+
+	BeforeEach(func() {
+		injector = &faultInjector{}
+		server = httptest.NewServer(injector)
+		sess := session.Must(session.NewSession(aws.NewConfig().
+			WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).
+			WithRegion("us-east-1").
+			WithEndpoint(server.URL).
+			WithMaxRetries(0)))
+
+		s = infoService{valueTable: valueTable{
+			name:           "table",
+			dynamoDbClient: dynamodb.New(sess),
+			retries:        newRetryBudget(),
+		}}
+
+		sleeps = nil
+		sleep = func(d time.Duration) {
+			sleeps = append(sleeps, d)
+		}
+	})
+
+	AfterEach(func() {
+		sleep = time.Sleep
+		server.Close()
+	})
+
+	It("should retry transient errors with jittered backoff", func() {
+		injector.faults = []fault{awsFault(500, dynamodb.ErrCodeInternalServerError), awsFault(400, "ThrottlingException")}
+
+		info, err := s.GetInfo(context.Background(), Namespace{}, "a")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Value).To(Equal("info value"))
+		Expect(injector.requests).To(Equal(3))
+
+		Expect(sleeps).To(HaveLen(2))
+		Expect(sleeps[0]).To(BeNumerically(">", 0))
+		Expect(sleeps[0]).To(BeNumerically("<=", apiRetries.baseDelay))
+		Expect(sleeps[1]).To(BeNumerically(">", 0))
+		Expect(sleeps[1]).To(BeNumerically("<=", 2*apiRetries.baseDelay))
+	})
+
+	It("should return UnavailableError if throttling persists", func() {
+		for i := 0; i < 5; i++ {
+			injector.faults = append(injector.faults, awsFault(400, dynamodb.ErrCodeProvisionedThroughputExceededException))
+		}
+
+		_, err := s.GetInfo(context.Background(), Namespace{}, "a")
+		var unavailable UnavailableError
+		Expect(errors.As(err, &unavailable)).To(BeTrue())
+		Expect(unavailable.RetryAfter).To(BeNumerically(">", 0))
+		Expect(isThrottled(unavailable.Err)).To(BeTrue())
+		Expect(injector.requests).To(Equal(apiRetries.maxAttempts))
+	})
+
+	It("should not retry once the retry budget is spent", func() {
+		s.retries = &retryBudget{}
+		injector.faults = []fault{awsFault(400, "ThrottlingException")}
+
+		_, err := s.GetInfo(context.Background(), Namespace{}, "a")
+		Expect(ClassOf(err)).To(Equal(ClassTransient))
+		Expect(injector.requests).To(Equal(1))
+		Expect(sleeps).To(BeEmpty())
+	})
+
+	It("should retry throttled transactions", func() {
+		injector.faults = []fault{canceledFault("None", "ThrottlingError", "None")}
+
+		_, err := s.CreateInfo(context.Background(), Namespace{}, "", "a", "info value", InfoAttributes{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(injector.requests).To(Equal(2))
+	})
+
+	It("should send the same client request token with retries of a transaction", func() {
+		injector.faults = []fault{awsFault(500, dynamodb.ErrCodeInternalServerError)}
+
+		_, err := s.CreateInfo(context.Background(), Namespace{}, "", "a", "info value", InfoAttributes{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(injector.bodies).To(HaveLen(2))
+
+		var first, retry dynamodb.TransactWriteItemsInput
+		Expect(json.Unmarshal([]byte(injector.bodies[0]), &first)).To(Succeed())
+		Expect(json.Unmarshal([]byte(injector.bodies[1]), &retry)).To(Succeed())
+		Expect(first.ClientRequestToken).NotTo(BeNil())
+		Expect(retry.ClientRequestToken).To(Equal(first.ClientRequestToken))
+	})
+
+	It("should send a new client request token with each transaction", func() {
+		_, err := s.CreateInfo(context.Background(), Namespace{}, "", "a", "info value", InfoAttributes{})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = s.CreateInfo(context.Background(), Namespace{}, "", "b", "info value", InfoAttributes{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var first, second dynamodb.TransactWriteItemsInput
+		Expect(json.Unmarshal([]byte(injector.bodies[0]), &first)).To(Succeed())
+		Expect(json.Unmarshal([]byte(injector.bodies[1]), &second)).To(Succeed())
+		Expect(second.ClientRequestToken).NotTo(Equal(first.ClientRequestToken))
+	})
+
+	It("should retry calls of batch jobs longer and without budget", func() {
+		s.retries = &retryBudget{}
+		for i := 0; i < batchRetries.maxAttempts-1; i++ {
+			injector.faults = append(injector.faults, awsFault(400, dynamodb.ErrCodeProvisionedThroughputExceededException))
+		}
+
+		transfer := infoTransferService{valueTable: s.valueTable.asBatch()}
+		ok, err := transfer.ImportInfo(context.Background(), Record{ID: "a", Value: "info value"}, ConflictOverwrite)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(injector.requests).To(Equal(batchRetries.maxAttempts))
+		Expect(sleeps).To(HaveLen(batchRetries.maxAttempts - 1))
+		for _, d := range sleeps {
+			Expect(d).To(BeNumerically("<=", batchRetries.maxDelay))
+		}
+	})
+
+	It("should return UnavailableError if throttling of a batch job persists", func() {
+		for i := 0; i < batchRetries.maxAttempts; i++ {
+			injector.faults = append(injector.faults, awsFault(400, dynamodb.ErrCodeProvisionedThroughputExceededException))
+		}
+
+		transfer := infoTransferService{valueTable: s.valueTable.asBatch()}
+		_, err := transfer.ImportInfo(context.Background(), Record{ID: "a", Value: "info value"}, ConflictFail)
+		var unavailable UnavailableError
+		Expect(errors.As(err, &unavailable)).To(BeTrue())
+		Expect(injector.requests).To(Equal(batchRetries.maxAttempts))
+	})
+
+	It("should not retry conflicts", func() {
+		injector.faults = []fault{canceledFault("ConditionalCheckFailed", "None", "None")}
+
+		_, err := s.CreateInfo(context.Background(), Namespace{}, "", "a", "info value", InfoAttributes{})
+		Expect(err).To(Equal(InfoAlreadyExistsError{InfoID: "a"}))
+		Expect(injector.requests).To(Equal(1))
+	})
+
+	It("should not retry validation errors", func() {
+		injector.faults = []fault{awsFault(400, "ValidationException")}
+
+		_, err := s.GetInfo(context.Background(), Namespace{}, "a")
+		Expect(ClassOf(err)).To(Equal(ClassValidation))
+		Expect(injector.requests).To(Equal(1))
+	})
+
+	It("should not retry fatal errors", func() {
+		injector.faults = []fault{awsFault(400, dynamodb.ErrCodeResourceNotFoundException)}
+
+		_, err := s.GetInfo(context.Background(), Namespace{}, "a")
+		Expect(ClassOf(err)).To(Equal(ClassFatal))
+		Expect(injector.requests).To(Equal(1))
+	})
+})
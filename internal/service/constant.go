@@ -1,3 +1,15 @@
 package service
 
 const ValueMaxLen = 1000
+
+const IDMaxLen = 1024
+
+// RootIndexName is the name of the global secondary index on the value table,
+// partitioned by the first path segment of the id and sorted by the id.
+const RootIndexName = "RootIndex"
+
+// SchemaVersion is the version of the layout of items in the value table.
+// Items record the version they are stored with, and older items are
+// migrated on read or by a backfill. Snapshots record it, so that they are
+// only restored by versions which understand their items.
+const SchemaVersion = 1
@@ -1,10 +1,16 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"simple-information-store-app/internal/env"
+	"simple-information-store-app/internal/config"
 	"simple-information-store-app/internal/helper"
-	"simple-information-store-app/internal/helper/awshelper"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -13,44 +19,129 @@ import (
 type Info struct {
 	ID    string
 	Value string
+
+	// Owner is the identity which created the info, empty if anonymous.
+	Owner string
+
+	// ExpiresAt is the time the info expires, zero if it never expires.
+	ExpiresAt time.Time
+
+	// CreatedAt and UpdatedAt are the times the info was created and its value
+	// was last updated, zero for infos stored before they were tracked.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// ContentType is the media type of the value.
+	ContentType string
+
+	// ContentHash is the hex encoded SHA-256 hash of the value.
+	ContentHash string
+
+	// Version starts at 1 and is incremented by each update of the value.
+	Version int64
 }
 
+// InfoList presents the direct children of a folder.
+type InfoList struct {
+	Prefix  string
+	IDs     []string
+	Folders []string
+}
+
+// DeletedInfos presents a page of a recursive deletion.
+type DeletedInfos struct {
+	Deleted int
+
+	// Next is the id after which the deletion continues, empty if all infos
+	// under the folder are deleted.
+	Next string
+}
+
+// DeleteInfosPageSize is the max. number of infos deleted by one call of
+// DeleteInfos, so that it ends well before the function times out.
+const DeleteInfosPageSize = 25
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoCreator
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoGetter
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoUpdater
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoLister
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoDeleter
 
 type InfoCreator interface {
-	// CreateInfo creates an info in the database.
+	// CreateInfo creates an info owned by owner in the namespace with the
+	// value and its attributes. owner may be empty for anonymous infos.
 	// ValueTooLongError is returned if the value length exceeds the limit.
-	CreateInfo(id, value string) (Info, error)
+	// InvalidIDError is returned if the id is not a valid path.
+	// InfoAlreadyExistsError is returned if an info with the id already exists.
+	// QuotaExceededError is returned if a quota of the namespace or the owner
+	// would be exceeded.
+	CreateInfo(ctx context.Context, ns Namespace, owner, id, value string, attrs InfoAttributes) (Info, error)
 }
 
 type InfoGetter interface {
-	// GetInfo returns the info with the given id.
-	// InfoNotFoundError is returned if the info does not exist.
-	GetInfo(id string) (Info, error)
+	// GetInfo returns the info with the given id in the namespace.
+	// InvalidIDError is returned if the id is not a valid path.
+	// InfoNotFoundError is returned if the info does not exist or is expired.
+	GetInfo(ctx context.Context, ns Namespace, id string) (Info, error)
 }
 
 type InfoUpdater interface {
-	// UpdateInfo updates an existing info.
+	// UpdateInfo updates the value and attributes of an existing info in the
+	// namespace on behalf of the principal, which may be empty for anonymous
+	// requests.
 	// ValueTooLongError is returned if the value length exceeds the limit.
-	// InfoNotFoundError is returned if the info does not exist.
-	UpdateInfo(id, newValue string) (Info, error)
+	// InvalidIDError is returned if the id is not a valid path.
+	// InfoNotFoundError is returned if the info does not exist or is expired.
+	// NotOwnerError is returned if the info is owned by another principal.
+	// QuotaExceededError is returned if a quota of the namespace or the owner
+	// would be exceeded.
+	UpdateInfo(ctx context.Context, ns Namespace, principal, id, newValue string, attrs InfoAttributes) (Info, error)
+}
+
+type InfoLister interface {
+	// ListInfos returns the direct children of the folder prefix, e.g. "a/b/",
+	// in the namespace. The root cannot be listed, since infos are indexed
+	// by their top-level folder. Infos stored before the index was added are
+	// listed once they are migrated.
+	// InvalidIDError is returned if the prefix is not a valid path or empty.
+	ListInfos(ctx context.Context, ns Namespace, prefix string) (InfoList, error)
+}
+
+type InfoDeleter interface {
+	// DeleteInfo deletes an existing info in the namespace on behalf of the
+	// principal, which may be empty for anonymous requests.
+	// InvalidIDError is returned if the id is not a valid path.
+	// NotOwnerError is returned if the info is owned by another principal.
+	DeleteInfo(ctx context.Context, ns Namespace, principal, id string) error
+
+	// DeleteInfos deletes up to DeleteInfosPageSize infos under the folder
+	// prefix in the namespace recursively, starting after the id after if not
+	// empty. Next of the result is passed as after to delete the next page.
+	// InvalidIDError is returned if the prefix is not a valid path or empty,
+	// or after is not under the prefix.
+	// NotOwnerError is returned at the first info owned by another principal.
+	DeleteInfos(ctx context.Context, ns Namespace, principal, prefix, after string) (DeletedInfos, error)
 }
 
 type InfoService interface {
 	InfoCreator
 	InfoGetter
+	InfoMetaGetter
 	InfoUpdater
-
-	// DeleteInfo deletes an existing info.
-	DeleteInfo(id string) error
+	InfoLister
+	InfoDeleter
 }
 
-type infoService struct{}
+type infoService struct {
+	valueTable
+	features config.Features
+}
 
-func NewInfoService() InfoService {
-	return infoService{}
+func NewInfoService(c config.Config) InfoService {
+	return infoService{
+		valueTable: valueTableOf(c),
+		features:   c.Features,
+	}
 }
 
 // ValueTooLongError indicates that the value length exceeds the limit.
@@ -72,127 +163,601 @@ func (err InfoNotFoundError) Error() string {
 	return fmt.Sprintf("Info with id %s does not exist.", err.InfoID)
 }
 
-func (_ infoService) CreateInfo(id, value string) (Info, error) {
-	if err := checkValueLen(value); err != nil {
+// AdminPrincipal is the principal of callers which bypass the authentication
+// of the API, e.g. infoctl with direct access to DynamoDB. They may update and
+// delete the infos of all owners. It is not a valid identity of API Gateway.
+const AdminPrincipal = "#admin"
+
+// NotOwnerError indicates that the info is owned by another principal, which
+// is the only one allowed to update or delete it.
+type NotOwnerError struct {
+	InfoID string
+}
+
+func (err NotOwnerError) Error() string {
+	return fmt.Sprintf("Info with id %s is owned by another principal.", err.InfoID)
+}
+
+// checkOwner checks if the principal may update or delete the info. Infos
+// without owner may be modified by all principals.
+func checkOwner(info Info, principal string) *NotOwnerError {
+	if info.Owner == "" || info.Owner == principal || principal == AdminPrincipal {
+		return nil
+	}
+
+	return &NotOwnerError{
+		InfoID: info.ID,
+	}
+}
+
+// InfoAlreadyExistsError indicates that an info with the given id already exists.
+type InfoAlreadyExistsError struct {
+	InfoID string
+}
+
+func (err InfoAlreadyExistsError) Error() string {
+	return fmt.Sprintf("Info with id %s already exists.", err.InfoID)
+}
+
+func (s infoService) CreateInfo(ctx context.Context, ns Namespace, owner, id, value string, attrs InfoAttributes) (Info, error) {
+	if err := checkID(id); err != nil {
+		return Info{}, *err
+	}
+
+	if err := checkValueLen(ns, value); err != nil {
+		return Info{}, *err
+	}
+
+	if err := checkQuota(ns, owner, 1, len(value)); err != nil {
 		return Info{}, *err
 	}
 
-	dynamoDbClient := awshelper.GetDynamoDbClient(env.GetDynamoDbEndpoint())
-	valueTableName := env.GetValueTableName()
-	_, err := dynamoDbClient.PutItem(&dynamodb.PutItemInput{
-		TableName:           &valueTableName,
-		ConditionExpression: helper.StringPtr("attribute_not_exists(Id)"),
-		Item: map[string]*dynamodb.AttributeValue{
-			"Id":    {S: &id},
-			"Value": {S: &value},
+	key := ns.key(id)
+	root := rootOf(key)
+	now := time.Now()
+	hash := contentHash(value)
+	item := map[string]*dynamodb.AttributeValue{
+		"Id":        {S: &key},
+		"Root":      {S: &root},
+		"Value":     {S: &value},
+		"CreatedAt": {N: helper.StringPtr(formatTime(now))},
+		"UpdatedAt": {N: helper.StringPtr(formatTime(now))},
+		"Hash":      {S: &hash},
+		"Size":      {N: helper.StringPtr(formatSize(value))},
+		"Version":   {N: helper.StringPtr("1")},
+
+		"SchemaVersion": {N: helper.StringPtr(strconv.Itoa(SchemaVersion))},
+	}
+
+	if owner != "" {
+		item["Owner"] = &dynamodb.AttributeValue{S: &owner}
+	}
+
+	setAttributes(item, attrs)
+
+	var expiresAt time.Time
+	if ns.DefaultTTL > 0 {
+		expiresAt = now.Add(ns.DefaultTTL).Truncate(time.Second)
+		item["ExpiresAt"] = &dynamodb.AttributeValue{N: helper.StringPtr(strconv.FormatInt(expiresAt.Unix(), 10))}
+	}
+
+	valueTableName := s.name
+	transactItems := []*dynamodb.TransactWriteItem{
+		{
+			Put: &dynamodb.Put{
+				TableName:           &valueTableName,
+				ConditionExpression: helper.StringPtr("attribute_not_exists(Id)"),
+				Item:                item,
+			},
 		},
-	})
+	}
+	transactItems = append(transactItems, s.usageUpdates(ns, owner, 1, len(value))...)
+
+	err := s.transactWrite(ctx, transactItems)
+
+	if isConditionalCheckFailedAt(err, 0) {
+		return Info{}, InfoAlreadyExistsError{
+			InfoID: id,
+		}
+	}
+
+	if quotaErr := quotaExceededErrorOf(err, 1, ns, owner, 1); quotaErr != nil {
+		return Info{}, *quotaErr
+	}
 
 	if err != nil {
 		return Info{}, err
 	}
 
 	return Info{
-		ID:    id,
-		Value: value,
+		ID:          id,
+		Value:       value,
+		Owner:       owner,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   parseTime(formatTime(now)),
+		UpdatedAt:   parseTime(formatTime(now)),
+		ContentType: contentTypeOf(attrs.ContentType),
+		ContentHash: hash,
+		Version:     1,
 	}, nil
 }
 
-func (_ infoService) GetInfo(id string) (Info, error) {
-	dynamoDbClient := awshelper.GetDynamoDbClient(env.GetDynamoDbEndpoint())
-	valueTableName := env.GetValueTableName()
-	result, err := dynamoDbClient.GetItem(&dynamodb.GetItemInput{
-		TableName: &valueTableName,
-		Key: map[string]*dynamodb.AttributeValue{
-			"Id": {S: &id},
-		},
-	})
+func (s infoService) GetInfo(ctx context.Context, ns Namespace, id string) (Info, error) {
+	if err := checkID(id); err != nil {
+		return Info{}, *err
+	}
 
+	key := ns.key(id)
+	item, err := s.getItem(ctx, key)
 	if err != nil {
 		return Info{}, err
 	}
 
-	if result.Item == nil {
+	if item == nil {
 		return Info{}, InfoNotFoundError{
 			InfoID: id,
 		}
 	}
 
-	return Info{
-		ID:    id,
-		Value: *result.Item["Value"].S,
-	}, nil
+	if s.features.MigrateOnRead {
+		item = s.migrateOnRead(ctx, key, item)
+	}
+
+	return infoOf(ns, item), nil
 }
 
-func (_ infoService) UpdateInfo(id, newValue string) (Info, error) {
-	if err := checkValueLen(newValue); err != nil {
+func (s infoService) UpdateInfo(ctx context.Context, ns Namespace, principal, id, newValue string, attrs InfoAttributes) (Info, error) {
+	if err := checkID(id); err != nil {
 		return Info{}, *err
 	}
 
-	dynamoDbClient := awshelper.GetDynamoDbClient(env.GetDynamoDbEndpoint())
-	valueTableName := env.GetValueTableName()
+	if err := checkValueLen(ns, newValue); err != nil {
+		return Info{}, *err
+	}
 
-	// First check if the id exists
-	result, err := dynamoDbClient.GetItem(&dynamodb.GetItemInput{
-		TableName: &valueTableName,
-		Key: map[string]*dynamodb.AttributeValue{
-			"Id": {S: &id},
-		},
+	key := ns.key(id)
+	valueTableName := s.name
+
+	// Retry if the value is changed between reading and writing, because the
+	// usage is updated by the difference of the value lengths.
+	for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+		// First check if the id exists
+		item, err := s.getItem(ctx, key)
+		if err != nil {
+			return Info{}, err
+		}
+
+		if item == nil {
+			return Info{}, InfoNotFoundError{
+				InfoID: id,
+			}
+		}
+
+		info := infoOf(ns, item)
+		if err := checkOwner(info, principal); err != nil {
+			return Info{}, *err
+		}
+
+		bytes := len(newValue) - len(info.Value)
+		if err := checkQuota(ns, info.Owner, 0, bytes); err != nil {
+			return Info{}, *err
+		}
+
+		// If the id exists, update the value
+		now := time.Now()
+		hash := contentHash(newValue)
+		update := &dynamodb.Update{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &key},
+			},
+			ExpressionAttributeNames: map[string]*string{
+				"#Value":   helper.StringPtr("Value"),
+				"#Hash":    helper.StringPtr("Hash"),
+				"#Size":    helper.StringPtr("Size"),
+				"#Version": helper.StringPtr("Version"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":value": {S: &newValue},
+				":now":   {N: helper.StringPtr(formatTime(now))},
+				":hash":  {S: &hash},
+				":size":  {N: helper.StringPtr(formatSize(newValue))},
+				":one":   {N: helper.StringPtr("1")},
+			},
+		}
+		update.UpdateExpression = helper.StringPtr(attributesUpdate("set #Value = :value, UpdatedAt = :now, #Hash = :hash, #Size = :size, #Version = if_not_exists(#Version, :one) + :one",
+			attrs, update.ExpressionAttributeValues))
+		update.ConditionExpression = helper.StringPtr(unchangedCondition(item, update.ExpressionAttributeNames, update.ExpressionAttributeValues))
+		transactItems := []*dynamodb.TransactWriteItem{{Update: update}}
+		transactItems = append(transactItems, s.usageUpdates(ns, info.Owner, 0, bytes)...)
+
+		err = s.transactWrite(ctx, transactItems)
+
+		if isConditionalCheckFailedAt(err, 0) {
+			continue
+		}
+
+		if quotaErr := quotaExceededErrorOf(err, 1, ns, info.Owner, 0); quotaErr != nil {
+			return Info{}, *quotaErr
+		}
+
+		if err != nil {
+			return Info{}, err
+		}
+
+		info.Value = newValue
+		info.UpdatedAt = parseTime(formatTime(now))
+		info.ContentType = contentTypeOf(attrs.ContentType)
+		info.ContentHash = hash
+		info.Version++
+		return info, nil
+	}
+
+	return Info{}, errConcurrentModification
+}
+
+func (s infoService) DeleteInfo(ctx context.Context, ns Namespace, principal, id string) error {
+	if err := checkID(id); err != nil {
+		return *err
+	}
+
+	_, err := s.deleteItem(ctx, ns, principal, ns.key(id))
+	return err
+}
+
+func (s infoService) ListInfos(ctx context.Context, ns Namespace, prefix string) (InfoList, error) {
+	prefix, invalidIDErr := normalizePrefix(prefix)
+	if invalidIDErr != nil {
+		return InfoList{}, *invalidIDErr
+	}
+
+	list := InfoList{
+		Prefix:  prefix,
+		IDs:     []string{},
+		Folders: []string{},
+	}
+
+	err := s.queryKeysWithPrefix(ctx, ns.key(prefix), "", func(key string) bool {
+		child, folder := childOf(prefix, ns.idOf(key))
+		if !folder {
+			list.IDs = append(list.IDs, child)
+		} else if n := len(list.Folders); n == 0 || list.Folders[n-1] != child {
+			// Ids are sorted, so infos in the same sub folder are adjacent.
+			list.Folders = append(list.Folders, child)
+		}
+		return true
 	})
 
 	if err != nil {
-		return Info{}, err
+		return InfoList{}, err
 	}
 
-	if result.Item == nil {
-		return Info{}, InfoNotFoundError{
-			InfoID: id,
+	return list, nil
+}
+
+func (s infoService) DeleteInfos(ctx context.Context, ns Namespace, principal, prefix, after string) (DeletedInfos, error) {
+	prefix, invalidIDErr := normalizePrefix(prefix)
+	if invalidIDErr != nil {
+		return DeletedInfos{}, *invalidIDErr
+	}
+
+	startAfter := ""
+	if after != "" {
+		if !strings.HasPrefix(after, prefix) {
+			return DeletedInfos{}, InvalidIDError{
+				InfoID: after,
+				Reason: fmt.Sprintf("it is not under %s", prefix),
+			}
 		}
+
+		startAfter = ns.key(after)
 	}
 
-	// If the id exists, update the value
-	_, err = dynamoDbClient.UpdateItem(&dynamodb.UpdateItemInput{
-		TableName: &valueTableName,
-		Key: map[string]*dynamodb.AttributeValue{
-			"Id": {S: &id},
-		},
-		ConditionExpression: helper.StringPtr("attribute_exists(Id)"),
-		UpdateExpression:    helper.StringPtr("set #Value = :value"),
+	// Query one key more than deleted to know if there is a next page.
+	keys := []string{}
+	err := s.queryKeysWithPrefix(ctx, ns.key(prefix), startAfter, func(key string) bool {
+		keys = append(keys, key)
+		return len(keys) <= DeleteInfosPageSize
+	})
+
+	if err != nil {
+		return DeletedInfos{}, err
+	}
+
+	result := DeletedInfos{}
+	if len(keys) > DeleteInfosPageSize {
+		keys = keys[:DeleteInfosPageSize]
+		result.Next = ns.idOf(keys[len(keys)-1])
+	}
+
+	for _, key := range keys {
+		ok, err := s.deleteItem(ctx, ns, principal, key)
+		if err != nil {
+			return result, err
+		}
+
+		if ok {
+			result.Deleted++
+		}
+	}
+
+	return result, nil
+}
+
+// deleteItem deletes the info with the storage key on behalf of the principal
+// and updates the usage. It returns false if the info does not exist.
+func (t valueTable) deleteItem(ctx context.Context, ns Namespace, principal, key string) (bool, error) {
+	valueTableName := t.name
+
+	// Retry if the value is changed between reading and deleting, because the
+	// usage is updated by the length of the value.
+	for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+		item, err := t.getItem(ctx, key)
+		if err != nil {
+			return false, err
+		}
+
+		// Deleting an info which does not exist is not an error.
+		if item == nil {
+			return false, nil
+		}
+
+		info := infoOf(ns, item)
+		if err := checkOwner(info, principal); err != nil {
+			return false, *err
+		}
+
+		del := &dynamodb.Delete{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &key},
+			},
+			ExpressionAttributeNames:  map[string]*string{},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{},
+		}
+		del.ConditionExpression = helper.StringPtr(unchangedCondition(item, del.ExpressionAttributeNames, del.ExpressionAttributeValues))
+		transactItems := []*dynamodb.TransactWriteItem{{Delete: del}}
+		transactItems = append(transactItems, t.usageUpdates(ns, info.Owner, -1, -len(info.Value))...)
+
+		err = t.transactWrite(ctx, transactItems)
+
+		if isConditionalCheckFailedAt(err, 0) {
+			continue
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, errConcurrentModification
+}
+
+// queryKeysWithPrefix calls fn with the storage keys of the infos under the
+// prefix in ascending order, starting after the key startAfter if not empty,
+// until fn returns false. Pages are queried one by one, so that a page whose
+// query is retried is passed to fn once.
+func (t valueTable) queryKeysWithPrefix(ctx context.Context, prefix, startAfter string, fn func(key string) bool) error {
+	dynamoDbClient := t.client()
+	valueTableName := t.name
+	root := rootOf(prefix)
+	input := &dynamodb.QueryInput{
+		TableName:              &valueTableName,
+		IndexName:              helper.StringPtr(RootIndexName),
+		KeyConditionExpression: helper.StringPtr("#Root = :root AND begins_with(Id, :prefix)"),
+		ProjectionExpression:   helper.StringPtr("Id"),
 		ExpressionAttributeNames: map[string]*string{
-			"#Value": helper.StringPtr("Value"),
+			"#Root": helper.StringPtr("Root"),
 		},
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":value": {S: &newValue},
+			":root":   {S: &root},
+			":prefix": {S: &prefix},
 		},
+	}
+
+	if startAfter != "" {
+		input.ExclusiveStartKey = map[string]*dynamodb.AttributeValue{
+			"Root": {S: &root},
+			"Id":   {S: &startAfter},
+		}
+	}
+
+	for {
+		var page *dynamodb.QueryOutput
+		err := t.call(ctx, func() error {
+			var err error
+			page, err = dynamoDbClient.QueryWithContext(ctx, input)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			if !fn(*item["Id"].S) {
+				return nil
+			}
+		}
+
+		if len(page.LastEvaluatedKey) == 0 {
+			return nil
+		}
+
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
+
+// maxWriteAttempts is the max. number of attempts of a write which depends on
+// the current value, before errConcurrentModification is returned.
+const maxWriteAttempts = 3
+
+var errConcurrentModification = errors.New("info is modified concurrently")
+
+// unchangedCondition returns the condition that the item is not changed since
+// it was read, and adds its attribute names and values. Items are compared by
+// their version, or by their value if they are stored before versions were
+// tracked, so that updates of the same length are detected.
+func unchangedCondition(item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) string {
+	names["#Version"] = helper.StringPtr("Version")
+	if version := item["Version"]; version != nil && version.N != nil {
+		values[":oldVersion"] = version
+		return "attribute_exists(Id) AND #Version = :oldVersion"
+	}
+
+	names["#Value"] = helper.StringPtr("Value")
+	values[":oldValue"] = item["Value"]
+	return "attribute_exists(Id) AND attribute_not_exists(#Version) AND #Value = :oldValue"
+}
+
+// isConditionalCheckFailedAt returns if err is a canceled transaction and the
+// condition check of the i-th item failed.
+func isConditionalCheckFailedAt(err error, i int) bool {
+	canceledErr, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok || i >= len(canceledErr.CancellationReasons) {
+		return false
+	}
+
+	code := canceledErr.CancellationReasons[i].Code
+	return code != nil && *code == "ConditionalCheckFailed"
+}
+
+// getItem returns the item with the storage key, or nil if it does not exist
+// or is expired but not yet deleted by DynamoDB.
+func (t valueTable) getItem(ctx context.Context, key string) (map[string]*dynamodb.AttributeValue, error) {
+	dynamoDbClient := t.client()
+	valueTableName := t.name
+	var result *dynamodb.GetItemOutput
+	err := t.call(ctx, func() error {
+		var err error
+		result, err = dynamoDbClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &key},
+			},
+		})
+		return err
 	})
 
 	if err != nil {
-		return Info{}, err
+		return nil, err
+	}
+
+	if result.Item == nil || isExpired(result.Item) {
+		return nil, nil
+	}
+
+	return result.Item, nil
+}
+
+func infoOf(ns Namespace, item map[string]*dynamodb.AttributeValue) Info {
+	value := *item["Value"].S
+	hash := stringOf(item["Hash"])
+	if hash == "" {
+		hash = contentHash(value)
 	}
 
 	return Info{
-		ID:    id,
-		Value: newValue,
-	}, nil
+		ID:          ns.idOf(*item["Id"].S),
+		Value:       value,
+		Owner:       stringOf(item["Owner"]),
+		ExpiresAt:   expiresAtOf(item),
+		CreatedAt:   timeOf(item["CreatedAt"]),
+		UpdatedAt:   timeOf(item["UpdatedAt"]),
+		ContentType: contentTypeOf(stringOf(item["ContentType"])),
+		ContentHash: hash,
+		Version:     versionOf(item),
+	}
 }
 
-func (_ infoService) DeleteInfo(id string) error {
-	dynamoDbClient := awshelper.GetDynamoDbClient(env.GetDynamoDbEndpoint())
-	valueTableName := env.GetValueTableName()
-	_, err := dynamoDbClient.DeleteItem(&dynamodb.DeleteItemInput{
-		TableName: &valueTableName,
-		Key: map[string]*dynamodb.AttributeValue{
-			"Id": {S: &id},
-		},
-	})
+// contentTypeOf returns the content type, or DefaultContentType if empty.
+func contentTypeOf(contentType string) string {
+	if contentType == "" {
+		return DefaultContentType
+	}
 
-	return err
+	return contentType
+}
+
+// attributesUpdate returns the update expression which extends the set
+// expression by updating the content type and, if not nil, the tags. Defaults
+// are removed rather than stored.
+func attributesUpdate(set string, attrs InfoAttributes, values map[string]*dynamodb.AttributeValue) string {
+	var remove []string
+	if contentType := contentTypeOf(attrs.ContentType); contentType != DefaultContentType {
+		set += ", ContentType = :contentType"
+		values[":contentType"] = &dynamodb.AttributeValue{S: &contentType}
+	} else {
+		remove = append(remove, "ContentType")
+	}
+
+	if len(attrs.Tags) > 0 {
+		set += ", Tags = :tags"
+		values[":tags"] = tagsAttr(attrs.Tags)
+	} else if attrs.Tags != nil {
+		remove = append(remove, "Tags")
+	}
+
+	if len(remove) > 0 {
+		return set + " remove " + strings.Join(remove, ", ")
+	}
+
+	return set
+}
+
+func contentHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatTime formats the time as Unix milliseconds, the format of timestamps
+// in the value table.
+func formatTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+func parseTime(ms string) time.Time {
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n*int64(time.Millisecond))
+}
+
+func timeOf(attr *dynamodb.AttributeValue) time.Time {
+	if attr == nil || attr.N == nil {
+		return time.Time{}
+	}
+
+	return parseTime(*attr.N)
+}
+
+func expiresAtOf(item map[string]*dynamodb.AttributeValue) time.Time {
+	attr, ok := item["ExpiresAt"]
+	if !ok || attr.N == nil {
+		return time.Time{}
+	}
+
+	sec, err := strconv.ParseInt(*attr.N, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0)
+}
+
+func isExpired(item map[string]*dynamodb.AttributeValue) bool {
+	expiresAt := expiresAtOf(item)
+	return !expiresAt.IsZero() && !expiresAt.After(time.Now())
 }
 
-func checkValueLen(value string) *ValueTooLongError {
-	if l := len(value); l > ValueMaxLen {
+func checkValueLen(ns Namespace, value string) *ValueTooLongError {
+	if l := len(value); l > ns.valueMaxLen() {
 		return &ValueTooLongError{
-			AllowedLen: ValueMaxLen,
+			AllowedLen: ns.valueMaxLen(),
 			ActualLen:  l,
 		}
 	}
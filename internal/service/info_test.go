@@ -0,0 +1,167 @@
+package service
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("infoOf()", func() {
+	var item map[string]*dynamodb.AttributeValue
+
+	BeforeEach(func() {
+		item = map[string]*dynamodb.AttributeValue{
+			"Id":    {S: stringPtr("team-a#a/b")},
+			"Value": {S: stringPtr("value")},
+		}
+	})
+
+	It("should return the info in the namespace", func() {
+		info := infoOf(Namespace{Name: "team-a"}, item)
+		Expect(info.ID).To(Equal("a/b"))
+		Expect(info.Value).To(Equal("value"))
+	})
+
+	It("should return the stored timestamps and hash", func() {
+		item["CreatedAt"] = &dynamodb.AttributeValue{N: stringPtr("1614592800000")}
+		item["UpdatedAt"] = &dynamodb.AttributeValue{N: stringPtr("1614592800500")}
+		item["Hash"] = &dynamodb.AttributeValue{S: stringPtr("hash")}
+
+		info := infoOf(Namespace{Name: "team-a"}, item)
+		Expect(info.CreatedAt).To(BeTemporally("==", time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)))
+		Expect(info.UpdatedAt).To(BeTemporally("==", time.Date(2021, 3, 1, 10, 0, 0, 500*int(time.Millisecond), time.UTC)))
+		Expect(info.ContentHash).To(Equal("hash"))
+	})
+
+	It("should compute the hash of infos stored without hash", func() {
+		info := infoOf(Namespace{Name: "team-a"}, item)
+		Expect(info.ContentHash).To(Equal(contentHash("value")))
+		Expect(info.ContentHash).To(HaveLen(64))
+		Expect(info.CreatedAt.IsZero()).To(BeTrue())
+	})
+})
+
+var _ = Describe("formatTime()", func() {
+	It("should be reversed by parseTime() with millisecond resolution", func() {
+		t := time.Date(2021, 3, 1, 10, 0, 0, 123456789, time.UTC)
+		Expect(parseTime(formatTime(t))).To(BeTemporally("==", t.Truncate(time.Millisecond)))
+	})
+})
+
+var _ = Describe("infoMetaOf()", func() {
+	It("should return the stored metadata", func() {
+		meta := infoMetaOf(Namespace{Name: "team-a"}, map[string]*dynamodb.AttributeValue{
+			"Id":          {S: stringPtr("team-a#a/b")},
+			"Size":        {N: stringPtr("5")},
+			"Version":     {N: stringPtr("3")},
+			"Hash":        {S: stringPtr("hash")},
+			"ContentType": {S: stringPtr("application/json")},
+			"Tags": {M: map[string]*dynamodb.AttributeValue{
+				"env": {S: stringPtr("prod")},
+			}},
+		})
+
+		Expect(meta.ID).To(Equal("a/b"))
+		Expect(meta.Size).To(Equal(int64(5)))
+		Expect(meta.Version).To(Equal(int64(3)))
+		Expect(meta.ContentHash).To(Equal("hash"))
+		Expect(meta.ContentType).To(Equal("application/json"))
+		Expect(meta.Tags).To(Equal(map[string]string{"env": "prod"}))
+	})
+
+	It("should fill in defaults of infos stored without metadata", func() {
+		meta := infoMetaOf(Namespace{}, map[string]*dynamodb.AttributeValue{
+			"Id":    {S: stringPtr("a")},
+			"Value": {S: stringPtr("value")},
+		})
+
+		Expect(meta.Size).To(Equal(int64(5)))
+		Expect(meta.Version).To(Equal(int64(1)))
+		Expect(meta.ContentHash).To(Equal(contentHash("value")))
+		Expect(meta.ContentType).To(Equal(DefaultContentType))
+		Expect(meta.Tags).To(BeEmpty())
+	})
+})
+
+var _ = Describe("unchangedCondition()", func() {
+	var (
+		names  map[string]*string
+		values map[string]*dynamodb.AttributeValue
+	)
+
+	BeforeEach(func() {
+		names = map[string]*string{}
+		values = map[string]*dynamodb.AttributeValue{}
+	})
+
+	It("should compare the version", func() {
+		condition := unchangedCondition(map[string]*dynamodb.AttributeValue{
+			"Id":      {S: stringPtr("a")},
+			"Value":   {S: stringPtr("value")},
+			"Version": {N: stringPtr("3")},
+		}, names, values)
+
+		Expect(condition).To(Equal("attribute_exists(Id) AND #Version = :oldVersion"))
+		Expect(*names["#Version"]).To(Equal("Version"))
+		Expect(*values[":oldVersion"].N).To(Equal("3"))
+	})
+
+	It("should compare the value of infos stored without version", func() {
+		condition := unchangedCondition(map[string]*dynamodb.AttributeValue{
+			"Id":    {S: stringPtr("a")},
+			"Value": {S: stringPtr("value")},
+		}, names, values)
+
+		Expect(condition).To(Equal("attribute_exists(Id) AND attribute_not_exists(#Version) AND #Value = :oldValue"))
+		Expect(*names["#Value"]).To(Equal("Value"))
+		Expect(*values[":oldValue"].S).To(Equal("value"))
+	})
+})
+
+var _ = Describe("checkOwner()", func() {
+	It("should allow all principals to modify infos without owner", func() {
+		Expect(checkOwner(Info{ID: "a"}, "")).To(BeNil())
+		Expect(checkOwner(Info{ID: "a"}, "key-b")).To(BeNil())
+	})
+
+	It("should only allow the owner and the admin to modify owned infos", func() {
+		info := Info{ID: "a", Owner: "key-a"}
+		Expect(checkOwner(info, "key-a")).To(BeNil())
+		Expect(checkOwner(info, AdminPrincipal)).To(BeNil())
+		Expect(checkOwner(info, "key-b")).To(Equal(&NotOwnerError{InfoID: "a"}))
+		Expect(checkOwner(info, "")).To(Equal(&NotOwnerError{InfoID: "a"}))
+	})
+})
+
+var _ = Describe("attributesUpdate()", func() {
+	const set = "set #Value = :value"
+	var values map[string]*dynamodb.AttributeValue
+
+	BeforeEach(func() {
+		values = map[string]*dynamodb.AttributeValue{}
+	})
+
+	It("should set the content type and tags", func() {
+		update := attributesUpdate(set, InfoAttributes{
+			ContentType: "application/json",
+			Tags:        map[string]string{"env": "prod"},
+		}, values)
+
+		Expect(update).To(Equal(set + ", ContentType = :contentType, Tags = :tags"))
+		Expect(*values[":contentType"].S).To(Equal("application/json"))
+		Expect(*values[":tags"].M["env"].S).To(Equal("prod"))
+	})
+
+	It("should remove the default content type and keep tags if nil", func() {
+		update := attributesUpdate(set, InfoAttributes{ContentType: DefaultContentType}, values)
+		Expect(update).To(Equal(set + " remove ContentType"))
+		Expect(values).To(BeEmpty())
+	})
+
+	It("should remove empty tags", func() {
+		update := attributesUpdate(set, InfoAttributes{Tags: map[string]string{}}, values)
+		Expect(update).To(Equal(set + " remove ContentType, Tags"))
+	})
+})
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"simple-information-store-app/internal/helper"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DefaultContentType is the content type of infos stored without one.
+const DefaultContentType = "text/plain; charset=utf-8"
+
+// InfoMeta presents the metadata of an info without its value.
+type InfoMeta struct {
+	ID string
+
+	// Size is the length of the value in bytes.
+	Size int64
+
+	ContentType string
+	Owner       string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// Version starts at 1 and is incremented by each update of the value.
+	Version int64
+
+	ContentHash string
+	Tags        map[string]string
+}
+
+// InfoAttributes are the metadata of an info sent together with its value.
+type InfoAttributes struct {
+	// ContentType is the media type of the value, DefaultContentType if empty.
+	ContentType string
+
+	// Tags are key value pairs of the client. Updates keep the tags of the
+	// info if nil, and remove them if empty.
+	Tags map[string]string
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoMetaGetter
+
+type InfoMetaGetter interface {
+	// GetInfoMeta returns the metadata of the info with the given id in the
+	// namespace. The value is not read.
+	// InvalidIDError is returned if the id is not a valid path.
+	// InfoNotFoundError is returned if the info does not exist or is expired.
+	GetInfoMeta(ctx context.Context, ns Namespace, id string) (InfoMeta, error)
+}
+
+// metaProjection is the projection expression of all attributes of an info
+// except the value.
+const metaProjection = "Id, #Owner, ExpiresAt, CreatedAt, UpdatedAt, #Hash, #Size, #Version, ContentType, Tags"
+
+var metaAttributeNames = map[string]*string{
+	"#Owner":   helper.StringPtr("Owner"),
+	"#Hash":    helper.StringPtr("Hash"),
+	"#Size":    helper.StringPtr("Size"),
+	"#Version": helper.StringPtr("Version"),
+}
+
+func (s infoService) GetInfoMeta(ctx context.Context, ns Namespace, id string) (InfoMeta, error) {
+	if err := checkID(id); err != nil {
+		return InfoMeta{}, *err
+	}
+
+	key := ns.key(id)
+	dynamoDbClient := s.client()
+	valueTableName := s.name
+	var result *dynamodb.GetItemOutput
+	err := s.call(ctx, func() error {
+		var err error
+		result, err = dynamoDbClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &key},
+			},
+			ProjectionExpression:     helper.StringPtr(metaProjection),
+			ExpressionAttributeNames: metaAttributeNames,
+		})
+		return err
+	})
+
+	if err != nil {
+		return InfoMeta{}, err
+	}
+
+	item := result.Item
+	if item == nil || isExpired(item) {
+		return InfoMeta{}, InfoNotFoundError{
+			InfoID: id,
+		}
+	}
+
+	// Infos stored before the size and hash were tracked have to be read
+	// completely once to compute them.
+	if item["Size"] == nil || item["Hash"] == nil {
+		item, err = s.getItem(ctx, key)
+		if err != nil {
+			return InfoMeta{}, err
+		}
+
+		if item == nil {
+			return InfoMeta{}, InfoNotFoundError{
+				InfoID: id,
+			}
+		}
+	}
+
+	return infoMetaOf(ns, item), nil
+}
+
+// infoMetaOf returns the metadata of an item read with or without the value.
+func infoMetaOf(ns Namespace, item map[string]*dynamodb.AttributeValue) InfoMeta {
+	meta := InfoMeta{
+		ID:          ns.idOf(*item["Id"].S),
+		Size:        int64Of(item["Size"]),
+		ContentType: contentTypeOf(stringOf(item["ContentType"])),
+		Owner:       stringOf(item["Owner"]),
+		ExpiresAt:   expiresAtOf(item),
+		CreatedAt:   timeOf(item["CreatedAt"]),
+		UpdatedAt:   timeOf(item["UpdatedAt"]),
+		Version:     versionOf(item),
+		ContentHash: stringOf(item["Hash"]),
+		Tags:        tagsOf(item),
+	}
+
+	if value, ok := item["Value"]; ok && value.S != nil {
+		meta.Size = int64(len(*value.S))
+		if meta.ContentHash == "" {
+			meta.ContentHash = contentHash(*value.S)
+		}
+	}
+
+	return meta
+}
+
+// versionOf returns the version of the item, 1 for infos stored before
+// versions were tracked.
+func versionOf(item map[string]*dynamodb.AttributeValue) int64 {
+	if version := int64Of(item["Version"]); version > 0 {
+		return version
+	}
+
+	return 1
+}
+
+func tagsOf(item map[string]*dynamodb.AttributeValue) map[string]string {
+	tags := map[string]string{}
+	if attr, ok := item["Tags"]; ok {
+		for k, v := range attr.M {
+			tags[k] = stringOf(v)
+		}
+	}
+
+	return tags
+}
+
+// tagsAttr returns the attribute value of the tags.
+func tagsAttr(tags map[string]string) *dynamodb.AttributeValue {
+	attrs := map[string]*dynamodb.AttributeValue{}
+	for k, v := range tags {
+		attrs[k] = &dynamodb.AttributeValue{S: helper.StringPtr(v)}
+	}
+
+	return &dynamodb.AttributeValue{M: attrs}
+}
+
+// setAttributes sets the content type and tags of the item, unless they are
+// the defaults.
+func setAttributes(item map[string]*dynamodb.AttributeValue, attrs InfoAttributes) {
+	if attrs.ContentType != "" && attrs.ContentType != DefaultContentType {
+		item["ContentType"] = &dynamodb.AttributeValue{S: helper.StringPtr(attrs.ContentType)}
+	}
+
+	if len(attrs.Tags) > 0 {
+		item["Tags"] = tagsAttr(attrs.Tags)
+	}
+}
+
+func formatSize(value string) string {
+	return strconv.Itoa(len(value))
+}
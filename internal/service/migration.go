@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// migration upgrades items of the previous schema version to its version.
+type migration struct {
+	version     int
+	description string
+
+	// apply adds the missing attributes to the item with the storage key.
+	// It only adds attributes, so that applying it again or to an item
+	// updated concurrently does not change anything.
+	apply func(key string, item map[string]*dynamodb.AttributeValue)
+}
+
+// migrations are the migrations in ascending order of their versions. The
+// last version is SchemaVersion. Items without SchemaVersion are of version 0.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "add Root, Hash, Size and Version of infos stored before they were tracked",
+		apply: func(key string, item map[string]*dynamodb.AttributeValue) {
+			value := *item["Value"].S
+			setIfMissing(item, "Root", &dynamodb.AttributeValue{S: helper.StringPtr(rootOf(key))})
+			setIfMissing(item, "Hash", &dynamodb.AttributeValue{S: helper.StringPtr(contentHash(value))})
+			setIfMissing(item, "Size", &dynamodb.AttributeValue{N: helper.StringPtr(formatSize(value))})
+			setIfMissing(item, "Version", &dynamodb.AttributeValue{N: helper.StringPtr("1")})
+		},
+	},
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoMigrator
+
+type InfoMigrator interface {
+	// MigrateInfos migrates the items of all namespaces older than
+	// SchemaVersion, scanning the table in the parallel segments of the
+	// checkpoint. fn is called with the number of items migrated of each page
+	// and the checkpoint after it, from which a later call resumes. Calls of
+	// fn are serialized.
+	MigrateInfos(ctx context.Context, checkpoint ExportCheckpoint, fn func(migrated int, checkpoint ExportCheckpoint) error) error
+}
+
+type migrationService struct {
+	valueTable
+}
+
+func NewMigrationService(c config.Config) InfoMigrator {
+	return migrationService{valueTable: valueTableOf(c).asBatch()}
+}
+
+func (s migrationService) MigrateInfos(ctx context.Context, checkpoint ExportCheckpoint, fn func(migrated int, checkpoint ExportCheckpoint) error) error {
+	input := dynamodb.ScanInput{
+		FilterExpression: helper.StringPtr("attribute_exists(#Value) AND (attribute_not_exists(SchemaVersion) OR SchemaVersion < :schema)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#Value": helper.StringPtr("Value"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":schema": {N: helper.StringPtr(strconv.Itoa(SchemaVersion))},
+		},
+	}
+
+	return s.scanTable(ctx, checkpoint, input, func(items []map[string]*dynamodb.AttributeValue, checkpoint ExportCheckpoint) error {
+		migrated := 0
+		for _, item := range items {
+			key := stringOf(item["Id"])
+			if strings.HasPrefix(key, SystemKeyPrefix) {
+				continue
+			}
+
+			_, added := migrateItem(key, item)
+			ok, err := s.saveMigration(ctx, key, added)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				migrated++
+			}
+		}
+
+		return fn(migrated, checkpoint)
+	})
+}
+
+// migrateOnRead migrates the item read with the storage key if it is older
+// than SchemaVersion, and returns the migrated item. The item is returned
+// even if saving the migration fails, which is then retried by the next read
+// or the backfill.
+func (t valueTable) migrateOnRead(ctx context.Context, key string, item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if schemaVersionOf(item) >= SchemaVersion {
+		return item
+	}
+
+	migrated, added := migrateItem(key, item)
+	t.saveMigration(ctx, key, added)
+	return migrated
+}
+
+// migrateItem applies the migrations newer than the version of the item to a
+// copy of it. It returns the migrated item and the attributes added to it,
+// including SchemaVersion.
+func migrateItem(key string, item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue) {
+	migrated := make(map[string]*dynamodb.AttributeValue, len(item)+1)
+	for name, attr := range item {
+		migrated[name] = attr
+	}
+
+	version := schemaVersionOf(item)
+	for _, m := range migrations {
+		if m.version > version {
+			m.apply(key, migrated)
+		}
+	}
+
+	migrated["SchemaVersion"] = &dynamodb.AttributeValue{N: helper.StringPtr(strconv.Itoa(SchemaVersion))}
+
+	added := map[string]*dynamodb.AttributeValue{}
+	for name, attr := range migrated {
+		if _, ok := item[name]; !ok || name == "SchemaVersion" {
+			added[name] = attr
+		}
+	}
+
+	return migrated, added
+}
+
+// saveMigration adds the attributes to the item with the storage key and
+// returns if it is migrated, false if the item is deleted or migrated by
+// another read or backfill in the meantime. Attributes written concurrently
+// are kept.
+func (t valueTable) saveMigration(ctx context.Context, key string, added map[string]*dynamodb.AttributeValue) (bool, error) {
+	dynamoDbClient := t.client()
+	err := t.call(ctx, func() error {
+		_, err := dynamoDbClient.UpdateItemWithContext(ctx, t.migrationUpdateOf(key, added))
+		return err
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// migrationUpdateOf returns the update which adds the attributes to the item
+// with the storage key, unless it already has them, and sets SchemaVersion.
+func (t valueTable) migrationUpdateOf(key string, added map[string]*dynamodb.AttributeValue) *dynamodb.UpdateItemInput {
+	names := make([]string, 0, len(added))
+	for name := range added {
+		if name != "SchemaVersion" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	schemaVersion := strconv.Itoa(SchemaVersion)
+	sets := []string{"SchemaVersion = :schema"}
+	attributeNames := map[string]*string{}
+	attributeValues := map[string]*dynamodb.AttributeValue{
+		":schema": {N: &schemaVersion},
+	}
+
+	for i, name := range names {
+		sets = append(sets, fmt.Sprintf("#a%d = if_not_exists(#a%d, :a%d)", i, i, i))
+		attributeNames[fmt.Sprintf("#a%d", i)] = helper.StringPtr(name)
+		attributeValues[fmt.Sprintf(":a%d", i)] = added[name]
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: helper.StringPtr(t.name),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Id": {S: &key},
+		},
+		ConditionExpression:       helper.StringPtr("attribute_exists(Id) AND (attribute_not_exists(SchemaVersion) OR SchemaVersion < :schema)"),
+		UpdateExpression:          helper.StringPtr("SET " + strings.Join(sets, ", ")),
+		ExpressionAttributeValues: attributeValues,
+	}
+
+	if len(attributeNames) > 0 {
+		input.ExpressionAttributeNames = attributeNames
+	}
+
+	return input
+}
+
+// schemaVersionOf returns the schema version of the item, 0 for items stored
+// before versions were tracked.
+func schemaVersionOf(item map[string]*dynamodb.AttributeValue) int {
+	return int(int64Of(item["SchemaVersion"]))
+}
+
+func setIfMissing(item map[string]*dynamodb.AttributeValue, name string, attr *dynamodb.AttributeValue) {
+	if _, ok := item[name]; !ok {
+		item[name] = attr
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("migrations", func() {
+	It("should be in ascending order up to SchemaVersion", func() {
+		for i, m := range migrations {
+			Expect(m.version).To(Equal(i + 1))
+		}
+
+		Expect(migrations[len(migrations)-1].version).To(Equal(SchemaVersion))
+	})
+})
+
+var _ = Describe("migrateItem()", func() {
+	var legacy map[string]*dynamodb.AttributeValue
+
+	BeforeEach(func() {
+		legacy = map[string]*dynamodb.AttributeValue{
+			"Id":    {S: stringPtr("team-a#a/b")},
+			"Value": {S: stringPtr("value")},
+		}
+	})
+
+	It("should add the missing attributes of infos stored before they were tracked", func() {
+		migrated, added := migrateItem("team-a#a/b", legacy)
+
+		Expect(*migrated["Root"].S).To(Equal("team-a#a"))
+		Expect(*migrated["Hash"].S).To(Equal(contentHash("value")))
+		Expect(*migrated["Size"].N).To(Equal("5"))
+		Expect(*migrated["Version"].N).To(Equal("1"))
+		Expect(*migrated["SchemaVersion"].N).To(Equal("1"))
+		Expect(added).To(HaveLen(5))
+		Expect(added).To(HaveKey("Root"))
+		Expect(added).NotTo(HaveKey("Value"))
+	})
+
+	It("should not modify the item", func() {
+		migrateItem("team-a#a/b", legacy)
+		Expect(legacy).To(HaveLen(2))
+	})
+
+	It("should keep existing attributes", func() {
+		legacy["Version"] = &dynamodb.AttributeValue{N: stringPtr("3")}
+		migrated, added := migrateItem("team-a#a/b", legacy)
+
+		Expect(*migrated["Version"].N).To(Equal("3"))
+		Expect(added).NotTo(HaveKey("Version"))
+	})
+
+	It("should be idempotent", func() {
+		migrated, _ := migrateItem("team-a#a/b", legacy)
+		again, added := migrateItem("team-a#a/b", migrated)
+
+		Expect(again).To(Equal(migrated))
+		Expect(added).To(HaveLen(1))
+		Expect(added).To(HaveKey("SchemaVersion"))
+	})
+})
+
+var _ = Describe("migrationUpdateOf()", func() {
+	table := valueTable{name: "table"}
+
+	It("should add the attributes unless they exist", func() {
+		_, added := migrateItem("a", map[string]*dynamodb.AttributeValue{
+			"Id":    {S: stringPtr("a")},
+			"Value": {S: stringPtr("value")},
+			"Root":  {S: stringPtr("a")},
+			"Size":  {N: stringPtr("5")},
+		})
+		input := table.migrationUpdateOf("a", added)
+
+		Expect(*input.TableName).To(Equal("table"))
+		Expect(*input.Key["Id"].S).To(Equal("a"))
+		Expect(*input.UpdateExpression).To(Equal("SET SchemaVersion = :schema, #a0 = if_not_exists(#a0, :a0), #a1 = if_not_exists(#a1, :a1)"))
+		Expect(*input.ExpressionAttributeNames["#a0"]).To(Equal("Hash"))
+		Expect(*input.ExpressionAttributeNames["#a1"]).To(Equal("Version"))
+		Expect(*input.ExpressionAttributeValues[":a1"].N).To(Equal("1"))
+		Expect(*input.ExpressionAttributeValues[":schema"].N).To(Equal("1"))
+		Expect(*input.ConditionExpression).To(ContainSubstring("SchemaVersion < :schema"))
+	})
+
+	It("should only set the schema version if no attribute is added", func() {
+		input := table.migrationUpdateOf("a", map[string]*dynamodb.AttributeValue{
+			"SchemaVersion": {N: stringPtr("1")},
+		})
+
+		Expect(*input.UpdateExpression).To(Equal("SET SchemaVersion = :schema"))
+		Expect(input.ExpressionAttributeNames).To(BeNil())
+	})
+})
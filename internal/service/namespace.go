@@ -0,0 +1,185 @@
+package service
+
+import (
+	"fmt"
+	"simple-information-store-app/internal/config"
+	"strings"
+	"time"
+)
+
+// DefaultNamespace is the namespace of infos accessed without namespace, e.g. /i/{id}.
+const DefaultNamespace = "default"
+
+// NamespaceSeparator separates the namespace from the id in the storage key.
+// It is not allowed in ids, so that keys of different namespaces never collide.
+const NamespaceSeparator = "#"
+
+// SystemKeyPrefix is the prefix of storage keys reserved for items which are
+// not infos, e.g. usage counters. Namespace names cannot be empty, so the keys
+// never collide with infos.
+const SystemKeyPrefix = NamespaceSeparator
+
+// Namespace presents an isolated set of infos with its own limits.
+// The zero value is the default namespace with default limits.
+type Namespace struct {
+	Name string
+
+	// ValueMaxLen is the max. length of values, ValueMaxLen constant if 0.
+	ValueMaxLen int
+
+	// DefaultTTL is the time to live of new infos, infinite if 0.
+	DefaultTTL time.Duration
+
+	// AuthMethods are the allowed authentication methods, all if empty.
+	AuthMethods []string
+
+	// Principals are the identities allowed to access the namespace, all if
+	// empty.
+	Principals []string
+
+	// ItemQuota is the max. number of infos, unlimited if 0.
+	ItemQuota int
+
+	// ByteQuota is the max. total length of values, unlimited if 0.
+	ByteQuota int64
+
+	// OwnerItemQuota is the max. number of infos per owner, unlimited if 0.
+	OwnerItemQuota int
+
+	// OwnerByteQuota is the max. total length of values per owner, unlimited if 0.
+	OwnerByteQuota int64
+}
+
+// AllowsAuthMethod returns if requests authenticated with the method may access the namespace.
+func (ns Namespace) AllowsAuthMethod(method string) bool {
+	if len(ns.AuthMethods) == 0 {
+		return true
+	}
+
+	for _, m := range ns.AuthMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsPrincipal returns if requests authenticated as the principal may
+// access the namespace. Anonymous requests, whose principal is empty, are
+// not allowed if the namespace is restricted to principals.
+func (ns Namespace) AllowsPrincipal(principal string) bool {
+	if len(ns.Principals) == 0 {
+		return true
+	}
+
+	for _, p := range ns.Principals {
+		if p == principal {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ns Namespace) valueMaxLen() int {
+	if ns.ValueMaxLen > 0 {
+		return ns.ValueMaxLen
+	}
+
+	return ValueMaxLen
+}
+
+func (ns Namespace) name() string {
+	if ns.Name == "" {
+		return DefaultNamespace
+	}
+
+	return ns.Name
+}
+
+// keyPrefix returns the prefix of storage keys of the namespace. Infos in the
+// default namespace are stored without prefix.
+func (ns Namespace) keyPrefix() string {
+	if ns.name() == DefaultNamespace {
+		return ""
+	}
+
+	return ns.Name + NamespaceSeparator
+}
+
+// key returns the storage key of the info id.
+func (ns Namespace) key(id string) string {
+	return ns.keyPrefix() + id
+}
+
+// idOf returns the info id of the storage key.
+func (ns Namespace) idOf(key string) string {
+	return strings.TrimPrefix(key, ns.keyPrefix())
+}
+
+// InvalidNamespaceError indicates that the name of the namespace is invalid,
+// e.g. of an imported record.
+type InvalidNamespaceError struct {
+	Namespace string
+}
+
+func (err InvalidNamespaceError) Error() string {
+	return fmt.Sprintf("Namespace %s is invalid.", err.Namespace)
+}
+
+// NamespaceNotFoundError indicates that the namespace is not configured.
+type NamespaceNotFoundError struct {
+	Namespace string
+}
+
+func (err NamespaceNotFoundError) Error() string {
+	return fmt.Sprintf("Namespace %s does not exist.", err.Namespace)
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . NamespaceGetter
+
+type NamespaceGetter interface {
+	// GetNamespace returns the configuration of the namespace. The default
+	// namespace is returned if name is empty.
+	// NamespaceNotFoundError is returned if the namespace is not configured.
+	GetNamespace(name string) (Namespace, error)
+}
+
+type namespaceGetter struct {
+	namespaces map[string]config.Namespace
+}
+
+func NewNamespaceGetter(c config.Config) NamespaceGetter {
+	return namespaceGetter{namespaces: c.Namespaces}
+}
+
+func (g namespaceGetter) GetNamespace(name string) (Namespace, error) {
+	if name == "" {
+		name = DefaultNamespace
+	}
+
+	nsConfig, ok := g.namespaces[name]
+	if !ok && name != DefaultNamespace {
+		return Namespace{}, NamespaceNotFoundError{
+			Namespace: name,
+		}
+	}
+
+	defaultTTL, err := nsConfig.ParseDefaultTTL()
+	if err != nil {
+		return Namespace{}, fmt.Errorf("invalid default TTL of namespace %s: %w", name, err)
+	}
+
+	return Namespace{
+		Name:           name,
+		ValueMaxLen:    nsConfig.ValueMaxLen,
+		DefaultTTL:     defaultTTL,
+		AuthMethods:    nsConfig.AuthMethods,
+		Principals:     nsConfig.Principals,
+		ItemQuota:      nsConfig.ItemQuota,
+		ByteQuota:      nsConfig.ByteQuota,
+		OwnerItemQuota: nsConfig.OwnerItemQuota,
+		OwnerByteQuota: nsConfig.OwnerByteQuota,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"simple-information-store-app/internal/config"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Namespace", func() {
+	Describe("key()", func() {
+		It("should not prefix keys in the default namespace", func() {
+			Expect(Namespace{}.key("a/b")).To(Equal("a/b"))
+			Expect(Namespace{Name: DefaultNamespace}.key("a/b")).To(Equal("a/b"))
+		})
+
+		It("should prefix keys in other namespaces", func() {
+			ns := Namespace{Name: "team-a"}
+			Expect(ns.key("a/b")).To(Equal("team-a#a/b"))
+			Expect(ns.idOf(ns.key("a/b"))).To(Equal("a/b"))
+		})
+	})
+
+	Describe("AllowsAuthMethod()", func() {
+		It("should allow all methods if none is configured", func() {
+			Expect(Namespace{}.AllowsAuthMethod("none")).To(BeTrue())
+		})
+
+		It("should only allow the configured methods", func() {
+			ns := Namespace{AuthMethods: []string{"api_key"}}
+			Expect(ns.AllowsAuthMethod("api_key")).To(BeTrue())
+			Expect(ns.AllowsAuthMethod("none")).To(BeFalse())
+		})
+	})
+
+	Describe("AllowsPrincipal()", func() {
+		It("should allow all principals if none is configured", func() {
+			Expect(Namespace{}.AllowsPrincipal("")).To(BeTrue())
+			Expect(Namespace{}.AllowsPrincipal("key-id")).To(BeTrue())
+		})
+
+		It("should only allow the configured principals", func() {
+			ns := Namespace{Principals: []string{"key-a"}}
+			Expect(ns.AllowsPrincipal("key-a")).To(BeTrue())
+			Expect(ns.AllowsPrincipal("key-b")).To(BeFalse())
+			Expect(ns.AllowsPrincipal("")).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("GetNamespace()", func() {
+	var (
+		c    config.Config
+		name string
+		ns   Namespace
+		err  error
+	)
+
+	BeforeEach(func() {
+		name = ""
+		c = config.Default()
+		c.Namespaces = map[string]config.Namespace{
+			"team-a": {ValueMaxLen: 5000, DefaultTTL: "24h", AuthMethods: []string{"api_key"}, Principals: []string{"key-id"}, ItemQuota: 10},
+		}
+	})
+
+	JustBeforeEach(func() {
+		ns, err = NewNamespaceGetter(c).GetNamespace(name)
+	})
+
+	When("name is empty", func() {
+		It("should return the default namespace", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ns.Name).To(Equal(DefaultNamespace))
+			Expect(ns.valueMaxLen()).To(Equal(ValueMaxLen))
+		})
+	})
+
+	When("the namespace is configured", func() {
+		BeforeEach(func() {
+			name = "team-a"
+		})
+
+		It("should return its configuration", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ns).To(Equal(Namespace{
+				Name:        "team-a",
+				ValueMaxLen: 5000,
+				DefaultTTL:  24 * time.Hour,
+				AuthMethods: []string{"api_key"},
+				Principals:  []string{"key-id"},
+				ItemQuota:   10,
+			}))
+		})
+	})
+
+	When("the namespace is not configured", func() {
+		BeforeEach(func() {
+			name = "team-b"
+		})
+
+		It("should return NamespaceNotFoundError", func() {
+			Expect(err).To(Equal(NamespaceNotFoundError{Namespace: "team-b"}))
+		})
+	})
+
+	When("the configuration is invalid", func() {
+		BeforeEach(func() {
+			c.Namespaces["team-a"] = config.Namespace{DefaultTTL: "a day"}
+			name = "team-a"
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
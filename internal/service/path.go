@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathSeparator separates the segments of a hierarchical info id, e.g. "a/b/c".
+const PathSeparator = "/"
+
+// MetaSegment is the last segment of the path of the metadata of an info,
+// e.g. "a/b/meta" for the info "a/b". Ids of infos cannot end with it, except
+// for the id consisting of it only.
+const MetaSegment = "meta"
+
+// InvalidIDError indicates that the info id or prefix is not a valid path.
+type InvalidIDError struct {
+	InfoID string
+	Reason string
+}
+
+func (err InvalidIDError) Error() string {
+	return fmt.Sprintf("Id %s is invalid: %s.", err.InfoID, err.Reason)
+}
+
+// checkID checks if the id is a valid path of an info, i.e. non-empty segments
+// separated by PathSeparator, without leading or trailing separator, without
+// NamespaceSeparator and not ending with MetaSegment.
+func checkID(id string) *InvalidIDError {
+	if err := checkPath(id); err != nil {
+		return err
+	}
+
+	if _, ok := MetaIDOf(id); ok {
+		return &InvalidIDError{
+			InfoID: id,
+			Reason: fmt.Sprintf("the last path segment %q is reserved", MetaSegment),
+		}
+	}
+
+	return nil
+}
+
+// checkPath checks if the path is valid like checkID, but it may end with
+// MetaSegment, e.g. as a folder.
+func checkPath(id string) *InvalidIDError {
+	if l := len(id); l > IDMaxLen {
+		return &InvalidIDError{
+			InfoID: id,
+			Reason: fmt.Sprintf("the length is %d, however max. %d allowed", l, IDMaxLen),
+		}
+	}
+
+	if strings.Contains(id, NamespaceSeparator) {
+		return &InvalidIDError{
+			InfoID: id,
+			Reason: fmt.Sprintf("%q is not allowed", NamespaceSeparator),
+		}
+	}
+
+	for _, segment := range strings.Split(id, PathSeparator) {
+		if segment == "" {
+			return &InvalidIDError{
+				InfoID: id,
+				Reason: "empty path segment",
+			}
+		}
+	}
+
+	return nil
+}
+
+// MetaIDOf returns the id of the info whose metadata path is id, e.g. "a/b"
+// for "a/b/meta". ok is false if id is not a metadata path.
+func MetaIDOf(id string) (infoID string, ok bool) {
+	suffix := PathSeparator + MetaSegment
+	if !strings.HasSuffix(id, suffix) || id == suffix {
+		return "", false
+	}
+
+	return strings.TrimSuffix(id, suffix), true
+}
+
+// normalizePrefix turns a folder path like "a/b" or "a/b/" into "a/b/" and
+// checks if it is valid. The root is not a valid prefix, because infos are
+// only queried within their top-level folder.
+func normalizePrefix(prefix string) (string, *InvalidIDError) {
+	trimmed := strings.TrimSuffix(prefix, PathSeparator)
+	if trimmed == "" {
+		return "", &InvalidIDError{
+			InfoID: prefix,
+			Reason: "the root cannot be listed or deleted, only folders under it",
+		}
+	}
+
+	if err := checkPath(trimmed); err != nil {
+		return "", &InvalidIDError{
+			InfoID: prefix,
+			Reason: err.Reason,
+		}
+	}
+
+	return trimmed + PathSeparator, nil
+}
+
+// rootOf returns the first segment of the path. It is used as the partition
+// key of the root index, so that all infos under a folder can be queried.
+func rootOf(path string) string {
+	return strings.SplitN(path, PathSeparator, 2)[0]
+}
+
+// childOf returns the direct child of the prefix that id is in. folder is true
+// if id is not a direct child, in which case child is the sub folder with
+// trailing separator.
+func childOf(prefix, id string) (child string, folder bool) {
+	rest := strings.TrimPrefix(id, prefix)
+	if i := strings.Index(rest, PathSeparator); i >= 0 {
+		return prefix + rest[:i+1], true
+	}
+
+	return id, false
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checkID()", func() {
+	DescribeTable("valid ids",
+		func(id string) {
+			Expect(checkID(id)).To(BeNil())
+		},
+		Entry("single segment", "a"),
+		Entry("multiple segments", "a/b/c"),
+		Entry("UUID", "3f1b4a8e-0f6d-4a2b-9c1e-2b7d5f0a9e11"),
+		Entry("meta segment only", "meta"),
+		Entry("meta segment as folder", "a/meta/b"),
+	)
+
+	DescribeTable("invalid ids",
+		func(id string) {
+			Expect(checkID(id)).NotTo(BeNil())
+		},
+		Entry("empty", ""),
+		Entry("leading separator", "/a"),
+		Entry("trailing separator", "a/"),
+		Entry("empty segment", "a//b"),
+		Entry("namespace separator", "team-a#b"),
+		Entry("too long", strings.Repeat("x", IDMaxLen+1)),
+		Entry("meta segment at the end", "a/meta"),
+	)
+})
+
+var _ = Describe("MetaIDOf()", func() {
+	It("should return the id of a metadata path", func() {
+		id, ok := MetaIDOf("a/b/meta")
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal("a/b"))
+	})
+
+	It("should reject other paths", func() {
+		_, ok := MetaIDOf("a/b")
+		Expect(ok).To(BeFalse())
+		_, ok = MetaIDOf("meta")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("normalizePrefix()", func() {
+	It("should append a trailing separator", func() {
+		prefix, err := normalizePrefix("a/b")
+		Expect(err).To(BeNil())
+		Expect(prefix).To(Equal("a/b/"))
+	})
+
+	It("should keep an existing trailing separator", func() {
+		prefix, err := normalizePrefix("a/b/")
+		Expect(err).To(BeNil())
+		Expect(prefix).To(Equal("a/b/"))
+	})
+
+	It("should allow a folder named like the meta segment", func() {
+		prefix, err := normalizePrefix("a/meta")
+		Expect(err).To(BeNil())
+		Expect(prefix).To(Equal("a/meta/"))
+	})
+
+	It("should reject an invalid prefix", func() {
+		_, err := normalizePrefix("a//")
+		Expect(err).NotTo(BeNil())
+		Expect(err.InfoID).To(Equal("a//"))
+	})
+
+	It("should reject the root", func() {
+		for _, prefix := range []string{"", "/"} {
+			_, err := normalizePrefix(prefix)
+			Expect(err).NotTo(BeNil())
+			Expect(err.InfoID).To(Equal(prefix))
+			Expect(err.Reason).To(ContainSubstring("root"))
+		}
+	})
+})
+
+var _ = Describe("rootOf()", func() {
+	It("should return the first segment", func() {
+		Expect(rootOf("a/b/c")).To(Equal("a"))
+		Expect(rootOf("a/")).To(Equal("a"))
+		Expect(rootOf("a")).To(Equal("a"))
+	})
+})
+
+var _ = Describe("childOf()", func() {
+	It("should return a direct child as is", func() {
+		child, folder := childOf("a/", "a/b")
+		Expect(child).To(Equal("a/b"))
+		Expect(folder).To(BeFalse())
+	})
+
+	It("should return the sub folder of a deeper info", func() {
+		child, folder := childOf("a/", "a/b/c/d")
+		Expect(child).To(Equal("a/b/"))
+		Expect(folder).To(BeTrue())
+	})
+})
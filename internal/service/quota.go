@@ -0,0 +1,166 @@
+package service
+
+import (
+	"fmt"
+	"simple-information-store-app/internal/helper"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// usageKeyPrefix is the prefix of storage keys of usage counters.
+const usageKeyPrefix = SystemKeyPrefix + "usage" + NamespaceSeparator
+
+// Kinds of quota.
+const (
+	QuotaItems = "items"
+	QuotaBytes = "bytes"
+)
+
+// QuotaExceededError indicates that a write would exceed a quota of the
+// namespace, or of the owner in the namespace if Owner is not empty.
+type QuotaExceededError struct {
+	Namespace string
+	Owner     string
+	Quota     string
+	Limit     int64
+}
+
+func (err QuotaExceededError) Error() string {
+	if err.Owner != "" {
+		return fmt.Sprintf("Owner %s has reached the quota of %d %s in namespace %s.", err.Owner, err.Limit, err.Quota, err.Namespace)
+	}
+
+	return fmt.Sprintf("Namespace %s has reached its quota of %d %s.", err.Namespace, err.Limit, err.Quota)
+}
+
+// usageKey returns the storage key of the usage counter of the namespace, or
+// of the owner in the namespace if owner is not empty.
+func usageKey(ns Namespace, owner string) string {
+	key := usageKeyPrefix + ns.name()
+	if owner != "" {
+		key += NamespaceSeparator + owner
+	}
+
+	return key
+}
+
+// parseUsageKey returns the namespace and owner of a usage counter key.
+func parseUsageKey(key string) (namespace, owner string, ok bool) {
+	if !strings.HasPrefix(key, usageKeyPrefix) {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, usageKeyPrefix), NamespaceSeparator, 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1], true
+	}
+
+	return parts[0], "", true
+}
+
+// quotaLimits returns the item and byte quota of the namespace, or of the owner
+// in the namespace if owner is not empty. 0 means unlimited.
+func quotaLimits(ns Namespace, owner string) (items, bytes int64) {
+	if owner != "" {
+		return int64(ns.OwnerItemQuota), ns.OwnerByteQuota
+	}
+
+	return int64(ns.ItemQuota), ns.ByteQuota
+}
+
+// usageUpdates returns the transaction items that add items and bytes to the
+// usage of the namespace and of the owner. The updates fail if a quota would
+// be exceeded. checkQuota must have been called before with the same deltas.
+func (t valueTable) usageUpdates(ns Namespace, owner string, items, bytes int) []*dynamodb.TransactWriteItem {
+	valueTableName := t.name
+	updates := []*dynamodb.TransactWriteItem{}
+	for _, o := range usageOwners(owner) {
+		key := usageKey(ns, o)
+		update := &dynamodb.Update{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &key},
+			},
+			UpdateExpression: helper.StringPtr("ADD ItemCount :items, ByteCount :bytes"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":items": {N: helper.StringPtr(strconv.Itoa(items))},
+				":bytes": {N: helper.StringPtr(strconv.Itoa(bytes))},
+			},
+			ReturnValuesOnConditionCheckFailure: helper.StringPtr(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+		}
+
+		conditions := []string{}
+		itemQuota, byteQuota := quotaLimits(ns, o)
+		if items > 0 && itemQuota > 0 {
+			conditions = append(conditions, "(attribute_not_exists(ItemCount) OR ItemCount <= :maxItems)")
+			update.ExpressionAttributeValues[":maxItems"] = &dynamodb.AttributeValue{
+				N: helper.StringPtr(strconv.FormatInt(itemQuota-int64(items), 10)),
+			}
+		}
+
+		if bytes > 0 && byteQuota > 0 {
+			conditions = append(conditions, "(attribute_not_exists(ByteCount) OR ByteCount <= :maxBytes)")
+			update.ExpressionAttributeValues[":maxBytes"] = &dynamodb.AttributeValue{
+				N: helper.StringPtr(strconv.FormatInt(byteQuota-int64(bytes), 10)),
+			}
+		}
+
+		if len(conditions) > 0 {
+			update.ConditionExpression = helper.StringPtr(strings.Join(conditions, " AND "))
+		}
+
+		updates = append(updates, &dynamodb.TransactWriteItem{Update: update})
+	}
+
+	return updates
+}
+
+// checkQuota returns QuotaExceededError if a single write of items and bytes
+// exceeds a quota, regardless of the current usage.
+func checkQuota(ns Namespace, owner string, items, bytes int) *QuotaExceededError {
+	for _, o := range usageOwners(owner) {
+		itemQuota, byteQuota := quotaLimits(ns, o)
+		if itemQuota > 0 && int64(items) > itemQuota {
+			return &QuotaExceededError{Namespace: ns.name(), Owner: o, Quota: QuotaItems, Limit: itemQuota}
+		}
+
+		if byteQuota > 0 && int64(bytes) > byteQuota {
+			return &QuotaExceededError{Namespace: ns.name(), Owner: o, Quota: QuotaBytes, Limit: byteQuota}
+		}
+	}
+
+	return nil
+}
+
+// quotaExceededErrorOf returns QuotaExceededError if err is a canceled
+// transaction and one of the usage updates returned by usageUpdates, starting
+// at index offset, failed.
+func quotaExceededErrorOf(err error, offset int, ns Namespace, owner string, items int) *QuotaExceededError {
+	for i, o := range usageOwners(owner) {
+		if !isConditionalCheckFailedAt(err, offset+i) {
+			continue
+		}
+
+		itemQuota, byteQuota := quotaLimits(ns, o)
+		oldUsage := usageOf(err.(*dynamodb.TransactionCanceledException).CancellationReasons[offset+i].Item)
+		if itemQuota > 0 && items > 0 && oldUsage.ItemCount+int64(items) > itemQuota {
+			return &QuotaExceededError{Namespace: ns.name(), Owner: o, Quota: QuotaItems, Limit: itemQuota}
+		}
+
+		return &QuotaExceededError{Namespace: ns.name(), Owner: o, Quota: QuotaBytes, Limit: byteQuota}
+	}
+
+	return nil
+}
+
+// usageOwners returns the owners whose usage is affected by a write of the
+// owner, i.e. the namespace itself ("") and the owner if not empty.
+func usageOwners(owner string) []string {
+	if owner == "" {
+		return []string{""}
+	}
+
+	return []string{"", owner}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("usageKey()", func() {
+	It("should return distinct keys for namespaces and owners", func() {
+		ns := Namespace{Name: "team-a"}
+		Expect(usageKey(ns, "")).To(Equal("#usage#team-a"))
+		Expect(usageKey(ns, "key-id")).To(Equal("#usage#team-a#key-id"))
+		Expect(usageKey(Namespace{}, "")).To(Equal("#usage#default"))
+	})
+
+	It("should be parsed by parseUsageKey()", func() {
+		namespace, owner, ok := parseUsageKey(usageKey(Namespace{Name: "team-a"}, "arn#1"))
+		Expect(ok).To(BeTrue())
+		Expect(namespace).To(Equal("team-a"))
+		Expect(owner).To(Equal("arn#1"))
+
+		_, _, ok = parseUsageKey("team-a#a/b")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("checkQuota()", func() {
+	ns := Namespace{Name: "team-a", ItemQuota: 10, ByteQuota: 100, OwnerByteQuota: 50}
+
+	It("should accept a write within all quotas", func() {
+		Expect(checkQuota(ns, "owner", 1, 50)).To(BeNil())
+	})
+
+	It("should reject a value larger than the namespace quota", func() {
+		Expect(checkQuota(ns, "", 1, 101)).To(Equal(&QuotaExceededError{
+			Namespace: "team-a", Quota: QuotaBytes, Limit: 100,
+		}))
+	})
+
+	It("should reject a value larger than the owner quota", func() {
+		Expect(checkQuota(ns, "owner", 1, 51)).To(Equal(&QuotaExceededError{
+			Namespace: "team-a", Owner: "owner", Quota: QuotaBytes, Limit: 50,
+		}))
+	})
+})
+
+var _ = Describe("usageUpdates()", func() {
+	ns := Namespace{Name: "team-a", ItemQuota: 10, OwnerItemQuota: 5}
+	table := valueTable{name: "table"}
+
+	It("should update the namespace and the owner", func() {
+		updates := table.usageUpdates(ns, "owner", 1, 10)
+		Expect(updates).To(HaveLen(2))
+		Expect(*updates[0].Update.TableName).To(Equal("table"))
+		Expect(*updates[0].Update.Key["Id"].S).To(Equal("#usage#team-a"))
+		Expect(*updates[1].Update.Key["Id"].S).To(Equal("#usage#team-a#owner"))
+		Expect(*updates[0].Update.ExpressionAttributeValues[":maxItems"].N).To(Equal("9"))
+		Expect(*updates[1].Update.ExpressionAttributeValues[":maxItems"].N).To(Equal("4"))
+	})
+
+	It("should only update the namespace of anonymous writes", func() {
+		Expect(table.usageUpdates(ns, "", 1, 10)).To(HaveLen(1))
+	})
+
+	It("should not check quotas when usage decreases", func() {
+		for _, update := range table.usageUpdates(ns, "owner", -1, -10) {
+			Expect(update.Update.ConditionExpression).To(BeNil())
+		}
+	})
+})
+
+var _ = Describe("quotaExceededErrorOf()", func() {
+	ns := Namespace{Name: "team-a", ItemQuota: 10, OwnerByteQuota: 50}
+
+	canceled := func(codes ...string) error {
+		reasons := []*dynamodb.CancellationReason{}
+		for _, code := range codes {
+			c := code
+			reasons = append(reasons, &dynamodb.CancellationReason{
+				Code: &c,
+				Item: map[string]*dynamodb.AttributeValue{
+					"ItemCount": {N: stringPtr("10")},
+					"ByteCount": {N: stringPtr("45")},
+				},
+			})
+		}
+		return &dynamodb.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	It("should return nil if no usage update failed", func() {
+		Expect(quotaExceededErrorOf(canceled("ConditionalCheckFailed", "None", "None"), 1, ns, "owner", 1)).To(BeNil())
+	})
+
+	It("should return the exceeded item quota of the namespace", func() {
+		err := quotaExceededErrorOf(canceled("None", "ConditionalCheckFailed", "None"), 1, ns, "owner", 1)
+		Expect(err).To(Equal(&QuotaExceededError{Namespace: "team-a", Quota: QuotaItems, Limit: 10}))
+	})
+
+	It("should return the exceeded byte quota of the owner", func() {
+		err := quotaExceededErrorOf(canceled("None", "None", "ConditionalCheckFailed"), 1, ns, "owner", 1)
+		Expect(err).To(Equal(&QuotaExceededError{Namespace: "team-a", Owner: "owner", Quota: QuotaBytes, Limit: 50}))
+	})
+})
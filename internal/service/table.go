@@ -0,0 +1,52 @@
+package service
+
+import (
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper/awshelper"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// valueTable is the value table of the configuration, which the services
+// store infos in.
+type valueTable struct {
+	name           string
+	dynamoDbClient *dynamodb.DynamoDB
+
+	// retries is the budget of retries of transient errors of the service.
+	retries *retryBudget
+
+	// batch tables retry calls with batchRetries, others with apiRetries.
+	batch bool
+}
+
+// valueTableOf returns the value table of the configuration. Its client is
+// created once, so that services created on startup reuse it in all
+// invocations.
+func valueTableOf(c config.Config) valueTable {
+	return valueTable{
+		name:           c.ValueTableName,
+		dynamoDbClient: awshelper.GetDynamoDbClient(c.DynamoDbEndpoint, c.Region),
+		retries:        newRetryBudget(),
+	}
+}
+
+// client returns the DynamoDB client of the table.
+func (t valueTable) client() *dynamodb.DynamoDB {
+	return t.dynamoDbClient
+}
+
+// asBatch returns the table for batch jobs, which retry calls longer.
+func (t valueTable) asBatch() valueTable {
+	t.batch = true
+	return t
+}
+
+// retryPolicy returns the retry policy of calls of the table.
+func (t valueTable) retryPolicy() retryPolicy {
+	if t.batch {
+		return batchRetries
+	}
+
+	return apiRetries
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Record presents an info with its metadata as exported, e.g. as a line of
+// NDJSON. Only Namespace, ID and Value are required to import a record.
+type Record struct {
+	// Namespace is the name of the namespace, the default namespace if empty.
+	Namespace string `json:"namespace,omitempty"`
+
+	ID          string            `json:"id"`
+	Value       string            `json:"value"`
+	Owner       string            `json:"owner,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	CreatedAt   *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt   *time.Time        `json:"updatedAt,omitempty"`
+	ExpiresAt   *time.Time        `json:"expiresAt,omitempty"`
+	Version     int64             `json:"version,omitempty"`
+}
+
+// ConflictPolicy is what ImportInfo does if an info with the id of the
+// record already exists.
+type ConflictPolicy string
+
+// Conflict policies of ImportInfo.
+const (
+	// ConflictSkip keeps the existing info.
+	ConflictSkip ConflictPolicy = "skip"
+
+	// ConflictOverwrite replaces the existing info with the record.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+
+	// ConflictFail returns InfoAlreadyExistsError.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ExportCheckpoint presents how far the segments of an export are scanned,
+// so that an interrupted export can be resumed.
+type ExportCheckpoint struct {
+	Segments []ExportSegment `json:"segments"`
+}
+
+// ExportSegment presents how far a segment of an export is scanned.
+type ExportSegment struct {
+	// LastKey is the storage key of the last scanned item, empty if the
+	// segment is not scanned yet.
+	LastKey string `json:"lastKey,omitempty"`
+
+	Done bool `json:"done,omitempty"`
+}
+
+// NewExportCheckpoint returns the checkpoint of an export which has not
+// started yet, scanning the table in the number of parallel segments.
+func NewExportCheckpoint(segments int) ExportCheckpoint {
+	return ExportCheckpoint{Segments: make([]ExportSegment, segments)}
+}
+
+// Done returns if all segments are scanned.
+func (c ExportCheckpoint) Done() bool {
+	for _, segment := range c.Segments {
+		if !segment.Done {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c ExportCheckpoint) clone() ExportCheckpoint {
+	return ExportCheckpoint{Segments: append([]ExportSegment{}, c.Segments...)}
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoExporter
+
+type InfoExporter interface {
+	// ExportInfos scans the infos of all namespaces in the parallel segments
+	// of the checkpoint, resuming after the scanned items. fn is called with
+	// the records of each scanned page and the checkpoint after it. Calls of
+	// fn are not concurrent, so that the records can be written before the
+	// checkpoint is saved. The export stops at the first error of fn.
+	// Items are read strongly consistent, so that all writes acknowledged
+	// before the export are exported. Expired infos and items which are not
+	// infos are not exported.
+	ExportInfos(ctx context.Context, checkpoint ExportCheckpoint, fn func(records []Record, checkpoint ExportCheckpoint) error) error
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . InfoImporter
+
+type InfoImporter interface {
+	// ImportInfo stores the record with its metadata and returns if it is
+	// stored, false if it is skipped according to the policy. Limits and
+	// quotas of the namespace are not checked and usage is not counted, so
+	// usage should be reconciled after importing.
+	// InvalidNamespaceError is returned if the namespace is not a valid name.
+	// InvalidIDError is returned if the id is not a valid path.
+	// InfoAlreadyExistsError is returned if the info exists and the policy is
+	// ConflictFail.
+	ImportInfo(ctx context.Context, record Record, policy ConflictPolicy) (bool, error)
+}
+
+type InfoTransferService interface {
+	InfoExporter
+	InfoImporter
+}
+
+type infoTransferService struct {
+	valueTable
+}
+
+func NewInfoTransferService(c config.Config) InfoTransferService {
+	return infoTransferService{valueTable: valueTableOf(c).asBatch()}
+}
+
+func (s infoTransferService) ExportInfos(ctx context.Context, checkpoint ExportCheckpoint, fn func(records []Record, checkpoint ExportCheckpoint) error) error {
+	return s.scanTable(ctx, checkpoint, dynamodb.ScanInput{}, func(items []map[string]*dynamodb.AttributeValue, checkpoint ExportCheckpoint) error {
+		return fn(recordsOf(items), checkpoint)
+	})
+}
+
+// scanTable scans the value table with the input in the parallel segments of
+// the checkpoint, with consistent reads. fn is called with the items of each
+// page and the checkpoint after it. Calls of fn are serialized, and all
+// segments stop at the first error.
+func (t valueTable) scanTable(ctx context.Context, checkpoint ExportCheckpoint, input dynamodb.ScanInput, fn func(items []map[string]*dynamodb.AttributeValue, checkpoint ExportCheckpoint) error) error {
+	checkpoint = checkpoint.clone()
+	dynamoDbClient := t.client()
+	valueTableName := t.name
+	totalSegments := int64(len(checkpoint.Segments))
+
+	var (
+		mutex    sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	// failed records the first error, after which all segments stop.
+	failed := func(err error) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		return firstErr != nil
+	}
+
+	for i := range checkpoint.Segments {
+		if checkpoint.Segments[i].Done {
+			continue
+		}
+
+		wg.Add(1)
+		go func(segment int64) {
+			defer wg.Done()
+
+			lastKey := checkpoint.Segments[segment].LastKey
+			for !failed(nil) {
+				input := input
+				input.TableName = &valueTableName
+				input.ConsistentRead = helper.BoolPtr(true)
+				input.Segment = &segment
+				input.TotalSegments = &totalSegments
+				if lastKey != "" {
+					input.ExclusiveStartKey = map[string]*dynamodb.AttributeValue{
+						"Id": {S: helper.StringPtr(lastKey)},
+					}
+				}
+
+				var page *dynamodb.ScanOutput
+				err := t.call(ctx, func() error {
+					var err error
+					page, err = dynamoDbClient.ScanWithContext(ctx, &input)
+					return err
+				})
+
+				if failed(err) {
+					return
+				}
+
+				lastKey = stringOf(page.LastEvaluatedKey["Id"])
+
+				mutex.Lock()
+				checkpoint.Segments[segment] = ExportSegment{LastKey: lastKey, Done: lastKey == ""}
+				if firstErr == nil {
+					firstErr = fn(page.Items, checkpoint.clone())
+				}
+				mutex.Unlock()
+
+				if lastKey == "" {
+					return
+				}
+			}
+		}(int64(i))
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+func (s infoTransferService) ImportInfo(ctx context.Context, record Record, policy ConflictPolicy) (bool, error) {
+	// Keys of records of invalid namespaces could collide with system keys,
+	// e.g. of usage counters.
+	if record.Namespace != "" && !config.ValidNamespaceName(record.Namespace) {
+		return false, InvalidNamespaceError{Namespace: record.Namespace}
+	}
+
+	if err := checkID(record.ID); err != nil {
+		return false, *err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: helper.StringPtr(s.name),
+		Item:      itemOf(record, time.Now()),
+	}
+	if policy != ConflictOverwrite {
+		input.ConditionExpression = helper.StringPtr("attribute_not_exists(Id)")
+	}
+
+	dynamoDbClient := s.client()
+	err := s.call(ctx, func() error {
+		_, err := dynamoDbClient.PutItemWithContext(ctx, input)
+		return err
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		if policy == ConflictSkip {
+			return false, nil
+		}
+
+		return false, InfoAlreadyExistsError{
+			InfoID: record.ID,
+		}
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// recordsOf returns the records of the infos of the scanned items.
+func recordsOf(items []map[string]*dynamodb.AttributeValue) []Record {
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		key := stringOf(item["Id"])
+		if key == "" || strings.HasPrefix(key, SystemKeyPrefix) || item["Value"] == nil || isExpired(item) {
+			continue
+		}
+
+		ns := Namespace{Name: namespaceOfKey(key)}
+		info := infoOf(ns, item)
+		meta := infoMetaOf(ns, item)
+		record := Record{
+			ID:        info.ID,
+			Value:     info.Value,
+			Owner:     info.Owner,
+			CreatedAt: optionalTime(info.CreatedAt),
+			UpdatedAt: optionalTime(info.UpdatedAt),
+			ExpiresAt: optionalTime(info.ExpiresAt),
+			Version:   info.Version,
+		}
+
+		if ns.name() != DefaultNamespace {
+			record.Namespace = ns.Name
+		}
+
+		if meta.ContentType != DefaultContentType {
+			record.ContentType = meta.ContentType
+		}
+
+		if len(meta.Tags) > 0 {
+			record.Tags = meta.Tags
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// itemOf returns the item of the record. Timestamps missing in the record
+// are now, the version is 1 if it is missing.
+func itemOf(record Record, now time.Time) map[string]*dynamodb.AttributeValue {
+	key := Namespace{Name: record.Namespace}.key(record.ID)
+	root := rootOf(key)
+	value := record.Value
+	hash := contentHash(value)
+	version := record.Version
+	if version < 1 {
+		version = 1
+	}
+
+	createdAt, updatedAt := now, now
+	if record.CreatedAt != nil {
+		createdAt = *record.CreatedAt
+	}
+
+	if record.UpdatedAt != nil {
+		updatedAt = *record.UpdatedAt
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"Id":        {S: &key},
+		"Root":      {S: &root},
+		"Value":     {S: &value},
+		"CreatedAt": {N: helper.StringPtr(formatTime(createdAt))},
+		"UpdatedAt": {N: helper.StringPtr(formatTime(updatedAt))},
+		"Hash":      {S: &hash},
+		"Size":      {N: helper.StringPtr(formatSize(value))},
+		"Version":   {N: helper.StringPtr(strconv.FormatInt(version, 10))},
+
+		"SchemaVersion": {N: helper.StringPtr(strconv.Itoa(SchemaVersion))},
+	}
+
+	if record.Owner != "" {
+		item["Owner"] = &dynamodb.AttributeValue{S: helper.StringPtr(record.Owner)}
+	}
+
+	if record.ExpiresAt != nil {
+		item["ExpiresAt"] = &dynamodb.AttributeValue{N: helper.StringPtr(strconv.FormatInt(record.ExpiresAt.Unix(), 10))}
+	}
+
+	setAttributes(item, InfoAttributes{ContentType: record.ContentType, Tags: record.Tags})
+	return item
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
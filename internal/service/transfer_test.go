@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recordsOf()", func() {
+	It("should return the records of infos with their namespace", func() {
+		records := recordsOf([]map[string]*dynamodb.AttributeValue{
+			{"Id": {S: stringPtr("a/b")}, "Value": {S: stringPtr("value")}, "Version": {N: stringPtr("2")}},
+			{"Id": {S: stringPtr("team-a#c")}, "Value": {S: stringPtr("c")}, "Owner": {S: stringPtr("owner")}, "ContentType": {S: stringPtr("application/json")}},
+		})
+
+		Expect(records).To(Equal([]Record{
+			{ID: "a/b", Value: "value", Version: 2},
+			{Namespace: "team-a", ID: "c", Value: "c", Owner: "owner", ContentType: "application/json", Version: 1},
+		}))
+	})
+
+	It("should skip usage counters and expired infos", func() {
+		expired := time.Now().Add(-time.Hour).Unix()
+		records := recordsOf([]map[string]*dynamodb.AttributeValue{
+			{"Id": {S: stringPtr(SystemKeyPrefix + "usage#default")}, "ItemCount": {N: stringPtr("1")}},
+			{"Id": {S: stringPtr("expired")}, "Value": {S: stringPtr("value")}, "ExpiresAt": {N: stringPtr(strconv.FormatInt(expired, 10))}},
+		})
+
+		Expect(records).To(BeEmpty())
+	})
+})
+
+var _ = Describe("itemOf()", func() {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	It("should return the item of the record in its namespace", func() {
+		item := itemOf(Record{Namespace: "team-a", ID: "a/b", Value: "value"}, now)
+		Expect(*item["Id"].S).To(Equal("team-a#a/b"))
+		Expect(*item["Root"].S).To(Equal("team-a#a"))
+		Expect(*item["Hash"].S).To(Equal(contentHash("value")))
+		Expect(*item["Size"].N).To(Equal("5"))
+		Expect(*item["Version"].N).To(Equal("1"))
+		Expect(*item["CreatedAt"].N).To(Equal(formatTime(now)))
+		Expect(item).NotTo(HaveKey("Owner"))
+		Expect(item).NotTo(HaveKey("ExpiresAt"))
+	})
+
+	It("should round-trip the record through recordsOf()", func() {
+		createdAt := now.Add(-time.Hour)
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		record := Record{
+			Namespace:   "team-a",
+			ID:          "a/b",
+			Value:       "value",
+			Owner:       "owner",
+			ContentType: "application/json",
+			Tags:        map[string]string{"k": "v"},
+			CreatedAt:   &createdAt,
+			UpdatedAt:   &now,
+			ExpiresAt:   &expiresAt,
+			Version:     3,
+		}
+
+		records := recordsOf([]map[string]*dynamodb.AttributeValue{itemOf(record, now)})
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].ID).To(Equal(record.ID))
+		Expect(records[0].Namespace).To(Equal(record.Namespace))
+		Expect(records[0].Owner).To(Equal(record.Owner))
+		Expect(records[0].ContentType).To(Equal(record.ContentType))
+		Expect(records[0].Tags).To(Equal(record.Tags))
+		Expect(*records[0].CreatedAt).To(BeTemporally("==", createdAt))
+		Expect(*records[0].UpdatedAt).To(BeTemporally("==", now))
+		Expect(*records[0].ExpiresAt).To(BeTemporally("==", expiresAt))
+		Expect(records[0].Version).To(Equal(int64(3)))
+	})
+})
+
+var _ = Describe("ImportInfo()", func() {
+	It("should reject records of invalid namespaces before storing them", func() {
+		for _, namespace := range []string{SystemKeyPrefix + "usage", "team#a", "Team A"} {
+			_, err := infoTransferService{}.ImportInfo(context.Background(), Record{Namespace: namespace, ID: "id", Value: "value"}, ConflictFail)
+			Expect(err).To(Equal(InvalidNamespaceError{Namespace: namespace}))
+		}
+	})
+
+	It("should reject records of invalid ids before storing them", func() {
+		_, err := infoTransferService{}.ImportInfo(context.Background(), Record{ID: "usage#default", Value: "value"}, ConflictFail)
+		Expect(err).To(BeAssignableToTypeOf(InvalidIDError{}))
+	})
+})
+
+var _ = Describe("ExportCheckpoint", func() {
+	It("should be done when all segments are done", func() {
+		checkpoint := NewExportCheckpoint(2)
+		Expect(checkpoint.Done()).To(BeFalse())
+
+		checkpoint.Segments[0].Done = true
+		Expect(checkpoint.Done()).To(BeFalse())
+
+		checkpoint.Segments[1].Done = true
+		Expect(checkpoint.Done()).To(BeTrue())
+	})
+})
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Usage presents the storage used by a namespace, or by an owner in the
+// namespace if Owner is not empty.
+type Usage struct {
+	Namespace string
+	Owner     string
+	ItemCount int64
+	ByteCount int64
+	ItemQuota int64
+	ByteQuota int64
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . UsageGetter
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o ../servicefakes . UsageReconciler
+
+type UsageGetter interface {
+	// GetUsage returns the usage of the namespace, or of the owner in the
+	// namespace if owner is not empty.
+	GetUsage(ctx context.Context, ns Namespace, owner string) (Usage, error)
+}
+
+type UsageReconciler interface {
+	// ReconcileUsage scans all infos and corrects the usage counters of all
+	// namespaces and owners by the difference to the actual usage. It returns
+	// the number of counters written. It repairs counters which drifted, e.g.
+	// because expired infos are deleted by DynamoDB without updating the
+	// usage.
+	ReconcileUsage(ctx context.Context) (int, error)
+}
+
+type UsageService interface {
+	UsageGetter
+	UsageReconciler
+}
+
+type usageService struct {
+	valueTable
+}
+
+func NewUsageService(c config.Config) UsageService {
+	return usageService{valueTable: valueTableOf(c)}
+}
+
+func (s usageService) GetUsage(ctx context.Context, ns Namespace, owner string) (Usage, error) {
+	key := usageKey(ns, owner)
+	dynamoDbClient := s.client()
+	valueTableName := s.name
+	var result *dynamodb.GetItemOutput
+	err := s.call(ctx, func() error {
+		var err error
+		result, err = dynamoDbClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &key},
+			},
+			ConsistentRead: helper.BoolPtr(true),
+		})
+		return err
+	})
+
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := usageOf(result.Item)
+	usage.Namespace = ns.name()
+	usage.Owner = owner
+	usage.ItemQuota, usage.ByteQuota = quotaLimits(ns, owner)
+	return usage, nil
+}
+
+func (s usageService) ReconcileUsage(ctx context.Context) (int, error) {
+	t := s.asBatch()
+	tally := newUsageTally()
+	dynamoDbClient := t.client()
+	valueTableName := t.name
+	input := &dynamodb.ScanInput{
+		TableName:            &valueTableName,
+		ConsistentRead:       helper.BoolPtr(true),
+		ProjectionExpression: helper.StringPtr("Id, #Owner, #Value, ExpiresAt, ItemCount, ByteCount"),
+		ExpressionAttributeNames: map[string]*string{
+			"#Owner": helper.StringPtr("Owner"),
+			"#Value": helper.StringPtr("Value"),
+		},
+	}
+
+	// Pages are scanned one by one, so that only the page which fails is
+	// retried.
+	for {
+		var page *dynamodb.ScanOutput
+		err := t.call(ctx, func() error {
+			var err error
+			page, err = dynamoDbClient.ScanWithContext(ctx, input)
+			return err
+		})
+
+		if err != nil {
+			return 0, err
+		}
+
+		tally.add(page.Items)
+		if len(page.LastEvaluatedKey) == 0 {
+			break
+		}
+
+		input.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+
+	// The differences are added rather than the counters overwritten, so that
+	// writes which update the counters during the scan are kept. Counters
+	// which are missing in the scan are only created if they are still
+	// missing, otherwise the write which created them counted the infos.
+	written := 0
+	for key, diff := range tally.differences() {
+		k := key
+		input := &dynamodb.UpdateItemInput{
+			TableName: &valueTableName,
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &k},
+			},
+			UpdateExpression: helper.StringPtr("ADD ItemCount :items, ByteCount :bytes"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":items": {N: helper.StringPtr(strconv.FormatInt(diff.ItemCount, 10))},
+				":bytes": {N: helper.StringPtr(strconv.FormatInt(diff.ByteCount, 10))},
+			},
+		}
+		if _, ok := tally.stored[key]; !ok {
+			input.ConditionExpression = helper.StringPtr("attribute_not_exists(Id)")
+		}
+
+		err := t.call(ctx, func() error {
+			_, err := dynamoDbClient.UpdateItemWithContext(ctx, input)
+			return err
+		})
+
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			continue
+		}
+
+		if err != nil {
+			return written, err
+		}
+
+		written++
+	}
+
+	return written, nil
+}
+
+// usageTally sums up the actual usage of scanned infos and the usage stored
+// in scanned counters, by the storage key of the counter.
+type usageTally struct {
+	actual map[string]Usage
+	stored map[string]Usage
+}
+
+func newUsageTally() usageTally {
+	return usageTally{
+		actual: map[string]Usage{},
+		stored: map[string]Usage{},
+	}
+}
+
+// add adds the scanned items, infos and counters, to the tally. Expired infos
+// and other system items are skipped.
+func (t usageTally) add(items []map[string]*dynamodb.AttributeValue) {
+	for _, item := range items {
+		key := *item["Id"].S
+		if _, _, ok := parseUsageKey(key); ok {
+			t.stored[key] = usageOf(item)
+			continue
+		}
+
+		if strings.HasPrefix(key, SystemKeyPrefix) || isExpired(item) {
+			continue
+		}
+
+		ns := Namespace{Name: namespaceOfKey(key)}
+		bytes := int64(len(stringOf(item["Value"])))
+		for _, o := range usageOwners(stringOf(item["Owner"])) {
+			k := usageKey(ns, o)
+			usage := t.actual[k]
+			usage.ItemCount++
+			usage.ByteCount += bytes
+			t.actual[k] = usage
+		}
+	}
+}
+
+// differences returns the differences of the actual and the stored usage of
+// the counters which drifted. Stale counters are reset to 0.
+func (t usageTally) differences() map[string]Usage {
+	differences := map[string]Usage{}
+	for key, stored := range t.stored {
+		actual := t.actual[key]
+		if actual != stored {
+			differences[key] = Usage{
+				ItemCount: actual.ItemCount - stored.ItemCount,
+				ByteCount: actual.ByteCount - stored.ByteCount,
+			}
+		}
+	}
+
+	for key, actual := range t.actual {
+		if _, ok := t.stored[key]; !ok {
+			differences[key] = actual
+		}
+	}
+
+	return differences
+}
+
+// usageOf returns the usage stored in a counter item, which may be nil.
+func usageOf(item map[string]*dynamodb.AttributeValue) Usage {
+	return Usage{
+		ItemCount: int64Of(item["ItemCount"]),
+		ByteCount: int64Of(item["ByteCount"]),
+	}
+}
+
+// namespaceOfKey returns the namespace of the storage key of an info.
+func namespaceOfKey(key string) string {
+	if i := strings.Index(key, NamespaceSeparator); i >= 0 {
+		return key[:i]
+	}
+
+	return DefaultNamespace
+}
+
+func stringOf(attr *dynamodb.AttributeValue) string {
+	if attr == nil || attr.S == nil {
+		return ""
+	}
+
+	return *attr.S
+}
+
+func int64Of(attr *dynamodb.AttributeValue) int64 {
+	if attr == nil || attr.N == nil {
+		return 0
+	}
+
+	n, _ := strconv.ParseInt(*attr.N, 10, 64)
+	return n
+}
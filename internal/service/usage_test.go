@@ -0,0 +1,47 @@
+package service
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("usageTally", func() {
+	counter := func(key, items, bytes string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"Id":        {S: stringPtr(key)},
+			"ItemCount": {N: stringPtr(items)},
+			"ByteCount": {N: stringPtr(bytes)},
+		}
+	}
+
+	info := func(key, owner, value string) map[string]*dynamodb.AttributeValue {
+		item := map[string]*dynamodb.AttributeValue{
+			"Id":    {S: stringPtr(key)},
+			"Value": {S: stringPtr(value)},
+		}
+		if owner != "" {
+			item["Owner"] = &dynamodb.AttributeValue{S: stringPtr(owner)}
+		}
+		return item
+	}
+
+	It("should return the differences of the drifted counters", func() {
+		tally := newUsageTally()
+		tally.add([]map[string]*dynamodb.AttributeValue{
+			counter("#usage#team-a", "3", "30"),
+			counter("#usage#team-a#key-a", "1", "5"),
+			counter("#usage#team-b", "2", "10"),
+			info("team-a#a", "key-a", "12345"),
+			info("team-a#b", "", "1234567890"),
+			info("c", "", "value"),
+			{"Id": {S: stringPtr("#ratelimit#key")}},
+		})
+
+		Expect(tally.differences()).To(Equal(map[string]Usage{
+			"#usage#team-a":  {ItemCount: -1, ByteCount: -15},
+			"#usage#team-b":  {ItemCount: -2, ByteCount: -10},
+			"#usage#default": {ItemCount: 1, ByteCount: 5},
+		}))
+	})
+})
@@ -0,0 +1,528 @@
+// Package snapshot writes and restores snapshot archives of the value table.
+//
+// An archive is a gzip compressed tar of manifest.json and infos.ndjson. The
+// infos of all namespaces are sorted by namespace and id, one record per line
+// with the checksum of the record. Archives of identical data are
+// byte-identical, so that they can be compared and deduplicated.
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"simple-information-store-app/internal/service"
+)
+
+// FormatVersion is the version of the layout of archives.
+const FormatVersion = 1
+
+// Names of the files in archives.
+const (
+	manifestName = "manifest.json"
+	infosName    = "infos.ndjson"
+)
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	FormatVersion int `json:"formatVersion"`
+
+	// SchemaVersion is the version of the items of the snapshotted table.
+	SchemaVersion int `json:"schemaVersion"`
+
+	ItemCount int `json:"itemCount"`
+
+	// Checksum is the hex encoded SHA-256 hash of infos.ndjson.
+	Checksum string `json:"checksum"`
+}
+
+// entry is a line of infos.ndjson.
+type entry struct {
+	service.Record
+
+	// Checksum is the hex encoded SHA-256 hash of the JSON of the record.
+	Checksum string `json:"checksum"`
+}
+
+// InvalidArchiveError indicates that an archive is corrupt or cannot be
+// restored by this version.
+type InvalidArchiveError struct {
+	Reason string
+}
+
+func (err InvalidArchiveError) Error() string {
+	return fmt.Sprintf("The snapshot archive is invalid: %s.", err.Reason)
+}
+
+// VerificationError indicates that a restored table does not contain the
+// infos of the archive.
+type VerificationError struct {
+	Missing    int
+	Unexpected int
+	Mismatched int
+}
+
+func (err VerificationError) Error() string {
+	return fmt.Sprintf("The restored table differs from the snapshot: %d infos missing, %d unexpected, %d different.", err.Missing, err.Unexpected, err.Mismatched)
+}
+
+// Write writes the archive of the records and returns its manifest.
+func Write(w io.Writer, records []service.Record) (Manifest, error) {
+	var s sorter
+	defer s.close()
+	if err := s.add(records...); err != nil {
+		return Manifest{}, err
+	}
+
+	return writeArchive(w, &s)
+}
+
+// writeArchive writes the archive of the records of the sorter. infos.ndjson
+// is spooled to a temporary file first, since its size and checksum precede
+// it in the archive.
+func writeArchive(w io.Writer, s *sorter) (Manifest, error) {
+	infos, err := ioutil.TempFile("", "snapshot-infos-")
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	defer os.Remove(infos.Name())
+	defer infos.Close()
+
+	hash := sha256.New()
+	bw := bufio.NewWriter(io.MultiWriter(infos, hash))
+	count, err := s.writeInfos(bw)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return Manifest{}, err
+	}
+
+	size, err := infos.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if _, err := infos.Seek(0, io.SeekStart); err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{
+		FormatVersion: FormatVersion,
+		SchemaVersion: service.SchemaVersion,
+		ItemCount:     count,
+		Checksum:      hex.EncodeToString(hash.Sum(nil)),
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifestJSON = append(manifestJSON, '\n')
+
+	// The gzip header has no name and modification time, and tar headers
+	// have fixed metadata, so that the archive depends on the data only.
+	gw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	tw := tar.NewWriter(gw)
+	if err := writeFile(tw, manifestName, int64(len(manifestJSON)), bytes.NewReader(manifestJSON)); err != nil {
+		return Manifest{}, err
+	}
+
+	if err := writeFile(tw, infosName, size, infos); err != nil {
+		return Manifest{}, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, gw.Close()
+}
+
+// writeFile writes the file of the size with the content of r to the tar.
+func writeFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Unix(0, 0),
+		Format:   tar.FormatUSTAR,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// Read reads and verifies the archive, and calls fn with its records in
+// order. The records are streamed through a temporary file, which the archive
+// is verified in before fn is called, so that fn is only called with the
+// records of a valid archive.
+// InvalidArchiveError is returned if the archive is corrupt or of a newer
+// format or schema version.
+func Read(r io.Reader, fn func(record service.Record) error) (Manifest, error) {
+	a, err := openArchive(r)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	defer a.close()
+	return a.manifest, a.each(func(e entry) error {
+		return fn(e.Record)
+	})
+}
+
+// archive is a verified archive, whose infos.ndjson is spooled to a
+// temporary file.
+type archive struct {
+	manifest Manifest
+	infos    *os.File
+}
+
+// openArchive reads the archive and verifies its manifest, checksums and
+// order of records.
+func openArchive(r io.Reader) (*archive, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, InvalidArchiveError{Reason: err.Error()}
+	}
+
+	a := &archive{}
+	var (
+		manifestJSON []byte
+		sum          string
+	)
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			a.close()
+			return nil, InvalidArchiveError{Reason: err.Error()}
+		}
+
+		switch header.Name {
+		case manifestName:
+			manifestJSON, err = ioutil.ReadAll(io.LimitReader(tr, maxManifestSize))
+		case infosName:
+			sum, err = a.spool(tr)
+		}
+
+		if err != nil {
+			a.close()
+			return nil, InvalidArchiveError{Reason: err.Error()}
+		}
+	}
+
+	if err := a.verify(manifestJSON, sum); err != nil {
+		a.close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// maxManifestSize is the max. size of manifests read.
+const maxManifestSize = 1 << 20
+
+// spool copies infos.ndjson to a temporary file and returns its checksum.
+func (a *archive) spool(r io.Reader) (string, error) {
+	if a.infos != nil {
+		return "", errors.New(infosName + " is duplicated")
+	}
+
+	infos, err := ioutil.TempFile("", "snapshot-infos-")
+	if err != nil {
+		return "", err
+	}
+
+	a.infos = infos
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(infos, hash), r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verify verifies the manifest, the checksum of infos.ndjson and its entries.
+func (a *archive) verify(manifestJSON []byte, sum string) error {
+	if manifestJSON == nil {
+		return InvalidArchiveError{Reason: manifestName + " is missing"}
+	} else if err := json.Unmarshal(manifestJSON, &a.manifest); err != nil {
+		return InvalidArchiveError{Reason: manifestName + " is not valid JSON"}
+	}
+
+	if a.manifest.FormatVersion != FormatVersion {
+		return InvalidArchiveError{Reason: fmt.Sprintf("format version %d is not supported", a.manifest.FormatVersion)}
+	}
+
+	if a.manifest.SchemaVersion > service.SchemaVersion {
+		return InvalidArchiveError{Reason: fmt.Sprintf("schema version %d is newer than %d", a.manifest.SchemaVersion, service.SchemaVersion)}
+	}
+
+	if a.infos == nil {
+		return InvalidArchiveError{Reason: infosName + " is missing"}
+	}
+
+	if sum != a.manifest.Checksum {
+		return InvalidArchiveError{Reason: "the checksum of " + infosName + " does not match"}
+	}
+
+	count := 0
+	err := a.each(func(entry) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if count != a.manifest.ItemCount {
+		return InvalidArchiveError{Reason: fmt.Sprintf("%d infos expected, but %d found", a.manifest.ItemCount, count)}
+	}
+
+	return nil
+}
+
+// each calls fn with the entries of infos.ndjson in order. The checksums of
+// the entries and their order are verified, which the comparison with a
+// restored table relies on.
+func (a *archive) each(fn func(e entry) error) error {
+	c, err := a.cursor()
+	if err != nil {
+		return err
+	}
+
+	previous := ""
+	for line := 1; ; line++ {
+		ok, err := c.next()
+		if err != nil {
+			return InvalidArchiveError{Reason: fmt.Sprintf("line %d of %s is not valid JSON", line, infosName)}
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if sum, err := recordChecksum(c.entry.Record); err != nil || sum != c.entry.Checksum {
+			return InvalidArchiveError{Reason: fmt.Sprintf("the checksum of info %s does not match", c.entry.ID)}
+		}
+
+		if line > 1 && c.key <= previous {
+			return InvalidArchiveError{Reason: fmt.Sprintf("line %d of %s is not sorted", line, infosName)}
+		}
+
+		previous = c.key
+		if err := fn(c.entry); err != nil {
+			return err
+		}
+	}
+}
+
+// cursor returns a cursor at the start of infos.ndjson.
+func (a *archive) cursor() (*runCursor, error) {
+	if _, err := a.infos.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return &runCursor{reader: bufio.NewReader(a.infos)}, nil
+}
+
+// close removes the temporary file.
+func (a *archive) close() {
+	if a.infos != nil {
+		a.infos.Close()
+		os.Remove(a.infos.Name())
+	}
+}
+
+// Take writes the archive of the infos the exporter exports and returns its
+// manifest. The table is scanned in the number of parallel segments, and the
+// records are streamed into the archive through temporary files.
+//
+// Each page of the scan is read consistently, but the pages are read one after
+// another, so that the archive is only a snapshot of one point in time if the
+// table is not written meanwhile. Take a table restored to a point in time,
+// see valuetable.RestoreToPointInTime, for a snapshot of a table in use.
+func Take(ctx context.Context, w io.Writer, exporter service.InfoExporter, segments int) (Manifest, error) {
+	var s sorter
+	defer s.close()
+	if err := exportTo(ctx, &s, exporter, segments); err != nil {
+		return Manifest{}, err
+	}
+
+	return writeArchive(w, &s)
+}
+
+// Restore imports the infos of the archive, which should be restored into a
+// new table, and verifies that the table contains exactly the infos of the
+// archive afterwards. Both the archive and the restored table are streamed
+// through temporary files, so that the memory does not grow with them. The
+// usage of the restored infos is not counted.
+// InvalidArchiveError is returned if the archive is corrupt.
+// InfoAlreadyExistsError is returned if an info of the archive exists.
+// VerificationError is returned if the table differs from the archive.
+func Restore(ctx context.Context, r io.Reader, importer service.InfoImporter, exporter service.InfoExporter, segments int) (Manifest, error) {
+	a, err := openArchive(r)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	defer a.close()
+	err = a.each(func(e entry) error {
+		_, err := importer.ImportInfo(ctx, e.Record, service.ConflictFail)
+		return err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	var restored sorter
+	defer restored.close()
+	if err := exportTo(ctx, &restored, exporter, segments); err != nil {
+		return Manifest{}, err
+	}
+
+	return a.manifest, a.compare(&restored, time.Now())
+}
+
+// exportTo adds the records of the infos of all namespaces to the sorter.
+func exportTo(ctx context.Context, s *sorter, exporter service.InfoExporter, segments int) error {
+	return exporter.ExportInfos(ctx, service.NewExportCheckpoint(segments), func(page []service.Record, _ service.ExportCheckpoint) error {
+		return s.add(page...)
+	})
+}
+
+// compare compares the entries of the archive with the restored records, both
+// in the order of their keys. Infos which have expired since the snapshot are
+// not expected.
+func (a *archive) compare(restored *sorter, now time.Time) error {
+	restoredInfos, err := ioutil.TempFile("", "snapshot-restored-")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(restoredInfos.Name())
+	defer restoredInfos.Close()
+
+	bw := bufio.NewWriter(restoredInfos)
+	if _, err := restored.writeInfos(bw); err != nil {
+		return err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := restoredInfos.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	expected, err := a.cursor()
+	if err != nil {
+		return err
+	}
+
+	actual := &runCursor{reader: bufio.NewReader(restoredInfos)}
+	expectedOK, err := expected.next()
+	if err != nil {
+		return err
+	}
+
+	actualOK, err := actual.next()
+	if err != nil {
+		return err
+	}
+
+	var verificationErr VerificationError
+	for expectedOK || actualOK {
+		advanceExpected, advanceActual := true, true
+		switch {
+		case actualOK && (!expectedOK || actual.key < expected.key):
+			verificationErr.Unexpected++
+			advanceExpected = false
+		case expectedOK && (!actualOK || expected.key < actual.key):
+			if expiresAt := expected.entry.ExpiresAt; expiresAt == nil || expiresAt.After(now) {
+				verificationErr.Missing++
+			}
+			advanceActual = false
+		case expected.entry.Checksum != actual.entry.Checksum:
+			verificationErr.Mismatched++
+		}
+
+		if advanceExpected {
+			if expectedOK, err = expected.next(); err != nil {
+				return err
+			}
+		}
+
+		if advanceActual {
+			if actualOK, err = actual.next(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if verificationErr != (VerificationError{}) {
+		return verificationErr
+	}
+
+	return nil
+}
+
+// normalize returns the record with times in UTC, so that its JSON does not
+// depend on the time zone of the machine.
+func normalize(record service.Record) service.Record {
+	for _, t := range []**time.Time{&record.CreatedAt, &record.UpdatedAt, &record.ExpiresAt} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+
+	return record
+}
+
+// keyOf returns the key records are sorted and compared by. It is unique,
+// since namespace names cannot contain the namespace separator.
+func keyOf(record service.Record) string {
+	return record.Namespace + service.NamespaceSeparator + record.ID
+}
+
+func recordChecksum(record service.Record) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	return checksum(data), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
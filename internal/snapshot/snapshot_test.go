@@ -0,0 +1,251 @@
+package snapshot_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/servicefakes"
+	"simple-information-store-app/internal/snapshot"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Write() and Read()", func() {
+	var (
+		records []service.Record
+		archive *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		createdAt := time.Date(2021, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+		records = []service.Record{
+			{ID: "b", Value: "b", Version: 1},
+			{Namespace: "team-a", ID: "a", Value: "a", Owner: "owner", Tags: map[string]string{"y": "2", "x": "1"}, CreatedAt: &createdAt, Version: 2},
+			{ID: "a/b", Value: "a/b", Version: 1},
+		}
+	})
+
+	JustBeforeEach(func() {
+		archive = &bytes.Buffer{}
+		_, err := snapshot.Write(archive, records)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should return the records sorted by namespace and id", func() {
+		manifest, read, err := readAll(bytes.NewReader(archive.Bytes()))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(manifest.FormatVersion).To(Equal(snapshot.FormatVersion))
+		Expect(manifest.SchemaVersion).To(Equal(service.SchemaVersion))
+		Expect(manifest.ItemCount).To(Equal(3))
+
+		Expect(read).To(HaveLen(3))
+		Expect(read[0].ID).To(Equal("a/b"))
+		Expect(read[1].ID).To(Equal("b"))
+		Expect(read[2].ID).To(Equal("a"))
+		Expect(read[2].Namespace).To(Equal("team-a"))
+		Expect(read[2].Tags).To(Equal(records[1].Tags))
+		Expect(*read[2].CreatedAt).To(BeTemporally("==", *records[1].CreatedAt))
+	})
+
+	It("should contain the manifest and the infos", func() {
+		files := filesOf(archive.Bytes())
+		Expect(files).To(HaveKey("manifest.json"))
+		Expect(files["infos.ndjson"]).To(HavePrefix(`{"id":"a/b","value":"a/b","version":1,"checksum":"`))
+	})
+
+	It("should be byte-identical for identical data", func() {
+		reversed := []service.Record{records[2], records[1], records[0]}
+		createdAt := records[1].CreatedAt.UTC()
+		reversed[1].CreatedAt = &createdAt
+
+		other := &bytes.Buffer{}
+		_, err := snapshot.Write(other, reversed)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(other.Bytes()).To(Equal(archive.Bytes()))
+	})
+
+	When("a record is modified", func() {
+		It("should fail the verification", func() {
+			files := filesOf(archive.Bytes())
+			files["infos.ndjson"] = strings.Replace(files["infos.ndjson"], `"value":"b"`, `"value":"c"`, 1)
+
+			_, read, err := readAll(archiveOf(files))
+			Expect(err).To(BeAssignableToTypeOf(snapshot.InvalidArchiveError{}))
+			Expect(read).To(BeEmpty())
+		})
+	})
+
+	When("the records are not sorted", func() {
+		It("should fail before returning records", func() {
+			files := filesOf(archive.Bytes())
+			lines := strings.SplitAfter(files["infos.ndjson"], "\n")
+			lines[0], lines[1] = lines[1], lines[0]
+			files["infos.ndjson"] = strings.Join(lines, "")
+			files["manifest.json"] = checksumReplaced(files["manifest.json"], files["infos.ndjson"])
+
+			_, read, err := readAll(archiveOf(files))
+			Expect(err).To(Equal(snapshot.InvalidArchiveError{Reason: "line 2 of infos.ndjson is not sorted"}))
+			Expect(read).To(BeEmpty())
+		})
+	})
+
+	When("the manifest is missing", func() {
+		It("should fail", func() {
+			files := filesOf(archive.Bytes())
+			delete(files, "manifest.json")
+
+			_, _, err := readAll(archiveOf(files))
+			Expect(err).To(Equal(snapshot.InvalidArchiveError{Reason: "manifest.json is missing"}))
+		})
+	})
+
+	When("the schema version is newer", func() {
+		It("should fail", func() {
+			files := filesOf(archive.Bytes())
+			files["manifest.json"] = strings.Replace(files["manifest.json"], `"schemaVersion": 1`, `"schemaVersion": 99`, 1)
+
+			_, _, err := readAll(archiveOf(files))
+			Expect(err).To(BeAssignableToTypeOf(snapshot.InvalidArchiveError{}))
+			Expect(err.Error()).To(ContainSubstring("schema version 99"))
+		})
+	})
+
+	When("the archive is not gzip compressed", func() {
+		It("should fail", func() {
+			_, _, err := readAll(strings.NewReader("not an archive"))
+			Expect(err).To(BeAssignableToTypeOf(snapshot.InvalidArchiveError{}))
+		})
+	})
+})
+
+var _ = Describe("Take() and Restore()", func() {
+	var (
+		records          []service.Record
+		fakeInfoExporter servicefakes.FakeInfoExporter
+		fakeInfoImporter servicefakes.FakeInfoImporter
+	)
+
+	BeforeEach(func() {
+		records = []service.Record{{ID: "a", Value: "a", Version: 1}, {Namespace: "team-a", ID: "b", Value: "b", Version: 1}}
+		fakeInfoExporter = servicefakes.FakeInfoExporter{}
+		fakeInfoExporter.ExportInfosStub = func(_ context.Context, c service.ExportCheckpoint, fn func([]service.Record, service.ExportCheckpoint) error) error {
+			return fn(records, c)
+		}
+		fakeInfoImporter = servicefakes.FakeInfoImporter{}
+		fakeInfoImporter.ImportInfoReturns(true, nil)
+	})
+
+	take := func() *bytes.Buffer {
+		archive := &bytes.Buffer{}
+		manifest, err := snapshot.Take(context.Background(), archive, &fakeInfoExporter, 4)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(manifest.ItemCount).To(Equal(2))
+		return archive
+	}
+
+	It("should take the snapshot of all infos scanned in parallel", func() {
+		take()
+		_, c, _ := fakeInfoExporter.ExportInfosArgsForCall(0)
+		Expect(c.Segments).To(HaveLen(4))
+	})
+
+	It("should restore the infos and verify them", func() {
+		archive := take()
+
+		manifest, err := snapshot.Restore(context.Background(), archive, &fakeInfoImporter, &fakeInfoExporter, 4)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(manifest.ItemCount).To(Equal(2))
+
+		Expect(fakeInfoImporter.ImportInfoCallCount()).To(Equal(2))
+		_, record, policy := fakeInfoImporter.ImportInfoArgsForCall(0)
+		Expect(record).To(Equal(records[0]))
+		Expect(policy).To(Equal(service.ConflictFail))
+	})
+
+	When("the restored table differs", func() {
+		It("should fail the verification", func() {
+			archive := take()
+			records = []service.Record{{ID: "a", Value: "changed", Version: 1}, {ID: "c", Value: "c", Version: 1}}
+
+			_, err := snapshot.Restore(context.Background(), archive, &fakeInfoImporter, &fakeInfoExporter, 4)
+			Expect(err).To(Equal(snapshot.VerificationError{Missing: 1, Unexpected: 1, Mismatched: 1}))
+		})
+	})
+
+	When("an info has expired since the snapshot", func() {
+		It("should not be expected", func() {
+			expiresAt := time.Now().Add(-time.Minute)
+			records[1].ExpiresAt = &expiresAt
+			archive := take()
+			records = records[:1]
+
+			_, err := snapshot.Restore(context.Background(), archive, &fakeInfoImporter, &fakeInfoExporter, 4)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+})
+
+// readAll returns the manifest and the records of the archive.
+func readAll(r io.Reader) (snapshot.Manifest, []service.Record, error) {
+	records := []service.Record{}
+	manifest, err := snapshot.Read(r, func(record service.Record) error {
+		records = append(records, record)
+		return nil
+	})
+
+	return manifest, records, err
+}
+
+// checksumReplaced returns the manifest with the checksum of the infos.
+func checksumReplaced(manifest, infos string) string {
+	var m snapshot.Manifest
+	Expect(json.Unmarshal([]byte(manifest), &m)).To(Succeed())
+	sum := sha256.Sum256([]byte(infos))
+	return strings.Replace(manifest, m.Checksum, hex.EncodeToString(sum[:]), 1)
+}
+
+// filesOf returns the files of the archive by name.
+func filesOf(archive []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	Expect(err).ShouldNot(HaveOccurred())
+
+	files := map[string]string{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+
+		Expect(err).ShouldNot(HaveOccurred())
+		data, err := ioutil.ReadAll(tr)
+		Expect(err).ShouldNot(HaveOccurred())
+		files[header.Name] = string(data)
+	}
+}
+
+// archiveOf returns an archive of the files.
+func archiveOf(files map[string]string) io.Reader {
+	archive := &bytes.Buffer{}
+	gw := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gw)
+	for name, data := range files {
+		Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))})).To(Succeed())
+		_, err := tw.Write([]byte(data))
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	Expect(tw.Close()).To(Succeed())
+	Expect(gw.Close()).To(Succeed())
+	return archive
+}
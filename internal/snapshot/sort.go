@@ -0,0 +1,188 @@
+package snapshot
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"simple-information-store-app/internal/service"
+)
+
+// runLen is the max. number of records which are sorted in memory. More
+// records are sorted in runs of runLen records, which are spooled to
+// temporary files and merged, so that the memory does not grow with the
+// table.
+var runLen = 10000
+
+// sorter writes the entries of records sorted by namespace and id.
+type sorter struct {
+	records []service.Record
+	runs    []*os.File
+}
+
+// add adds the records, spooling them as a run whenever there are runLen.
+func (s *sorter) add(records ...service.Record) error {
+	for _, record := range records {
+		s.records = append(s.records, normalize(record))
+		if len(s.records) >= runLen {
+			if err := s.spool(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeInfos writes infos.ndjson of the records added so far and returns the
+// number of records.
+func (s *sorter) writeInfos(w io.Writer) (int, error) {
+	if len(s.runs) == 0 {
+		sortRecords(s.records)
+		return len(s.records), writeEntries(w, s.records)
+	}
+
+	if len(s.records) > 0 {
+		if err := s.spool(); err != nil {
+			return 0, err
+		}
+	}
+
+	return s.merge(w)
+}
+
+// close removes the spooled runs.
+func (s *sorter) close() {
+	for _, run := range s.runs {
+		run.Close()
+		os.Remove(run.Name())
+	}
+
+	s.runs = nil
+}
+
+// spool writes the entries of the sorted records to a temporary file.
+func (s *sorter) spool() error {
+	f, err := ioutil.TempFile("", "snapshot-run-")
+	if err != nil {
+		return err
+	}
+
+	s.runs = append(s.runs, f)
+	sortRecords(s.records)
+	w := bufio.NewWriter(f)
+	if err := writeEntries(w, s.records); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	s.records = s.records[:0]
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// merge writes the lines of all runs in the order of their keys to w and
+// returns the number of lines. The lines are copied as they are, so that they
+// are identical to the lines of records sorted in memory.
+func (s *sorter) merge(w io.Writer) (int, error) {
+	cursors := &runCursors{}
+	for _, run := range s.runs {
+		c := &runCursor{reader: bufio.NewReader(run)}
+		if ok, err := c.next(); err != nil {
+			return 0, err
+		} else if ok {
+			heap.Push(cursors, c)
+		}
+	}
+
+	n := 0
+	for cursors.Len() > 0 {
+		c := (*cursors)[0]
+		if _, err := w.Write(c.line); err != nil {
+			return n, err
+		}
+
+		n++
+		if ok, err := c.next(); err != nil {
+			return n, err
+		} else if ok {
+			heap.Fix(cursors, 0)
+		} else {
+			heap.Pop(cursors)
+		}
+	}
+
+	return n, nil
+}
+
+// runCursor is the current line of entries sorted by key, e.g. of a spooled
+// run, with its entry and key.
+type runCursor struct {
+	reader *bufio.Reader
+	line   []byte
+	entry  entry
+	key    string
+}
+
+// next reads the next line and returns false at the end.
+func (c *runCursor) next() (bool, error) {
+	line, err := c.reader.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		return false, nil
+	} else if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	var e entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return false, err
+	}
+
+	c.line, c.entry, c.key = line, e, keyOf(e.Record)
+	return true, nil
+}
+
+// runCursors is a min-heap of cursors by key.
+type runCursors []*runCursor
+
+func (h runCursors) Len() int            { return len(h) }
+func (h runCursors) Less(i, j int) bool  { return h[i].key < h[j].key }
+func (h runCursors) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runCursors) Push(x interface{}) { *h = append(*h, x.(*runCursor)) }
+
+func (h *runCursors) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+func sortRecords(records []service.Record) {
+	sort.Slice(records, func(i, j int) bool {
+		return keyOf(records[i]) < keyOf(records[j])
+	})
+}
+
+// writeEntries writes the entries of the records, one per line.
+func writeEntries(w io.Writer, records []service.Record) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		sum, err := recordChecksum(record)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(entry{Record: record, Checksum: sum}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
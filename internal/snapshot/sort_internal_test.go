@@ -0,0 +1,94 @@
+package snapshot
+
+import (
+	"bytes"
+	"simple-information-store-app/internal/service"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sorter", func() {
+	var records []service.Record
+
+	BeforeEach(func() {
+		records = []service.Record{}
+		for i := 9; i >= 0; i-- {
+			records = append(records, service.Record{ID: "id-" + strconv.Itoa(i), Value: "value", Version: 1})
+			records = append(records, service.Record{Namespace: "team-a", ID: "id-" + strconv.Itoa(i), Value: "value", Version: 1})
+		}
+	})
+
+	infosOf := func(records []service.Record) string {
+		var s sorter
+		defer s.close()
+		Expect(s.add(records...)).To(Succeed())
+
+		var infos bytes.Buffer
+		n, err := s.writeInfos(&infos)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n).To(Equal(len(records)))
+		return infos.String()
+	}
+
+	It("should merge spooled runs like records sorted in memory", func() {
+		inMemory := infosOf(records)
+
+		defer func(l int) { runLen = l }(runLen)
+		runLen = 3
+		Expect(infosOf(records)).To(Equal(inMemory))
+	})
+
+	It("should remove the spooled runs when closed", func() {
+		defer func(l int) { runLen = l }(runLen)
+		runLen = 3
+
+		var s sorter
+		Expect(s.add(records...)).To(Succeed())
+		Expect(s.runs).To(HaveLen(6))
+
+		name := s.runs[0].Name()
+		s.close()
+		Expect(name).NotTo(BeAnExistingFile())
+	})
+})
+
+var _ = Describe("archive", func() {
+	It("should compare a restored table spooled in runs", func() {
+		records := []service.Record{}
+		for i := 9; i >= 0; i-- {
+			records = append(records, service.Record{ID: "id-" + strconv.Itoa(i), Value: "value", Version: 1})
+		}
+
+		var archiveBytes bytes.Buffer
+		_, err := Write(&archiveBytes, records)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		a, err := openArchive(&archiveBytes)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer a.close()
+
+		defer func(l int) { runLen = l }(runLen)
+		runLen = 3
+
+		restored := func(records ...service.Record) *sorter {
+			s := &sorter{}
+			Expect(s.add(records...)).To(Succeed())
+			return s
+		}
+
+		s := restored(records...)
+		defer s.close()
+		Expect(s.runs).NotTo(BeEmpty())
+		Expect(a.compare(s, time.Now())).To(Succeed())
+
+		changed := append([]service.Record{}, records[1:]...)
+		changed[0].Value = "changed"
+		changed = append(changed, service.Record{Namespace: "team-a", ID: "id-0", Value: "value", Version: 1})
+		other := restored(changed...)
+		defer other.close()
+		Expect(a.compare(other, time.Now())).To(Equal(VerificationError{Missing: 1, Unexpected: 1, Mismatched: 1}))
+	})
+})
@@ -0,0 +1,238 @@
+// Package valuetable defines the schema of the value table once, and creates
+// and deletes tables of it, e.g. in DynamoDB local. The input of the AWS CLI
+// in local-dynamodb-value-table.json and ValueTable in template.yaml are
+// generated from and checked against the definition.
+package valuetable
+
+import (
+	"context"
+	"encoding/json"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/helper"
+	"simple-information-store-app/internal/helper/awshelper"
+	"simple-information-store-app/internal/service"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v2"
+)
+
+//go:generate go run ../../cmd/valuetable -o ../../local-dynamodb-value-table.json cli-input
+
+// TimeToLiveAttribute is the attribute DynamoDB deletes expired items by.
+const TimeToLiveAttribute = "ExpiresAt"
+
+// Definition is the schema of a table. Its fields are named like in the input
+// of the CreateTable API and the properties of AWS::DynamoDB::Table.
+type Definition struct {
+	TableName              string                 `json:"TableName,omitempty" yaml:"TableName,omitempty"`
+	KeySchema              []KeyElement           `json:"KeySchema" yaml:"KeySchema"`
+	AttributeDefinitions   []AttributeDefinition  `json:"AttributeDefinitions" yaml:"AttributeDefinitions"`
+	GlobalSecondaryIndexes []GlobalSecondaryIndex `json:"GlobalSecondaryIndexes" yaml:"GlobalSecondaryIndexes"`
+	BillingMode            string                 `json:"BillingMode" yaml:"BillingMode"`
+}
+
+type KeyElement struct {
+	AttributeName string `json:"AttributeName" yaml:"AttributeName"`
+	KeyType       string `json:"KeyType" yaml:"KeyType"`
+}
+
+type AttributeDefinition struct {
+	AttributeName string `json:"AttributeName" yaml:"AttributeName"`
+	AttributeType string `json:"AttributeType" yaml:"AttributeType"`
+}
+
+type GlobalSecondaryIndex struct {
+	IndexName  string       `json:"IndexName" yaml:"IndexName"`
+	KeySchema  []KeyElement `json:"KeySchema" yaml:"KeySchema"`
+	Projection Projection   `json:"Projection" yaml:"Projection"`
+}
+
+type Projection struct {
+	ProjectionType string `json:"ProjectionType" yaml:"ProjectionType"`
+}
+
+// ValueTable returns the definition of the value table with the name. Infos
+// are keyed by their storage key, and listed by the RootIndex.
+func ValueTable(name string) Definition {
+	return Definition{
+		TableName: name,
+		KeySchema: []KeyElement{
+			{AttributeName: "Id", KeyType: dynamodb.KeyTypeHash},
+		},
+		AttributeDefinitions: []AttributeDefinition{
+			{AttributeName: "Id", AttributeType: dynamodb.ScalarAttributeTypeS},
+			{AttributeName: "Root", AttributeType: dynamodb.ScalarAttributeTypeS},
+		},
+		GlobalSecondaryIndexes: []GlobalSecondaryIndex{
+			{
+				IndexName: service.RootIndexName,
+				KeySchema: []KeyElement{
+					{AttributeName: "Root", KeyType: dynamodb.KeyTypeHash},
+					{AttributeName: "Id", KeyType: dynamodb.KeyTypeRange},
+				},
+				Projection: Projection{ProjectionType: dynamodb.ProjectionTypeKeysOnly},
+			},
+		},
+		BillingMode: dynamodb.BillingModePayPerRequest,
+	}
+}
+
+// CLIInput returns the input of aws dynamodb create-table --cli-input-json for
+// the value table in DynamoDB local.
+func CLIInput() ([]byte, error) {
+	b, err := json.MarshalIndent(ValueTable(config.LocalValueTableName), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// templateProperties are the properties of ValueTable in template.yaml.
+// Point-in-time recovery is enabled for snapshots of points in time, see
+// RestoreToPointInTime.
+type templateProperties struct {
+	Definition                       `yaml:",inline"`
+	TimeToLiveSpecification          timeToLiveSpecification          `yaml:"TimeToLiveSpecification"`
+	PointInTimeRecoverySpecification pointInTimeRecoverySpecification `yaml:"PointInTimeRecoverySpecification"`
+}
+
+type timeToLiveSpecification struct {
+	AttributeName string `yaml:"AttributeName"`
+	Enabled       bool   `yaml:"Enabled"`
+}
+
+type pointInTimeRecoverySpecification struct {
+	PointInTimeRecoveryEnabled bool `yaml:"PointInTimeRecoveryEnabled"`
+}
+
+// TemplateProperties returns the properties of ValueTable in template.yaml as
+// YAML. The table name is chosen by CloudFormation.
+func TemplateProperties() ([]byte, error) {
+	return yaml.Marshal(templateProperties{
+		Definition:                       ValueTable(""),
+		TimeToLiveSpecification:          timeToLiveSpecification{AttributeName: TimeToLiveAttribute, Enabled: true},
+		PointInTimeRecoverySpecification: pointInTimeRecoverySpecification{PointInTimeRecoveryEnabled: true},
+	})
+}
+
+// UniqueName returns a name with the prefix which no other table has, e.g.
+// for a test run.
+func UniqueName(prefix string) string {
+	return prefix + "-" + uuid.New().String()
+}
+
+// Create creates the empty value table of the configuration and waits until
+// it is active. It fails if the table exists.
+func Create(c config.Config) error {
+	name := c.ValueTableName
+	dynamoDbClient := awshelper.GetDynamoDbClient(c.DynamoDbEndpoint, c.Region)
+	_, err := dynamoDbClient.CreateTable(createTableInputOf(ValueTable(name)))
+	if err != nil {
+		return err
+	}
+
+	if err := dynamoDbClient.WaitUntilTableExists(&dynamodb.DescribeTableInput{TableName: &name}); err != nil {
+		return err
+	}
+
+	_, err = dynamoDbClient.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+		TableName: &name,
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: helper.StringPtr(TimeToLiveAttribute),
+			Enabled:       helper.BoolPtr(true),
+		},
+	})
+	return err
+}
+
+// Delete deletes the value table of the configuration and waits until it is
+// gone. Deleting a table which does not exist is not an error.
+func Delete(c config.Config) error {
+	name := c.ValueTableName
+	dynamoDbClient := awshelper.GetDynamoDbClient(c.DynamoDbEndpoint, c.Region)
+	_, err := dynamoDbClient.DeleteTable(&dynamodb.DeleteTableInput{TableName: &name})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return dynamoDbClient.WaitUntilTableNotExists(&dynamodb.DescribeTableInput{TableName: &name})
+}
+
+// Restoring a table takes from minutes to hours, depending on its size.
+const (
+	restoreWaitDelay       = 20 * time.Second
+	restoreWaitMaxAttempts = 4 * 180
+)
+
+// RestoreToPointInTime creates the table with the name and the items of the
+// value table of the configuration at the time, or at the latest restorable
+// time if it is zero, from the point-in-time recovery of the value table, and
+// waits until it is active. Point-in-time recovery has to be enabled for the
+// value table, which DynamoDB local does not support.
+func RestoreToPointInTime(ctx context.Context, c config.Config, name string, at time.Time) error {
+	input := &dynamodb.RestoreTableToPointInTimeInput{
+		SourceTableName:     helper.StringPtr(c.ValueTableName),
+		TargetTableName:     &name,
+		BillingModeOverride: helper.StringPtr(dynamodb.BillingModePayPerRequest),
+	}
+	if at.IsZero() {
+		input.UseLatestRestorableTime = helper.BoolPtr(true)
+	} else {
+		input.RestoreDateTime = &at
+	}
+
+	dynamoDbClient := awshelper.GetDynamoDbClient(c.DynamoDbEndpoint, c.Region)
+	if _, err := dynamoDbClient.RestoreTableToPointInTimeWithContext(ctx, input); err != nil {
+		return err
+	}
+
+	return dynamoDbClient.WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{TableName: &name},
+		request.WithWaiterDelay(request.ConstantWaiterDelay(restoreWaitDelay)),
+		request.WithWaiterMaxAttempts(restoreWaitMaxAttempts))
+}
+
+func createTableInputOf(d Definition) *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		TableName:   helper.StringPtr(d.TableName),
+		BillingMode: helper.StringPtr(d.BillingMode),
+		KeySchema:   keySchemaOf(d.KeySchema),
+	}
+
+	for _, a := range d.AttributeDefinitions {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: helper.StringPtr(a.AttributeName),
+			AttributeType: helper.StringPtr(a.AttributeType),
+		})
+	}
+
+	for _, index := range d.GlobalSecondaryIndexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndex{
+			IndexName:  helper.StringPtr(index.IndexName),
+			KeySchema:  keySchemaOf(index.KeySchema),
+			Projection: &dynamodb.Projection{ProjectionType: helper.StringPtr(index.Projection.ProjectionType)},
+		})
+	}
+
+	return input
+}
+
+func keySchemaOf(elements []KeyElement) []*dynamodb.KeySchemaElement {
+	keySchema := make([]*dynamodb.KeySchemaElement, 0, len(elements))
+	for _, e := range elements {
+		keySchema = append(keySchema, &dynamodb.KeySchemaElement{
+			AttributeName: helper.StringPtr(e.AttributeName),
+			KeyType:       helper.StringPtr(e.KeyType),
+		})
+	}
+
+	return keySchema
+}
@@ -0,0 +1,63 @@
+package valuetable_test
+
+import (
+	"io/ioutil"
+	"simple-information-store-app/internal/config"
+	"simple-information-store-app/internal/service"
+	"simple-information-store-app/internal/valuetable"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("ValueTable()", func() {
+	It("should have the index the services list infos by", func() {
+		d := valuetable.ValueTable("name")
+		Expect(d.TableName).To(Equal("name"))
+		Expect(d.GlobalSecondaryIndexes).To(HaveLen(1))
+		Expect(d.GlobalSecondaryIndexes[0].IndexName).To(Equal(service.RootIndexName))
+	})
+})
+
+var _ = Describe("local-dynamodb-value-table.json", func() {
+	It("should be generated from the definition", func() {
+		b, err := ioutil.ReadFile("../../local-dynamodb-value-table.json")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		input, err := valuetable.CLIInput()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(b)).To(Equal(string(input)), "run go generate ./internal/valuetable")
+		Expect(string(b)).To(ContainSubstring(config.LocalValueTableName))
+	})
+})
+
+var _ = Describe("template.yaml", func() {
+	It("should define ValueTable like the definition", func() {
+		b, err := ioutil.ReadFile("../../template.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var template struct {
+			Resources struct {
+				ValueTable struct {
+					Properties yaml.MapSlice `yaml:"Properties"`
+				} `yaml:"ValueTable"`
+			} `yaml:"Resources"`
+		}
+		Expect(yaml.Unmarshal(b, &template)).To(Succeed())
+		properties, err := yaml.Marshal(template.Resources.ValueTable.Properties)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		expected, err := valuetable.TemplateProperties()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(properties)).To(MatchYAML(string(expected)))
+	})
+})
+
+var _ = Describe("UniqueName()", func() {
+	It("should return names with the prefix which differ", func() {
+		name := valuetable.UniqueName("test")
+		Expect(name).To(HavePrefix("test-"))
+		Expect(valuetable.UniqueName("test")).NotTo(Equal(name))
+	})
+})
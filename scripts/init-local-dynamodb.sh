@@ -1,2 +1,2 @@
 docker run --name dynamodb --network sam -p 8000:8000 -d amazon/dynamodb-local
-aws dynamodb create-table --cli-input-json file://local-dynamodb-value-table.json --endpoint-url http://localhost:8000 --no-cli-pager
+AWS_ACCESS_KEY_ID=${AWS_ACCESS_KEY_ID:-local} AWS_SECRET_ACCESS_KEY=${AWS_SECRET_ACCESS_KEY:-local} go run ./cmd/valuetable -dynamodb-endpoint http://localhost:8000 create